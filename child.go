@@ -0,0 +1,65 @@
+package tinyconf
+
+import "reflect"
+
+// Child returns the manager that uses drivers and the logger of the manager, drivers of options are appended on top
+// of them, i.e. they have higher priority. The child parses configs of types registered in the manager without
+// registering them, the manager configs and registry are not changed. Values set by Set of the manager have priority
+// over the child drivers, values set by Set of the child have the highest priority.
+func (c *Manager) Child(opts ...Option) (*Manager, error) {
+	child, err := New(opts...)
+	if err != nil {
+		return nil, err
+	}
+	child.parent = c
+	hasLogger := false
+	for _, opt := range opts {
+		if _, ok := opt.(loggerOption); ok {
+			hasLogger = true
+		}
+	}
+	if !hasLogger {
+		child.log = c.log
+	}
+	drivers := make([]Driver, 0, len(c.drivers)+len(child.drivers))
+	child.drivers = append(append(drivers, c.drivers...), child.drivers...)
+	return child, nil
+}
+
+// getRegistered returns the config registered by type in the manager or its parents. Parents registrations are
+// returned as copies without parsed values cache, because parents drivers differ.
+func (c *Manager) getRegistered(typeOf reflect.Type) (*Registered, bool) {
+	if register, ok := c.registered[typeOf]; ok {
+		return register, true
+	}
+	if c.parent == nil {
+		return nil, false
+	}
+	register, ok := c.parent.getRegistered(typeOf)
+	if !ok {
+		return nil, false
+	}
+	return &Registered{Storage: register.Storage, Config: register.Config}, true
+}
+
+// getRegisteredTypes returns types of configs registered in the manager and its parents.
+func (c *Manager) getRegisteredTypes() []reflect.Type {
+	var types []reflect.Type
+	for m := c; m != nil; m = m.parent {
+		for typeOf := range m.registered {
+			if !containsType(types, typeOf) {
+				types = append(types, typeOf)
+			}
+		}
+	}
+	return types
+}
+
+func containsType(types []reflect.Type, typeOf reflect.Type) bool {
+	for _, item := range types {
+		if item == typeOf {
+			return true
+		}
+	}
+	return false
+}
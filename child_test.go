@@ -0,0 +1,104 @@
+package tinyconf
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager_Child(t *testing.T) {
+	type Auth struct {
+		Issuer string
+	}
+	type Config struct {
+		Name string
+		Port int
+		Auth Auth
+	}
+	log := &testLogger{}
+	parent, _ := New(
+		WithLogger(log),
+		WithDriver(&tlsMockDriver{values: map[string]string{"Name": "global", "Port": "80", "Auth.Issuer": "global"}}),
+	)
+	parentConf := &Config{}
+	assert.NoError(t, parent.Register(parentConf))
+	assert.NoError(t, parent.Parse(parentConf))
+
+	child, err := parent.Child(WithDriver(&tlsMockDriver{values: map[string]string{"Name": "tenant", "Auth.Issuer": "tenant"}}))
+	assert.NoError(t, err)
+	assert.Same(t, log, child.log)
+	assert.Len(t, child.drivers, 2)
+
+	childConf := &Config{}
+	assert.NoError(t, child.Parse(childConf))
+	assert.Equal(t, &Config{Name: "tenant", Port: 80, Auth: Auth{Issuer: "tenant"}}, childConf)
+	assert.Equal(t, &Config{Name: "global", Port: 80, Auth: Auth{Issuer: "global"}}, parentConf)
+	assert.Len(t, parent.registered, 1)
+	assert.Len(t, child.registered, 0)
+
+	auth := &Auth{}
+	assert.NoError(t, child.Parse(auth))
+	assert.Equal(t, "tenant", auth.Issuer)
+
+	assert.NoError(t, parent.Set("Port", 8080))
+	assert.NoError(t, child.Set("Name", "override"))
+	assert.NoError(t, child.Parse(childConf))
+	assert.Equal(t, 8080, childConf.Port)
+	assert.Equal(t, "override", childConf.Name)
+	assert.NoError(t, parent.Parse(parentConf))
+	assert.Equal(t, "global", parentConf.Name)
+
+	assert.NoError(t, child.Register(&Config{}))
+	assert.Len(t, parent.registered, 1)
+
+	quiet := &testLogger{}
+	child, err = parent.Child(WithLogger(quiet))
+	assert.NoError(t, err)
+	assert.Same(t, quiet, child.log)
+}
+
+// docMockDriver documents registered configs by their types and names.
+type docMockDriver struct {
+	tlsMockDriver
+}
+
+func (d *docMockDriver) GenDoc(registers ...*Registered) string {
+	docs := make([]string, 0, len(registers))
+	for _, register := range registers {
+		docs = append(docs, strings.TrimSpace(register.Name+" "+reflect.TypeOf(register.Config).String()))
+	}
+	return strings.Join(docs, "\n")
+}
+
+func TestManager_ChildInheritsRegistrations(t *testing.T) {
+	type DB struct {
+		Host string
+	}
+	type Config struct {
+		Port int
+	}
+	type Tenant struct {
+		Name string
+	}
+	parent, _ := New(WithDriver(&docMockDriver{tlsMockDriver{values: map[string]string{
+		"Port": "80", "primary.Host": "primary.local",
+	}}}))
+	primary := &DB{}
+	assert.NoError(t, parent.Register(&Config{}))
+	assert.NoError(t, parent.RegisterNamed("primary", primary))
+
+	child, _ := parent.Child()
+	assert.NoError(t, child.Register(&Tenant{}))
+	assert.Equal(t, "*tinyconf.Config\n*tinyconf.Tenant\nprimary *tinyconf.DB", child.GenDoc("tls"))
+	assert.Equal(t, "*tinyconf.Config\nprimary *tinyconf.DB", parent.GenDoc("tls"))
+
+	// named configs of the parent are parsed by the child
+	assert.NoError(t, child.Parse(primary))
+	assert.Equal(t, "primary.local", primary.Host)
+
+	// overrides of the child are checked against parent registrations
+	assert.ErrorContains(t, child.Set("Port", "80"), "can't be assigned")
+	assert.ErrorContains(t, child.Set("primary.Host", 1), "can't be assigned")
+}
@@ -0,0 +1,219 @@
+package tinyconf
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/insei/cast"
+)
+
+var (
+	typeOfTextUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	typeOfJSONUnmarshaler = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+)
+
+type decodeFunc func(string) (any, error)
+
+var (
+	decodersMu sync.RWMutex
+	decoders   = map[reflect.Type]decodeFunc{}
+)
+
+func init() {
+	RegisterDecoder(ParseDuration)
+	RegisterDecoder(url.Parse)
+	RegisterDecoder(func(s string) (url.URL, error) {
+		u, err := url.Parse(s)
+		if err != nil {
+			return url.URL{}, err
+		}
+		return *u, nil
+	})
+	RegisterDecoder(regexp.Compile)
+	RegisterDecoder(time.LoadLocation)
+}
+
+// RegisterDecoder registers the decoder of string values to T for all drivers that uses Decode and DecodeAny.
+// Decoders are shared by all managers and drivers in the process, register them before the first Parse call.
+// Registered decoder has priority over encoding.TextUnmarshaler implementation and cast package conversion.
+func RegisterDecoder[T any](decode func(string) (T, error)) {
+	typeOf := reflect.TypeOf((*T)(nil)).Elem()
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+	decoders[typeOf] = func(s string) (any, error) {
+		return decode(s)
+	}
+}
+
+func getDecoder(typeOf reflect.Type) (decodeFunc, bool) {
+	decodersMu.RLock()
+	defer decodersMu.RUnlock()
+	decode, ok := decoders[typeOf]
+	return decode, ok
+}
+
+// wrapPointers wraps value to pointers count times, e.g. for *(*int) field types.
+func wrapPointers(val reflect.Value, count int) reflect.Value {
+	for i := 0; i < count; i++ {
+		ptr := reflect.New(val.Type())
+		ptr.Elem().Set(val)
+		val = ptr
+	}
+	return val
+}
+
+// IsDecodable reports whether values of typeOf are decoded as a whole, i.e. by registered decoder or
+// encoding.TextUnmarshaler, even if typeOf is a struct.
+func IsDecodable(typeOf reflect.Type) bool {
+	for {
+		if _, ok := getDecoder(typeOf); ok {
+			return true
+		}
+		if reflect.PointerTo(typeOf).Implements(typeOfTextUnmarshaler) {
+			return true
+		}
+		if typeOf.Kind() != reflect.Ptr {
+			return false
+		}
+		typeOf = typeOf.Elem()
+	}
+}
+
+// Decode converts string value to value of typeOf. Registered decoders are consulted first,
+// then encoding.TextUnmarshaler implementation, the cast package conversion is used as fallback.
+// Errors of encoding.TextUnmarshaler implementations are marked as ErrInvalidValue, so they fail Parse.
+func Decode(s string, typeOf reflect.Type) (any, error) {
+	baseType := typeOf
+	pointers := 0
+	for {
+		if decode, ok := getDecoder(baseType); ok {
+			val, err := decode(s)
+			if err != nil {
+				return nil, err
+			}
+			return wrapPointers(reflect.ValueOf(val), pointers).Interface(), nil
+		}
+		if baseType.Kind() != reflect.Ptr {
+			break
+		}
+		baseType = baseType.Elem()
+		pointers++
+	}
+	if reflect.PointerTo(baseType).Implements(typeOfTextUnmarshaler) {
+		val := reflect.New(baseType)
+		if err := val.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+			return nil, InvalidValue(err)
+		}
+		return wrapPointers(val.Elem(), pointers).Interface(), nil
+	}
+	val, err := cast.ToReflect(s, typeOf)
+	if err == nil {
+		return val, nil
+	}
+	// named types of basic kinds, i.e. enums, are converted through the underlying type
+	underlyingType := basicTypes[baseType.Kind()]
+	if underlyingType == nil || underlyingType == baseType {
+		return nil, err
+	}
+	val, err = cast.ToReflect(s, underlyingType)
+	if err != nil {
+		return nil, err
+	}
+	return wrapPointers(reflect.ValueOf(val).Convert(baseType), pointers).Interface(), nil
+}
+
+// DecodeAny converts the value decoded by driver from structured document (e.g. yaml scalar, mapping or sequence)
+// to value of typeOf. Mappings and sequences are converted by json.Unmarshaler implementation, sequences of
+// other slices and mappings of other maps with string keys are converted item by item,
+// other values are converted by Decode from their string representation.
+func DecodeAny(val any, typeOf reflect.Type) (any, error) {
+	valOf := reflect.ValueOf(val)
+	if !valOf.IsValid() {
+		return nil, fmt.Errorf("failed to convert nil to %s", typeOf.String())
+	}
+	if valOf.Type() == typeOf {
+		return val, nil
+	}
+	switch valOf.Kind() {
+	case reflect.Map, reflect.Slice:
+		baseType := typeOf
+		pointers := 0
+		for baseType.Kind() == reflect.Ptr {
+			baseType = baseType.Elem()
+			pointers++
+		}
+		if !reflect.PointerTo(baseType).Implements(typeOfJSONUnmarshaler) {
+			to, err := decodeItems(valOf, baseType)
+			if err != nil {
+				return nil, err
+			}
+			return wrapPointers(to, pointers).Interface(), nil
+		}
+		data, err := json.Marshal(val)
+		if err != nil {
+			return nil, err
+		}
+		to := reflect.New(baseType)
+		if err = to.Interface().(json.Unmarshaler).UnmarshalJSON(data); err != nil {
+			return nil, InvalidValue(err)
+		}
+		return wrapPointers(to.Elem(), pointers).Interface(), nil
+	case reflect.String:
+		return Decode(valOf.String(), typeOf)
+	}
+	return Decode(fmt.Sprintf("%v", val), typeOf)
+}
+
+// decodeItems converts sequences to slices and mappings to maps with string keys item by item by DecodeAny,
+// e.g. yaml sequence of numbers to []int field.
+func decodeItems(valOf reflect.Value, typeOf reflect.Type) (reflect.Value, error) {
+	switch {
+	case valOf.Kind() == reflect.Slice && typeOf.Kind() == reflect.Slice:
+		to := reflect.MakeSlice(typeOf, valOf.Len(), valOf.Len())
+		for i := 0; i < valOf.Len(); i++ {
+			item, err := DecodeAny(valOf.Index(i).Interface(), typeOf.Elem())
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("failed to convert item %d: %w", i, err)
+			}
+			to.Index(i).Set(reflect.ValueOf(item))
+		}
+		return to, nil
+	case valOf.Kind() == reflect.Map && typeOf.Kind() == reflect.Map &&
+		valOf.Type().Key().Kind() == reflect.String && typeOf.Key().Kind() == reflect.String:
+		to := reflect.MakeMapWithSize(typeOf, valOf.Len())
+		iter := valOf.MapRange()
+		for iter.Next() {
+			key := iter.Key().String()
+			item, err := DecodeAny(iter.Value().Interface(), typeOf.Elem())
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("failed to convert %q item: %w", key, err)
+			}
+			to.SetMapIndex(reflect.ValueOf(key).Convert(typeOf.Key()), reflect.ValueOf(item))
+		}
+		return to, nil
+	}
+	return reflect.Value{}, fmt.Errorf("failed to convert %s to %s, unsupported type", valOf.Type().String(), typeOf.String())
+}
+
+var basicTypes = map[reflect.Kind]reflect.Type{
+	reflect.Bool:    reflect.TypeOf(false),
+	reflect.Int:     reflect.TypeOf(0),
+	reflect.Int8:    reflect.TypeOf(int8(0)),
+	reflect.Int16:   reflect.TypeOf(int16(0)),
+	reflect.Int32:   reflect.TypeOf(int32(0)),
+	reflect.Int64:   reflect.TypeOf(int64(0)),
+	reflect.Uint:    reflect.TypeOf(uint(0)),
+	reflect.Uint8:   reflect.TypeOf(uint8(0)),
+	reflect.Uint16:  reflect.TypeOf(uint16(0)),
+	reflect.Uint32:  reflect.TypeOf(uint32(0)),
+	reflect.Uint64:  reflect.TypeOf(uint64(0)),
+	reflect.Float32: reflect.TypeOf(float32(0)),
+	reflect.Float64: reflect.TypeOf(float64(0)),
+	reflect.String:  reflect.TypeOf(""),
+}
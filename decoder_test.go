@@ -0,0 +1,197 @@
+package tinyconf
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/insei/fmap/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+type testLevel int
+
+const (
+	testLevelInfo = testLevel(iota)
+	testLevelDebug
+)
+
+func (l *testLevel) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "info":
+		*l = testLevelInfo
+	case "debug":
+		*l = testLevelDebug
+	default:
+		return fmt.Errorf("unknown level %s", text)
+	}
+	return nil
+}
+
+type testMode string
+
+type testColor uint8
+
+type testJSONPair struct {
+	Left, Right string
+}
+
+func (p *testJSONPair) UnmarshalJSON(data []byte) error {
+	var m map[string]string
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	p.Left, p.Right = m["left"], m["right"]
+	return nil
+}
+
+func TestDecode(t *testing.T) {
+	RegisterDecoder(func(s string) (testColor, error) {
+		switch s {
+		case "red":
+			return 1, nil
+		case "green":
+			return 2, nil
+		}
+		return 0, fmt.Errorf("unknown color %s", s)
+	})
+	location, _ := time.LoadLocation("UTC")
+	tests := []struct {
+		name     string
+		value    string
+		typeOf   reflect.Type
+		expected any
+		wantErr  bool
+	}{
+		{name: "cast int", value: "42", typeOf: reflect.TypeOf(0), expected: 42},
+		{name: "cast invalid int", value: "x", typeOf: reflect.TypeOf(0), wantErr: true},
+		{name: "duration", value: "1m30s", typeOf: reflect.TypeOf(time.Duration(0)), expected: 90 * time.Second},
+		{name: "net.IP", value: "10.0.0.1", typeOf: reflect.TypeOf(net.IP{}), expected: net.ParseIP("10.0.0.1")},
+		{name: "url", value: "https://example.com/a", typeOf: reflect.TypeOf(&url.URL{}), expected: &url.URL{Scheme: "https", Host: "example.com", Path: "/a"}},
+		{name: "url value", value: "https://example.com", typeOf: reflect.TypeOf(url.URL{}), expected: url.URL{Scheme: "https", Host: "example.com"}},
+		{name: "regexp", value: "^a+$", typeOf: reflect.TypeOf(&regexp.Regexp{}), expected: regexp.MustCompile("^a+$")},
+		{name: "regexp invalid", value: "(", typeOf: reflect.TypeOf(&regexp.Regexp{}), wantErr: true},
+		{name: "location", value: "UTC", typeOf: reflect.TypeOf(&time.Location{}), expected: location},
+		{name: "text unmarshaler", value: "debug", typeOf: reflect.TypeOf(testLevel(0)), expected: testLevelDebug},
+		{name: "text unmarshaler pointer", value: "debug", typeOf: reflect.TypeOf(new(testLevel)), expected: func() *testLevel { l := testLevelDebug; return &l }()},
+		{name: "text unmarshaler error", value: "trace", typeOf: reflect.TypeOf(testLevel(0)), wantErr: true},
+		{name: "registered decoder", value: "green", typeOf: reflect.TypeOf(testColor(0)), expected: testColor(2)},
+		{name: "registered decoder error", value: "blue", typeOf: reflect.TypeOf(testColor(0)), wantErr: true},
+		{name: "named string", value: "fast", typeOf: reflect.TypeOf(testMode("")), expected: testMode("fast")},
+		{name: "unsupported", value: "x", typeOf: reflect.TypeOf(struct{}{}), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			val, err := Decode(tt.value, tt.typeOf)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, val)
+		})
+	}
+}
+
+func TestDecodeAny(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    any
+		typeOf   reflect.Type
+		expected any
+		wantErr  bool
+	}{
+		{name: "same type", value: "test", typeOf: reflect.TypeOf(""), expected: "test"},
+		{name: "float to int", value: float64(123), typeOf: reflect.TypeOf(0), expected: 123},
+		{name: "string to text unmarshaler", value: "info", typeOf: reflect.TypeOf(testLevel(0)), expected: testLevelInfo},
+		{name: "map to json unmarshaler", value: map[string]any{"left": "l", "right": "r"}, typeOf: reflect.TypeOf(&testJSONPair{}), expected: &testJSONPair{Left: "l", Right: "r"}},
+		{name: "map to unsupported", value: map[string]any{"left": "l"}, typeOf: reflect.TypeOf(""), wantErr: true},
+		{name: "sequence to slice", value: []any{1, "2", float64(3)}, typeOf: reflect.TypeOf([]int{}), expected: []int{1, 2, 3}},
+		{name: "sequence to pointer of slice", value: []any{"a", "b"}, typeOf: reflect.TypeOf(&[]string{}), expected: &[]string{"a", "b"}},
+		{name: "sequence of text unmarshalers", value: []any{"info"}, typeOf: reflect.TypeOf([]testLevel{}), expected: []testLevel{testLevelInfo}},
+		{name: "sequence with invalid item", value: []any{1, "a"}, typeOf: reflect.TypeOf([]int{}), wantErr: true},
+		{name: "sequence to map", value: []any{1}, typeOf: reflect.TypeOf(map[string]int{}), wantErr: true},
+		{name: "mapping to map", value: map[string]any{"a": 1, "b": "2"}, typeOf: reflect.TypeOf(map[string]int{}), expected: map[string]int{"a": 1, "b": 2}},
+		{name: "mapping with invalid item", value: map[string]any{"a": []any{1}}, typeOf: reflect.TypeOf(map[string]string{}), wantErr: true},
+		{name: "nil", value: nil, typeOf: reflect.TypeOf(""), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			val, err := DecodeAny(tt.value, tt.typeOf)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, val)
+		})
+	}
+}
+
+func TestRegisterDecoder(t *testing.T) {
+	type upper string
+	RegisterDecoder(func(s string) (upper, error) {
+		return upper(strings.ToUpper(s)), nil
+	})
+	val, err := Decode("abc", reflect.TypeOf(upper("")))
+	assert.NoError(t, err)
+	assert.Equal(t, upper("ABC"), val)
+}
+
+func TestIsValueField(t *testing.T) {
+	storage, _ := fmap.Get[struct {
+		Section struct {
+			Name string
+		}
+		URL url.URL
+	}]()
+	assert.False(t, IsValueField(storage.MustFind("Section")))
+	assert.True(t, IsValueField(storage.MustFind("Section.Name")))
+	assert.True(t, IsValueField(storage.MustFind("URL")))
+	assert.False(t, IsValueField(storage.MustFind("URL.Host")))
+}
+
+type decodeMockDriver struct{}
+
+func (d *decodeMockDriver) GenDoc(...*Registered) string { return "" }
+func (d *decodeMockDriver) GetName() string              { return "decode" }
+func (d *decodeMockDriver) GetValue(field fmap.Field) (*Value, error) {
+	value, ok := field.GetTag().Lookup("decode")
+	if !ok {
+		return nil, ErrIncorrectTagSettings
+	}
+	val, err := DecodeField(field, value)
+	if err != nil {
+		return nil, err
+	}
+	return &Value{Source: "decode", Value: val}, nil
+}
+
+func TestManager_ParseDecodable(t *testing.T) {
+	type Config struct {
+		Pad  string
+		HTTP struct {
+			Pad      int64
+			Upstream struct {
+				Pad string
+				URL url.URL        `decode:"https://example.com"`
+				IP  net.IP         `decode:"127.0.0.1"`
+				Loc *time.Location `decode:"UTC"`
+			}
+		}
+	}
+	m, _ := New(WithDriver(&decodeMockDriver{}))
+	conf := &Config{}
+	assert.NoError(t, m.Register(conf))
+	assert.NoError(t, m.Parse(conf))
+	assert.Equal(t, url.URL{Scheme: "https", Host: "example.com"}, conf.HTTP.Upstream.URL)
+	assert.Equal(t, net.ParseIP("127.0.0.1"), conf.HTTP.Upstream.IP)
+	assert.Equal(t, "UTC", conf.HTTP.Upstream.Loc.String())
+	assert.Equal(t, "", conf.HTTP.Upstream.Pad)
+}
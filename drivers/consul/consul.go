@@ -0,0 +1,261 @@
+package consul
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/insei/fmap/v3"
+
+	"github.com/insei/tinyconf"
+	"github.com/insei/tinyconf/slices118"
+	"github.com/insei/tinyconf/strings118"
+)
+
+type consulDriver struct {
+	name   string
+	addr   string
+	prefix string
+	token  string
+	dc     string
+	wait   time.Duration
+	client *http.Client
+
+	mu    sync.RWMutex
+	index uint64
+	// values are values of keys relative to the prefix
+	values map[string]string
+}
+
+// kvPair is the key of the KV API response, the value is base64 encoded.
+type kvPair struct {
+	Key   string
+	Value *string
+}
+
+// fullKey returns the Consul key of the key relative to the prefix.
+func (d *consulDriver) fullKey(key string) string {
+	if d.prefix == "" {
+		return key
+	}
+	return d.prefix + "/" + key
+}
+
+// request requests keys under the prefix recursively, the request blocks until the index of keys is greater than
+// the index or the wait time is passed, if the index is not zero.
+func (d *consulDriver) request(ctx context.Context, index uint64) (map[string]string, uint64, error) {
+	query := url.Values{"recurse": []string{""}}
+	if d.dc != "" {
+		query.Set("dc", d.dc)
+	}
+	if index > 0 {
+		query.Set("index", strconv.FormatUint(index, 10))
+		query.Set("wait", strconv.FormatInt(d.wait.Milliseconds(), 10)+"ms")
+	}
+	path := "/v1/kv/"
+	if d.prefix != "" {
+		path += d.prefix + "/"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.addr+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if d.token != "" {
+		req.Header.Set("X-Consul-Token", d.token)
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to request consul keys: %s", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, 0, fmt.Errorf("failed to request consul keys: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	newIndex, err := strconv.ParseUint(resp.Header.Get("X-Consul-Index"), 10, 64)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to request consul keys: invalid X-Consul-Index header: %s", err)
+	}
+	values := map[string]string{}
+	if resp.StatusCode == http.StatusNotFound {
+		// there are no keys under the prefix
+		return values, newIndex, nil
+	}
+	var pairs []kvPair
+	if err = json.NewDecoder(resp.Body).Decode(&pairs); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode consul keys: %s", err)
+	}
+	for _, pair := range pairs {
+		key := pair.Key
+		if d.prefix != "" {
+			key = strings.TrimPrefix(key, d.prefix+"/")
+		}
+		// folders keys have no values
+		if strings.HasSuffix(key, "/") || key == "" {
+			continue
+		}
+		var value []byte
+		if pair.Value != nil {
+			if value, err = base64.StdEncoding.DecodeString(*pair.Value); err != nil {
+				return nil, 0, fmt.Errorf("failed to decode consul key %s value: %s", pair.Key, err)
+			}
+		}
+		values[key] = string(value)
+	}
+	return values, newIndex, nil
+}
+
+// Poll waits for changes of keys under the prefix by the blocking query with the last X-Consul-Index and reports
+// whether keys are changed, see tinyconf.Watcher.
+func (d *consulDriver) Poll(ctx context.Context) (bool, error) {
+	d.mu.RLock()
+	index := d.index
+	d.mu.RUnlock()
+	values, newIndex, err := d.request(ctx, index)
+	if err != nil {
+		return false, err
+	}
+	if newIndex == index {
+		return false, nil
+	}
+	// the index must only grow, it is reset if it goes backwards, i.e. after the restore of Consul snapshot
+	if newIndex < index {
+		newIndex = 0
+	}
+	d.mu.Lock()
+	d.values, d.index = values, newIndex
+	d.mu.Unlock()
+	return true, nil
+}
+
+// Watch polls keys by blocking queries until ctx is done, see tinyconf.Watcher.
+func (d *consulDriver) Watch(ctx context.Context, fn func(error)) {
+	tinyconf.WatchPolls(ctx, func() time.Duration { return 0 }, d.Poll, fn)
+}
+
+// getKey returns the key of the field relative to the prefix, it is the path of `consul` tag or the struct path
+// with slash separators, i.e. http/port for `consul:"port"` field of `consul:"http"` section.
+func (d *consulDriver) getKey(field fmap.Field) (string, bool) {
+	if field.GetTag().Get(d.name) == "-" {
+		return "", false
+	}
+	path := field.GetTagPath(d.name, true)
+	if path == "" {
+		if !field.IsExported() {
+			return "", false
+		}
+		path = field.GetStructPath()
+	}
+	return strings.ReplaceAll(path, ".", "/"), true
+}
+
+func (d *consulDriver) GetValue(field fmap.Field) (*tinyconf.Value, error) {
+	key, ok := d.getKey(field)
+	if !ok {
+		return nil, fmt.Errorf("%w: consul key is not set for %s config field", tinyconf.ErrIncorrectTagSettings, field.GetStructPath())
+	}
+	d.mu.RLock()
+	kvVal, ok := d.values[key]
+	d.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s key is not found in consul for %s config field", tinyconf.ErrValueNotFound, d.fullKey(key), field.GetStructPath())
+	}
+	value, err := tinyconf.DecodeField(field, kvVal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse consul key %s value for %s config field: %w", d.fullKey(key), field.GetStructPath(), err)
+	}
+	return &tinyconf.Value{Source: d.fullKey(key), Value: value}, nil
+}
+
+// GetKeys returns keys of map[string]T section entries or indexes of []T section items by Consul keys,
+// i.e. main for db/main/host key of `consul:"db"` section.
+func (d *consulDriver) GetKeys(field fmap.Field) ([]string, error) {
+	key, ok := d.getKey(field)
+	if !ok {
+		return nil, fmt.Errorf("%w: consul key is not set for %s config field", tinyconf.ErrIncorrectTagSettings, field.GetStructPath())
+	}
+	prefix := key + "/"
+	d.mu.RLock()
+	var keys []string
+	for valueKey := range d.values {
+		if !strings.HasPrefix(valueKey, prefix) {
+			continue
+		}
+		entryKey := strings.Split(valueKey[len(prefix):], "/")[0]
+		if !slices118.Contains(keys, entryKey) {
+			keys = append(keys, entryKey)
+		}
+	}
+	d.mu.RUnlock()
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w: no %s keys in consul for %s config field", tinyconf.ErrValueNotFound, d.fullKey(prefix), field.GetStructPath())
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (d *consulDriver) GetName() string {
+	return d.name
+}
+
+// GenDoc returns `consul kv put` commands of value fields of registered configs with default values,
+// values of hidden fields are empty.
+func (d *consulDriver) GenDoc(registers ...*tinyconf.Registered) string {
+	var doc string
+	var keys []string
+	for _, register := range registers {
+		for _, configField := range register.GetFields() {
+			fld := configField.Field
+			if !tinyconf.IsValueField(fld) {
+				continue
+			}
+			key, ok := d.getKey(fld)
+			if !ok || slices118.Contains(keys, key) {
+				continue
+			}
+			keys = append(keys, key)
+			value := tinyconf.FormatFieldValue(fld, configField.Value)
+			if tinyconf.IsHiddenField(fld, false) {
+				value = ""
+			}
+			if fieldDoc := fld.GetTag().Get("doc"); fieldDoc != "" {
+				doc += "#" + fieldDoc + "\n"
+			}
+			doc += fmt.Sprintf("consul kv put %s %s\n", d.fullKey(key), strings118.ShellQuote(value))
+		}
+	}
+	return doc
+}
+
+// New returns the driver that reads values of Consul KV keys under the prefix by the HTTP API of the agent,
+// i.e. New("http://127.0.0.1:8500", "app") reads app/http/port key for `consul:"port"` field of `consul:"http"` section.
+// Keys are read by New, the driver implements tinyconf.Watcher to reload them.
+func New(addr, prefix string, opts ...Option) (tinyconf.Driver, error) {
+	d := &consulDriver{
+		name:   "consul",
+		addr:   strings.TrimSuffix(addr, "/"),
+		prefix: strings.Trim(prefix, "/"),
+		wait:   5 * time.Minute,
+		client: &http.Client{},
+	}
+	for _, opt := range opts {
+		opt.apply(d)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	values, index, err := d.request(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	d.values, d.index = values, index
+	return d, nil
+}
@@ -0,0 +1,284 @@
+package consul
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/insei/fmap/v3"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/insei/tinyconf"
+	"github.com/insei/tinyconf/drivers/internal/drivertest"
+)
+
+type Server struct {
+	Host string `consul:"host"`
+	Port int    `consul:"port"`
+}
+
+type Config struct {
+	Name string `consul:"name" doc:"application name"`
+	HTTP struct {
+		Port    int           `consul:"port"`
+		Timeout time.Duration `consul:"timeout"`
+		Secret  string        `consul:"secret" hidden:"true"`
+	} `consul:"http"`
+	Servers []Server          `consul:"servers"`
+	DB      map[string]Server `consul:"db" key:"name"`
+	Debug   bool
+	Skipped string `consul:"-"`
+}
+
+// kvServer is the stand-in of Consul KV HTTP API, blocking queries wait for changes of keys.
+type kvServer struct {
+	mu      sync.Mutex
+	token   string
+	keys    map[string]*string
+	index   uint64
+	changed chan struct{}
+}
+
+func newKVServer(token string) *kvServer {
+	return &kvServer{token: token, keys: map[string]*string{}, index: 1, changed: make(chan struct{})}
+}
+
+func (s *kvServer) put(values map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, value := range values {
+		encoded := base64.StdEncoding.EncodeToString([]byte(value))
+		s.keys[key] = &encoded
+	}
+	s.index++
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+func (s *kvServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.token != "" && r.Header.Get("X-Consul-Token") != s.token {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte("Permission denied"))
+		return
+	}
+	prefix := strings.TrimPrefix(r.URL.Path, "/v1/kv/")
+	if _, ok := r.URL.Query()["recurse"]; !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	changed := s.changed
+	index := s.index
+	s.mu.Unlock()
+	if queryIndex, _ := strconv.ParseUint(r.URL.Query().Get("index"), 10, 64); queryIndex > 0 && queryIndex >= index {
+		wait, _ := time.ParseDuration(r.URL.Query().Get("wait"))
+		select {
+		case <-changed:
+		case <-time.After(wait):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	type pair struct {
+		Key   string
+		Value *string
+	}
+	var pairs []pair
+	for key, value := range s.keys {
+		if strings.HasPrefix(key, prefix) {
+			pairs = append(pairs, pair{Key: key, Value: value})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].Key < pairs[j].Key })
+	w.Header().Set("X-Consul-Index", strconv.FormatUint(s.index, 10))
+	if len(pairs) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(pairs)
+}
+
+func TestNew(t *testing.T) {
+	kv := newKVServer("token")
+	server := httptest.NewServer(kv)
+	defer server.Close()
+
+	_, err := New(server.URL, "app")
+	assert.EqualError(t, err, "failed to request consul keys: 403 Forbidden: Permission denied")
+
+	d, err := New(server.URL, "app", WithToken("token"))
+	assert.NoError(t, err)
+	assert.Equal(t, "consul", d.GetName())
+	storage, _ := fmap.Get[Config]()
+	_, err = d.GetValue(storage.MustFind("Name"))
+	assert.ErrorIs(t, err, tinyconf.ErrValueNotFound)
+
+	_, err = New("http://127.0.0.1:0", "app")
+	assert.ErrorContains(t, err, "failed to request consul keys")
+}
+
+func TestConsulDriver_GetValue(t *testing.T) {
+	kv := newKVServer("")
+	kv.put(map[string]string{
+		"app/name":         "app",
+		"app/http/port":    "8080",
+		"app/http/timeout": "1m",
+		"app/Debug":        "true",
+		"app/Skipped":      "skipped",
+		"app/":             "",
+		"other/name":       "other",
+	})
+	kv.keys["app/empty"] = nil
+	server := httptest.NewServer(kv)
+	defer server.Close()
+	d, err := New(server.URL+"/", "/app/")
+	assert.NoError(t, err)
+	storage, _ := fmap.Get[Config]()
+	drivertest.GetValue(t, d, storage, []drivertest.Case{
+		{Path: "Name", Want: &tinyconf.Value{Source: "app/name", Value: "app"}},
+		{Path: "HTTP.Port", Want: &tinyconf.Value{Source: "app/http/port", Value: 8080}},
+		{Path: "HTTP.Timeout", Want: &tinyconf.Value{Source: "app/http/timeout", Value: time.Minute}},
+		{Path: "Debug", Want: &tinyconf.Value{Source: "app/Debug", Value: true}},
+		{Path: "HTTP.Secret", WantErr: tinyconf.ErrValueNotFound},
+		{Path: "Skipped", WantErr: tinyconf.ErrIncorrectTagSettings},
+	})
+	assert.Equal(t, map[string]string{
+		"name": "app", "http/port": "8080", "http/timeout": "1m", "Debug": "true", "Skipped": "skipped", "empty": "",
+	}, d.(*consulDriver).values)
+}
+
+func TestConsulDriver_Parse(t *testing.T) {
+	kv := newKVServer("")
+	kv.put(map[string]string{
+		"app/servers/0/host":  "a.local",
+		"app/servers/1/host":  "b.local",
+		"app/servers/1/port":  "81",
+		"app/db/main/host":    "main.local",
+		"app/db/main/port":    "5432",
+		"app/db/reports/port": "5433",
+	})
+	server := httptest.NewServer(kv)
+	defer server.Close()
+	d, err := New(server.URL, "app")
+	assert.NoError(t, err)
+	conf := &Config{}
+	drivertest.Parse(t, d, conf)
+	assert.Equal(t, []Server{{Host: "a.local"}, {Host: "b.local", Port: 81}}, conf.Servers)
+	assert.Equal(t, map[string]Server{"main": {Host: "main.local", Port: 5432}, "reports": {Port: 5433}}, conf.DB)
+
+	drivertest.GetKeys(t, d, configStorage(t).MustFind("DB"), []string{"main", "reports"})
+}
+
+func configStorage(t *testing.T) fmap.Storage {
+	storage, err := fmap.Get[Config]()
+	assert.NoError(t, err)
+	return storage
+}
+
+func TestConsulDriver_Poll(t *testing.T) {
+	kv := newKVServer("")
+	kv.put(map[string]string{"app/name": "v1"})
+	server := httptest.NewServer(kv)
+	defer server.Close()
+	d, err := New(server.URL, "app", WithWait(10*time.Millisecond))
+	assert.NoError(t, err)
+	watcher := d.(tinyconf.Watcher)
+
+	// the blocking query returns without changes after the wait time
+	changed, err := watcher.Poll(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, changed)
+
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		kv.put(map[string]string{"app/name": "v2"})
+	}()
+	d.(*consulDriver).wait = time.Minute
+	changed, err = watcher.Poll(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	val, err := d.GetValue(configStorage(t).MustFind("Name"))
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", val.Value)
+
+	// the index is reset when it goes backwards
+	d.(*consulDriver).wait = 10 * time.Millisecond
+	kv.mu.Lock()
+	kv.index = 1
+	kv.mu.Unlock()
+	changed, err = watcher.Poll(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, uint64(0), d.(*consulDriver).index)
+}
+
+func TestConsulDriver_Watch(t *testing.T) {
+	kv := newKVServer("")
+	kv.put(map[string]string{"app/name": "v1"})
+	server := httptest.NewServer(kv)
+	defer server.Close()
+	d, err := New(server.URL, "app")
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	changes := make(chan error, 1)
+	go func() {
+		defer close(done)
+		d.(tinyconf.Watcher).Watch(ctx, func(err error) {
+			select {
+			case changes <- err:
+			default:
+			}
+		})
+	}()
+	kv.put(map[string]string{"app/name": "v2"})
+	select {
+	case err := <-changes:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("keys change is not reported")
+	}
+	val, err := d.GetValue(configStorage(t).MustFind("Name"))
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", val.Value)
+
+	// the blocking query is canceled with the context
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch is not stopped")
+	}
+}
+
+func TestConsulDriver_GenDoc(t *testing.T) {
+	kv := newKVServer("")
+	server := httptest.NewServer(kv)
+	defer server.Close()
+	d, err := New(server.URL, "app")
+	assert.NoError(t, err)
+	conf := &Config{Name: "it's app"}
+	conf.HTTP.Secret = "secret"
+	assert.Equal(t, `#application name
+consul kv put app/name 'it'\''s app'
+consul kv put app/http/port '0'
+consul kv put app/http/timeout '0s'
+consul kv put app/http/secret ''
+consul kv put app/servers/0/host ''
+consul kv put app/servers/0/port '0'
+consul kv put app/db/<name>/host ''
+consul kv put app/db/<name>/port '0'
+consul kv put app/Debug 'false'
+`, d.GenDoc(&tinyconf.Registered{Storage: configStorage(t), Config: conf}))
+}
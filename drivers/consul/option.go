@@ -0,0 +1,63 @@
+package consul
+
+import (
+	"net/http"
+	"time"
+)
+
+type Option interface {
+	apply(*consulDriver)
+}
+
+type tokenOption struct {
+	token string
+}
+
+func (o tokenOption) apply(d *consulDriver) {
+	d.token = o.token
+}
+
+// WithToken sets the ACL token of requests, it is sent in X-Consul-Token header.
+func WithToken(token string) Option {
+	return tokenOption{token: token}
+}
+
+type datacenterOption struct {
+	dc string
+}
+
+func (o datacenterOption) apply(d *consulDriver) {
+	d.dc = o.dc
+}
+
+// WithDatacenter sets the datacenter of keys, the datacenter of the agent is used by default.
+func WithDatacenter(dc string) Option {
+	return datacenterOption{dc: dc}
+}
+
+type clientOption struct {
+	client *http.Client
+}
+
+func (o clientOption) apply(d *consulDriver) {
+	d.client = o.client
+}
+
+// WithClient sets the http client of requests, i.e. with custom TLS settings. The client timeout must be greater than
+// the blocking query wait time (see WithWait).
+func WithClient(client *http.Client) Option {
+	return clientOption{client: client}
+}
+
+type waitOption struct {
+	wait time.Duration
+}
+
+func (o waitOption) apply(d *consulDriver) {
+	d.wait = o.wait
+}
+
+// WithWait sets the maximum duration of blocking queries of Poll and Watch, the default is 5m.
+func WithWait(wait time.Duration) Option {
+	return waitOption{wait: wait}
+}
@@ -0,0 +1,153 @@
+package cred
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/insei/fmap/v3"
+
+	"github.com/insei/tinyconf"
+	"github.com/insei/tinyconf/slices118"
+)
+
+// DirEnv is the env variable with the directory of credentials passed to the service by systemd.
+const DirEnv = "CREDENTIALS_DIRECTORY"
+
+var (
+	// ErrNoDirectory is returned when the service is started without credentials, i.e. not by systemd.
+	ErrNoDirectory = errors.New(DirEnv + " is not set")
+	// ErrNoCredential is returned when the credential is not passed to the service by LoadCredential= or SetCredential=.
+	ErrNoCredential = errors.New("credential is not found")
+)
+
+type credDriver struct {
+	name string
+}
+
+// getName returns the credential name from `cred` tag and reports whether the credential is optional,
+// i.e. `cred:"db-password,optional"`. Fields of maps and slices entries are not supported, because the tag is the same
+// for all entries.
+func (d *credDriver) getName(field fmap.Field) (string, bool, bool) {
+	if tinyconf.IsDynamicField(field) {
+		return "", false, false
+	}
+	name, options, _ := strings.Cut(field.GetTag().Get(d.name), ",")
+	if name == "" || name == "-" {
+		return "", false, false
+	}
+	return name, options == "optional", true
+}
+
+// validName reports whether the credential name is the file name, not the path.
+func validName(name string) bool {
+	return !strings.ContainsRune(name, filepath.Separator) && name != "." && name != ".."
+}
+
+// isRaw reports whether the field is []byte without `encoding` tag, binary credentials like keys are set to it as is.
+func isRaw(field fmap.Field) bool {
+	return field.GetType() == reflect.TypeOf([]byte(nil)) && field.GetTag().Get("encoding") == ""
+}
+
+func (d *credDriver) GetValue(field fmap.Field) (*tinyconf.Value, error) {
+	name, optional, ok := d.getName(field)
+	if !ok {
+		return nil, fmt.Errorf("%w: cred tag is not set for %s config field", tinyconf.ErrIncorrectTagSettings, field.GetStructPath())
+	}
+	if !validName(name) {
+		return nil, fmt.Errorf("%w: invalid credential name %q for %s config field", tinyconf.ErrIncorrectTagSettings, name, field.GetStructPath())
+	}
+	dir, ok := os.LookupEnv(DirEnv)
+	if !ok || dir == "" {
+		err := fmt.Errorf("%w: can't read %s credential for %s config field", ErrNoDirectory, name, field.GetStructPath())
+		return nil, notFound(err, optional)
+	}
+	path := filepath.Join(dir, name)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		err = fmt.Errorf("%w: %s is not found in %s for %s config field", ErrNoCredential, name, dir, field.GetStructPath())
+		return nil, notFound(err, optional)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error while read %s credential for %s config field: %w", name, field.GetStructPath(), err)
+	}
+	if isRaw(field) {
+		return &tinyconf.Value{Source: path, Value: data, Hidden: tinyconf.IsHiddenField(field, true)}, nil
+	}
+	value, err := tinyconf.DecodeField(field, strings.TrimRight(string(data), "\r\n"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s credential value for %s config field: %w", name, field.GetStructPath(), err)
+	}
+	return &tinyconf.Value{Source: path, Value: value, Hidden: tinyconf.IsHiddenField(field, true)}, nil
+}
+
+// notFound returns the error of the missing credential, missing optional credentials are not found values,
+// so other drivers can provide them, other missing credentials are invalid values that fail Parse.
+func notFound(err error, optional bool) error {
+	if optional {
+		return optionalError{err: err}
+	}
+	return tinyconf.InvalidValue(err)
+}
+
+// optionalError is the error of the missing optional credential, errors.Is reports tinyconf.ErrValueNotFound
+// and ErrNoDirectory or ErrNoCredential of the wrapped error.
+type optionalError struct {
+	err error
+}
+
+func (e optionalError) Error() string {
+	return fmt.Sprintf("%s: optional %s", tinyconf.ErrValueNotFound, e.err)
+}
+
+func (e optionalError) Unwrap() error {
+	return e.err
+}
+
+func (e optionalError) Is(target error) bool {
+	return target == tinyconf.ErrValueNotFound
+}
+
+func (d *credDriver) GetName() string {
+	return d.name
+}
+
+// GenDoc returns LoadCredential= settings of the systemd service unit for fields with `cred` tag, credentials
+// are loaded from the systemd credentials store (/etc/credstore, /run/credstore and others) by the name.
+func (d *credDriver) GenDoc(registers ...*tinyconf.Registered) string {
+	var doc string
+	var names []string
+	for _, register := range registers {
+		for _, configField := range register.GetFields() {
+			fld := configField.Field
+			if !tinyconf.IsValueField(fld) {
+				continue
+			}
+			name, _, ok := d.getName(fld)
+			if !ok || !validName(name) || slices118.Contains(names, name) {
+				continue
+			}
+			names = append(names, name)
+			if fieldDoc := fld.GetTag().Get("doc"); fieldDoc != "" {
+				doc += "#" + fieldDoc + "\n"
+			}
+			doc += "LoadCredential=" + name + "\n"
+		}
+	}
+	if doc == "" {
+		return ""
+	}
+	return "[Service]\n" + doc
+}
+
+// New returns the driver that reads values of systemd credentials by `cred` tag from $CREDENTIALS_DIRECTORY,
+// i.e. `cred:"db-password"` field is read from $CREDENTIALS_DIRECTORY/db-password of LoadCredential=db-password.
+// Values are hidden in logs unless the field has `hidden:"false"` tag.
+func New() (tinyconf.Driver, error) {
+	return &credDriver{
+		name: "cred",
+	}, nil
+}
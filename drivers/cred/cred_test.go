@@ -0,0 +1,119 @@
+package cred
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/insei/fmap/v3"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/insei/tinyconf"
+	"github.com/insei/tinyconf/drivers/internal/drivertest"
+)
+
+type Config struct {
+	Password string `cred:"db-password" doc:"database password"`
+	Token    []byte `cred:"api-token"`
+	User     string `cred:"db-user" hidden:"false"`
+	Port     int    `cred:"port"`
+	Cert     string `cred:"tls-cert,optional"`
+	Invalid  string `cred:"../passwd"`
+	Name     string
+	DB       map[string]struct {
+		Password string `cred:"password"`
+	}
+}
+
+func writeCredentials(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, data := range files {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(data), 0o400))
+	}
+	t.Setenv(DirEnv, dir)
+	return dir
+}
+
+func TestCredDriver_GetValue(t *testing.T) {
+	dir := writeCredentials(t, map[string]string{
+		"db-password": "secret\n",
+		"api-token":   "token\n",
+		"db-user":     "app",
+		"port":        "http",
+	})
+	d, _ := New()
+	assert.Equal(t, "cred", d.GetName())
+	storage, _ := fmap.Get[Config]()
+	drivertest.GetValue(t, d, storage, []drivertest.Case{
+		{Path: "Password", Want: &tinyconf.Value{Source: filepath.Join(dir, "db-password"), Value: "secret", Hidden: true}},
+		{Path: "Token", Want: &tinyconf.Value{Source: filepath.Join(dir, "api-token"), Value: []byte("token\n"), Hidden: true}},
+		{Path: "User", Want: &tinyconf.Value{Source: filepath.Join(dir, "db-user"), Value: "app"}},
+		{Path: "Invalid", WantErr: tinyconf.ErrIncorrectTagSettings},
+		{Path: "Name", WantErr: tinyconf.ErrIncorrectTagSettings},
+	})
+	_, err := d.GetValue(storage.MustFind("Port"))
+	assert.ErrorContains(t, err, "failed to parse port credential value for Port config field")
+}
+
+func TestCredDriver_Errors(t *testing.T) {
+	d, _ := New()
+	storage, _ := fmap.Get[Config]()
+
+	t.Setenv(DirEnv, "")
+	_, err := d.GetValue(storage.MustFind("Password"))
+	assert.ErrorIs(t, err, ErrNoDirectory)
+	assert.ErrorIs(t, err, tinyconf.ErrInvalidValue)
+	assert.NotErrorIs(t, err, tinyconf.ErrValueNotFound)
+	assert.EqualError(t, err, "CREDENTIALS_DIRECTORY is not set: "+
+		"can't read db-password credential for Password config field")
+	_, err = d.GetValue(storage.MustFind("Cert"))
+	assert.ErrorIs(t, err, tinyconf.ErrValueNotFound)
+	assert.ErrorIs(t, err, ErrNoDirectory)
+	assert.NotErrorIs(t, err, tinyconf.ErrInvalidValue)
+
+	dir := writeCredentials(t, nil)
+	_, err = d.GetValue(storage.MustFind("Password"))
+	assert.ErrorIs(t, err, ErrNoCredential)
+	assert.ErrorIs(t, err, tinyconf.ErrInvalidValue)
+	assert.NotErrorIs(t, err, tinyconf.ErrValueNotFound)
+	assert.NotErrorIs(t, err, ErrNoDirectory)
+	assert.EqualError(t, err, "credential is not found: "+
+		"db-password is not found in "+dir+" for Password config field")
+	_, err = d.GetValue(storage.MustFind("Cert"))
+	assert.ErrorIs(t, err, tinyconf.ErrValueNotFound)
+	assert.ErrorIs(t, err, ErrNoCredential)
+	assert.NotErrorIs(t, err, ErrNoDirectory)
+	assert.EqualError(t, err, "value was not found: optional credential is not found: "+
+		"tls-cert is not found in "+dir+" for Cert config field")
+}
+
+func TestCredDriver_Parse(t *testing.T) {
+	writeCredentials(t, map[string]string{"db-password": "secret", "db-user": "app", "api-token": "token", "port": "80"})
+	d, _ := New()
+	conf := &Config{Name: "app"}
+	m := drivertest.Parse(t, d, conf)
+	assert.Equal(t, "secret", conf.Password)
+	assert.Equal(t, "app", conf.User)
+	assert.Equal(t, "app", conf.Name)
+
+	// missing required credentials fail Parse
+	writeCredentials(t, map[string]string{"db-password": "secret"})
+	err := m.Parse(conf)
+	var fieldErr *tinyconf.FieldError
+	if assert.ErrorAs(t, err, &fieldErr) {
+		assert.ErrorIs(t, err, ErrNoCredential)
+	}
+}
+
+func TestCredDriver_GenDoc(t *testing.T) {
+	d, _ := New()
+	storage, _ := fmap.Get[Config]()
+	assert.Equal(t, `[Service]
+#database password
+LoadCredential=db-password
+LoadCredential=api-token
+LoadCredential=db-user
+LoadCredential=port
+LoadCredential=tls-cert
+`, d.GenDoc(&tinyconf.Registered{Storage: storage, Config: &Config{Password: "secret"}}))
+}
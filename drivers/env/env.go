@@ -12,26 +12,140 @@ import (
 	"github.com/insei/tinyconf/cmp118"
 	"github.com/insei/tinyconf/slices118"
 
-	"github.com/insei/cast"
 	"github.com/insei/fmap/v3"
 )
 
 type envDriver struct {
-	name string
+	name     string
+	prefix   string
+	autoKeys bool
 }
 
-func (d envDriver) GetValue(field fmap.Field) (*tinyconf.Value, error) {
+// deriveKey converts field struct path to env key, e.g. HTTP.MaxBody -> HTTP_MAX_BODY.
+func deriveKey(structPath string) string {
+	return strings.ToUpper(strings.Join(tinyconf.SplitWords(structPath), "_"))
+}
+
+func (d envDriver) getKey(field fmap.Field) (string, bool) {
+	if tinyconf.IsDynamicField(field) {
+		key, ok := d.composeKey(field)
+		return d.prefix + key, ok
+	}
 	envKey, ok := field.GetTag().Lookup(d.name)
-	if !ok || envKey == "" {
+	if envKey == "-" || d.isExcludedSection(field) {
+		return "", false
+	}
+	if ok && envKey != "" {
+		return d.prefix + envKey, true
+	}
+	if !d.autoKeys || !field.IsExported() {
+		return "", false
+	}
+	return d.prefix + deriveKey(field.GetStructPath()), true
+}
+
+// isExcludedSection reports whether some parent section of the field has `env:"-"` tag, like composeKey does.
+func (d envDriver) isExcludedSection(field fmap.Field) bool {
+	for f := tinyconf.ParentOf(field); f != nil; f = tinyconf.ParentOf(f) {
+		if f.GetTag().Get(d.name) == "-" {
+			return true
+		}
+	}
+	return false
+}
+
+// composeKey composes env key from the field and its parents keys, map section entries contribute upper-cased keys,
+// e.g. DB_MAIN_HOST for `env:"HOST"` field of `env:"DB"` map section entry main.
+// Parents without env keys are skipped, but the field itself and map section fields must have them.
+func (d envDriver) composeKey(field fmap.Field) (string, bool) {
+	var segments []string
+	var child fmap.Field
+	for f := field; f != nil; child, f = f, tinyconf.ParentOf(f) {
+		if entry, ok := f.(tinyconf.EntryField); ok {
+			segments = append(segments, strings.ToUpper(entry.GetKey()))
+			continue
+		}
+		envKey, ok := f.GetTag().Lookup(d.name)
+		if envKey == "-" {
+			return "", false
+		}
+		_, isEntryParent := child.(tinyconf.EntryField)
+		switch {
+		case ok && envKey != "":
+			segments = append(segments, envKey)
+		case d.autoKeys && f.IsExported():
+			segments = append(segments, deriveKey(f.GetName()))
+		case f == field || isEntryParent:
+			return "", false
+		}
+	}
+	for i, j := 0, len(segments)-1; i < j; i, j = i+1, j-1 {
+		segments[i], segments[j] = segments[j], segments[i]
+	}
+	return strings.Join(segments, "_"), true
+}
+
+// GetKeys discovers keys of map[string]T section entries by env variables like DB_<KEY>_HOST,
+// where HOST is the key of some entry struct value field. Keys are lower-cased.
+func (d envDriver) GetKeys(field fmap.Field) ([]string, error) {
+	mapKey, ok := d.composeKey(field)
+	if !ok {
+		return nil, fmt.Errorf("%w: env tag is not set for %s config field", tinyconf.ErrIncorrectTagSettings, field.GetStructPath())
+	}
+	elemType := field.GetType().Elem()
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	elemStorage, err := fmap.GetFrom(reflect.New(elemType).Interface())
+	if err != nil {
+		return nil, err
+	}
+	var suffixes []string
+	for _, path := range elemStorage.GetAllPaths() {
+		elemField := elemStorage.MustFind(path)
+		if !tinyconf.IsValueField(elemField) {
+			continue
+		}
+		if suffix, ok := d.composeKey(elemField); ok {
+			suffixes = append(suffixes, "_"+suffix)
+		}
+	}
+	prefix := d.prefix + mapKey + "_"
+	var keys []string
+	for _, env := range os.Environ() {
+		name := strings.SplitN(env, "=", 2)[0]
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		rest := strings.TrimPrefix(name, prefix)
+		for _, suffix := range suffixes {
+			if len(rest) <= len(suffix) || !strings.HasSuffix(rest, suffix) {
+				continue
+			}
+			key := strings.ToLower(strings.TrimSuffix(rest, suffix))
+			if !slices118.Contains(keys, key) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w: no %s env keys for %s config field", tinyconf.ErrValueNotFound, prefix, field.GetStructPath())
+	}
+	return keys, nil
+}
+
+func (d envDriver) GetValue(field fmap.Field) (*tinyconf.Value, error) {
+	envKey, ok := d.getKey(field)
+	if !ok {
 		return nil, fmt.Errorf("%w: env tag is not set for %s config field", tinyconf.ErrIncorrectTagSettings, field.GetStructPath())
 	}
 	envVal, ok := os.LookupEnv(envKey)
 	if !ok {
 		return nil, fmt.Errorf("%w: %s is not defined in env for %s config field", tinyconf.ErrValueNotFound, envKey, field.GetStructPath())
 	}
-	value, err := cast.ToReflect(envVal, field.GetType())
+	value, err := tinyconf.DecodeField(field, envVal)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse env value from key %s for %s config field: %s", envKey, field.GetStructPath(), err)
+		return nil, fmt.Errorf("failed to parse env value from key %s for %s config field: %w", envKey, field.GetStructPath(), err)
 	}
 	return &tinyconf.Value{Source: envKey, Value: value}, err
 }
@@ -41,41 +155,43 @@ func (d envDriver) GetName() string {
 }
 
 type field struct {
+	key   string
 	path  string
-	value any
+	value string
 	depth int
 	tag   reflect.StructTag
 }
 
-func (f field) genDoc(driver string) string {
-	if tagDriver, ok := f.tag.Lookup(driver); ok {
-		tagDoc := f.tag.Get("doc")
-		return fmt.Sprintf("#%s\n#%s=%v\n", tagDoc, tagDriver, f.value)
-	}
-	return ""
+func (f field) genDoc() string {
+	tagDoc := f.tag.Get("doc")
+	return fmt.Sprintf("#%s\n#%s=%s\n", tagDoc, f.key, f.value)
 }
 
 func (d envDriver) getUniqueFields(registers []*tinyconf.Registered) []field {
 	var fields []field
 	for _, register := range registers {
-		for _, path := range register.Storage.GetAllPaths() {
-			fld := register.Storage.MustFind(path)
+		for _, configField := range register.GetFields() {
+			fld := configField.Field
 
-			tag := fld.GetTag()
-			tagDriver, ok := tag.Lookup(d.name)
+			if !tinyconf.IsValueField(fld) {
+				continue
+			}
+			key, ok := d.getKey(fld)
 			if !ok {
 				continue
 			}
 
+			unprefixedKey := strings.TrimPrefix(key, d.prefix)
 			member := field{
-				path:  strings.Split(tagDriver, "_")[0],
-				value: fld.Get(register.Config),
-				depth: strings.Count(tagDriver, "_"),
-				tag:   tag,
+				key:   key,
+				path:  strings.Split(unprefixedKey, "_")[0],
+				value: tinyconf.FormatFieldValue(fld, configField.Value),
+				depth: strings.Count(unprefixedKey, "_"),
+				tag:   fld.GetTag(),
 			}
 
 			if slices118.ContainsFunc(fields, func(item field) bool {
-				return item.tag.Get(d.name) == member.tag.Get(d.name)
+				return item.key == member.key
 			}) {
 				continue
 			}
@@ -90,7 +206,7 @@ func (d envDriver) getRootMap(fields []field) map[int]map[string]string {
 	root := make(map[string]string)
 
 	for _, field := range fields {
-		root[field.path] += field.genDoc(d.name)
+		root[field.path] += field.genDoc()
 		roots[field.depth] = root
 	}
 	return roots
@@ -118,13 +234,17 @@ func (d envDriver) GenDoc(registers ...*tinyconf.Registered) string {
 	return doc
 }
 
-func New() (tinyconf.Driver, error) {
+func New(opts ...Option) (tinyconf.Driver, error) {
 	setENVsFromExecutable()
 	setENVsFromWD()
 
-	return envDriver{
+	d := envDriver{
 		name: "env",
-	}, nil
+	}
+	for _, opt := range opts {
+		opt.apply(&d)
+	}
+	return d, nil
 }
 
 func setENVsFromExecutable() {
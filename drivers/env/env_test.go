@@ -3,6 +3,7 @@ package env
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/insei/fmap/v3"
 	"github.com/insei/tinyconf"
@@ -58,6 +59,45 @@ func Test_envDriver_GetValue(t *testing.T) {
 			wantErr:       false,
 			expectedValue: "value",
 		},
+		"EnvDurationWithDays": {
+			setup: func() {
+				os.Setenv("TEST", "1d12h")
+			},
+			getField: func() fmap.Field {
+				storage, _ := fmap.Get[struct {
+					Test time.Duration `env:"TEST"`
+				}]()
+				return storage.MustFind("Test")
+			},
+			wantErr:       false,
+			expectedValue: 36 * time.Hour,
+		},
+		"EnvBase64Encoded": {
+			setup: func() {
+				os.Setenv("TEST", "c2VjcmV0")
+			},
+			getField: func() fmap.Field {
+				storage, _ := fmap.Get[struct {
+					Test []byte `env:"TEST" encoding:"base64"`
+				}]()
+				return storage.MustFind("Test")
+			},
+			wantErr:       false,
+			expectedValue: []byte("secret"),
+		},
+		"EnvJSONEncodedMap": {
+			setup: func() {
+				os.Setenv("TEST", `{"a":1}`)
+			},
+			getField: func() fmap.Field {
+				storage, _ := fmap.Get[struct {
+					Test map[string]int `env:"TEST" encoding:"json"`
+				}]()
+				return storage.MustFind("Test")
+			},
+			wantErr:       false,
+			expectedValue: map[string]int{"a": 1},
+		},
 		"InvalidEnvValue": {
 			setup: func() {
 				os.Setenv("TEST", "value")
@@ -89,6 +129,133 @@ func Test_envDriver_GetValue(t *testing.T) {
 	}
 }
 
+func Test_deriveKey(t *testing.T) {
+	tests := map[string]string{
+		"Port":             "PORT",
+		"HTTP.Auth.Issuer": "HTTP_AUTH_ISSUER",
+		"HTTP.MaxBody":     "HTTP_MAX_BODY",
+		"HTTPPort":         "HTTP_PORT",
+		"Service.ID":       "SERVICE_ID",
+		"Redis2Host":       "REDIS2_HOST",
+	}
+	for path, expected := range tests {
+		t.Run(path, func(t *testing.T) {
+			assert.Equal(t, expected, deriveKey(path))
+		})
+	}
+}
+
+func Test_envDriver_GetValueWithOptions(t *testing.T) {
+	type Config struct {
+		HTTP struct {
+			Auth struct {
+				Issuer string
+				Alg    string `env:"ALG"`
+				Secret string `env:"-"`
+			}
+		}
+		DB struct {
+			Host string
+			Port int `env:"DB_PORT"`
+		} `env:"-"`
+	}
+	storage, _ := fmap.Get[Config]()
+
+	tests := map[string]struct {
+		opts          []Option
+		path          string
+		setup         func()
+		wantErr       error
+		expectedValue any
+		expectedKey   string
+	}{
+		"NoAutoKeys": {
+			path:    "HTTP.Auth.Issuer",
+			setup:   func() { os.Setenv("HTTP_AUTH_ISSUER", "me") },
+			wantErr: tinyconf.ErrIncorrectTagSettings,
+		},
+		"AutoKeys": {
+			opts:          []Option{AutoKeys()},
+			path:          "HTTP.Auth.Issuer",
+			setup:         func() { os.Setenv("HTTP_AUTH_ISSUER", "me") },
+			expectedValue: "me",
+			expectedKey:   "HTTP_AUTH_ISSUER",
+		},
+		"AutoKeysWithPrefix": {
+			opts:          []Option{WithPrefix("MYAPP_"), AutoKeys()},
+			path:          "HTTP.Auth.Issuer",
+			setup:         func() { os.Setenv("MYAPP_HTTP_AUTH_ISSUER", "prefixed") },
+			expectedValue: "prefixed",
+			expectedKey:   "MYAPP_HTTP_AUTH_ISSUER",
+		},
+		"PrefixWithTag": {
+			opts:          []Option{WithPrefix("MYAPP_"), AutoKeys()},
+			path:          "HTTP.Auth.Alg",
+			setup:         func() { os.Setenv("MYAPP_ALG", "SHA256") },
+			expectedValue: "SHA256",
+			expectedKey:   "MYAPP_ALG",
+		},
+		"OptOut": {
+			opts:    []Option{AutoKeys()},
+			path:    "HTTP.Auth.Secret",
+			setup:   func() { os.Setenv("HTTP_AUTH_SECRET", "secret") },
+			wantErr: tinyconf.ErrIncorrectTagSettings,
+		},
+		"OptOutSection": {
+			opts:    []Option{AutoKeys()},
+			path:    "DB.Host",
+			setup:   func() { os.Setenv("DB_HOST", "db.local") },
+			wantErr: tinyconf.ErrIncorrectTagSettings,
+		},
+		"OptOutSectionWithTag": {
+			opts:    []Option{AutoKeys()},
+			path:    "DB.Port",
+			setup:   func() { os.Setenv("DB_PORT", "5432") },
+			wantErr: tinyconf.ErrIncorrectTagSettings,
+		},
+	}
+
+	for tn, tc := range tests {
+		t.Run(tn, func(t *testing.T) {
+			os.Clearenv()
+			tc.setup()
+			d, _ := New(tc.opts...)
+			val, err := d.GetValue(storage.MustFind(tc.path))
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedValue, val.Value)
+			assert.Equal(t, tc.expectedKey, val.Source)
+		})
+	}
+}
+
+func TestEnvDriver_GenDocAutoKeys(t *testing.T) {
+	type Config struct {
+		HTTP struct {
+			Host   string `doc:"http host"`
+			Port   int    `env:"PORT" doc:"http port"`
+			Secret string `env:"-"`
+		}
+	}
+	storage, _ := fmap.Get[Config]()
+	conf := Config{}
+	conf.HTTP.Host = "localhost"
+	conf.HTTP.Port = 8080
+
+	driver := envDriver{name: "env", prefix: "MYAPP_", autoKeys: true}
+	out := driver.GenDoc(&tinyconf.Registered{Storage: storage, Config: conf})
+	assert.Equal(t, `#http host
+#MYAPP_HTTP_HOST=localhost
+
+#http port
+#MYAPP_PORT=8080
+
+`, out)
+}
+
 func TestEnvDriver_GenDoc(t *testing.T) {
 	type TestingFirstStruct struct {
 		Service struct {
@@ -326,3 +493,142 @@ func TestNew(t *testing.T) {
 		})
 	}
 }
+
+func TestEnvDriver_MapSections(t *testing.T) {
+	type DB struct {
+		Host string `env:"HOST"`
+		Port int    `env:"PORT"`
+		Pass string
+	}
+	type Config struct {
+		DB map[string]DB `env:"DB" key:"name"`
+	}
+	os.Clearenv()
+	os.Setenv("APP_DB_MAIN_HOST", "main.local")
+	os.Setenv("APP_DB_REPORTS_V2_PORT", "5433")
+	os.Setenv("APP_DB_MAIN_PASS", "no auto keys")
+	os.Setenv("APP_OTHER_HOST", "other")
+
+	d, _ := New(WithPrefix("APP_"))
+	storage, _ := fmap.Get[Config]()
+	keys, err := d.(tinyconf.KeysDriver).GetKeys(storage.MustFind("DB"))
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"main", "reports_v2"}, keys)
+
+	m, _ := tinyconf.New(tinyconf.WithDriver(d))
+	conf := &Config{}
+	assert.NoError(t, m.Register(conf))
+	assert.NoError(t, m.Parse(conf))
+	assert.Equal(t, map[string]DB{
+		"main":       {Host: "main.local"},
+		"reports_v2": {Port: 5433},
+	}, conf.DB)
+
+	assert.Equal(t, "#\n#APP_DB_<NAME>_HOST=\n#\n#APP_DB_<NAME>_PORT=0\n\n", d.GenDoc(&tinyconf.Registered{Storage: storage, Config: &Config{}}))
+
+	os.Clearenv()
+	_, err = d.(tinyconf.KeysDriver).GetKeys(storage.MustFind("DB"))
+	assert.ErrorIs(t, err, tinyconf.ErrValueNotFound)
+}
+
+func TestEnvDriver_MapSectionsAutoKeys(t *testing.T) {
+	type Config struct {
+		Storage struct {
+			Buckets map[string]*struct {
+				MaxSize int
+			}
+		}
+	}
+	os.Clearenv()
+	os.Setenv("STORAGE_BUCKETS_LOGS_MAX_SIZE", "10")
+	d, _ := New(AutoKeys())
+	m, _ := tinyconf.New(tinyconf.WithDriver(d))
+	conf := &Config{}
+	assert.NoError(t, m.Register(conf))
+	assert.NoError(t, m.Parse(conf))
+	assert.Equal(t, 10, conf.Storage.Buckets["logs"].MaxSize)
+}
+
+func TestEnvDriver_SliceSections(t *testing.T) {
+	type Server struct {
+		Host string `env:"HOST"`
+		Port int    `env:"PORT"`
+	}
+	type Config struct {
+		Servers []Server `env:"SERVERS"`
+	}
+	os.Clearenv()
+	os.Setenv("SERVERS_0_HOST", "a.local")
+	os.Setenv("SERVERS_1_HOST", "b.local")
+	os.Setenv("SERVERS_1_PORT", "8080")
+	os.Setenv("SERVERS_MAIN_HOST", "not an index")
+
+	d, _ := New()
+	m, _ := tinyconf.New(tinyconf.WithDriver(d))
+	conf := &Config{}
+	assert.NoError(t, m.Register(conf))
+	assert.NoError(t, m.Parse(conf))
+	assert.Equal(t, []Server{{Host: "a.local"}, {Host: "b.local", Port: 8080}}, conf.Servers)
+
+	storage, _ := fmap.Get[Config]()
+	assert.Equal(t, "#\n#SERVERS_0_HOST=\n#\n#SERVERS_0_PORT=0\n\n", d.GenDoc(&tinyconf.Registered{Storage: storage, Config: &Config{}}))
+}
+
+type variantStorage interface{}
+
+type variantS3 struct {
+	Bucket string `env:"S3_BUCKET" doc:"bucket name"`
+}
+
+func TestEnvDriver_Variants(t *testing.T) {
+	tinyconf.RegisterVariant[variantStorage]("s3", variantS3{})
+	type Config struct {
+		Storage variantStorage `env:"STORAGE"`
+	}
+	os.Clearenv()
+	os.Setenv("STORAGE_TYPE", "s3")
+	os.Setenv("S3_BUCKET", "data")
+	d, _ := New()
+	m, _ := tinyconf.New(tinyconf.WithDriver(d))
+	conf := &Config{}
+	assert.NoError(t, m.Register(conf))
+	assert.NoError(t, m.Parse(conf))
+	assert.Equal(t, variantS3{Bucket: "data"}, conf.Storage)
+
+	storage, _ := fmap.Get[Config]()
+	assert.Equal(t, "#one of: s3\n#STORAGE_TYPE=s3\n\n#(type=s3) bucket name\n#S3_BUCKET=\n\n",
+		d.GenDoc(&tinyconf.Registered{Storage: storage, Config: &Config{}}))
+}
+
+func TestEnvDriver_NamedConfigs(t *testing.T) {
+	type DB struct {
+		Host string `env:"HOST" doc:"db host"`
+		Port int
+	}
+	os.Clearenv()
+	os.Setenv("APP_PRIMARY_HOST", "primary.local")
+	os.Setenv("APP_REPLICA_PORT", "5433")
+	d, _ := New(WithPrefix("APP_"), AutoKeys())
+	m, _ := tinyconf.New(tinyconf.WithDriver(d))
+	primary, replica := &DB{}, &DB{}
+	assert.NoError(t, m.RegisterNamed("primary", primary))
+	assert.NoError(t, m.RegisterNamed("replica", replica))
+	assert.NoError(t, m.Parse(primary))
+	assert.NoError(t, m.Parse(replica))
+	assert.Equal(t, &DB{Host: "primary.local"}, primary)
+	assert.Equal(t, &DB{Port: 5433}, replica)
+	assert.Equal(t, "#db host\n#APP_PRIMARY_HOST=primary.local\n#\n#APP_PRIMARY_PORT=0\n\n"+
+		"#db host\n#APP_REPLICA_HOST=\n#\n#APP_REPLICA_PORT=5433\n\n", m.GenDoc("env"))
+}
+
+func TestEnvDriver_Lookup(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("APP_PLUGIN_PORT", "9090")
+	d, _ := New(WithPrefix("APP_"))
+	m, _ := tinyconf.New(tinyconf.WithDriver(d))
+	port, err := m.GetInt("plugin.port")
+	assert.NoError(t, err)
+	assert.Equal(t, 9090, port)
+	_, ok := m.Lookup("plugin.host")
+	assert.False(t, ok)
+}
@@ -0,0 +1,30 @@
+package env
+
+type Option interface {
+	apply(*envDriver)
+}
+
+type prefixOption struct {
+	prefix string
+}
+
+func (o prefixOption) apply(d *envDriver) {
+	d.prefix = o.prefix
+}
+
+// WithPrefix sets the prefix prepended to every env key, i.e. both to keys from env tags and derived keys.
+func WithPrefix(prefix string) Option {
+	return prefixOption{prefix: prefix}
+}
+
+type autoKeysOption struct{}
+
+func (o autoKeysOption) apply(d *envDriver) {
+	d.autoKeys = true
+}
+
+// AutoKeys enables env keys derivation from the field struct path for fields without env tag,
+// e.g. HTTP.Auth.Issuer -> HTTP_AUTH_ISSUER. Use `env:"-"` tag to exclude field from the driver.
+func AutoKeys() Option {
+	return autoKeysOption{}
+}
@@ -0,0 +1,257 @@
+package file
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/insei/fmap/v3"
+
+	"github.com/insei/tinyconf"
+	"github.com/insei/tinyconf/slices118"
+)
+
+// dataLink is the symlink to the current data directory of Kubernetes ConfigMap and Secret volumes,
+// keys files are symlinks to ..data/<key> and ..data symlink is swapped atomically on update.
+const dataLink = "..data"
+
+// maxSwapRetries limits reads of the data directory that is removed by the concurrent swap.
+const maxSwapRetries = 3
+
+type fileDriver struct {
+	name string
+	dir  string
+	read func(path string) ([]byte, error)
+
+	mu sync.Mutex
+	// target is the resolved ..data directory of cached files
+	target string
+	// states are states of cached files of the plain directory
+	states map[string]fileState
+	files  map[string]string
+}
+
+// fileState is the modification time and the size of the file, files of the plain directory are read again
+// when the state of some file is changed.
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// statFiles returns states of regular files of the directory by names, Kubernetes service entries (..data
+// and timestamped directories) are skipped.
+func statFiles(dir string) (map[string]fileState, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	states := map[string]fileState{}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), "..") {
+			continue
+		}
+		info, err := os.Stat(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if !info.Mode().IsRegular() {
+			continue
+		}
+		states[entry.Name()] = fileState{modTime: info.ModTime(), size: info.Size()}
+	}
+	return states, nil
+}
+
+// isCached reports whether files of the plain directory are cached with the same states.
+func (d *fileDriver) isCached(states map[string]fileState) bool {
+	if d.states == nil || d.target != "" || len(states) != len(d.states) {
+		return false
+	}
+	for name, state := range states {
+		cached, ok := d.states[name]
+		if !ok || !cached.modTime.Equal(state.modTime) || cached.size != state.size {
+			return false
+		}
+	}
+	return true
+}
+
+// readFiles reads files of the directory by names of states, trailing newlines of values are trimmed.
+func (d *fileDriver) readFiles(dir string, states map[string]fileState) (map[string]string, error) {
+	files := map[string]string{}
+	for name := range states {
+		data, err := d.read(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		files[name] = strings.TrimRight(string(data), "\r\n")
+	}
+	return files, nil
+}
+
+// load returns files of the directory. Files of Kubernetes volumes are read from the resolved ..data directory,
+// so all values are taken from the same version of the volume, and they are cached until ..data is swapped.
+// Files of the plain directory are cached until some file is added, removed or changed.
+func (d *fileDriver) load() (map[string]string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	link := filepath.Join(d.dir, dataLink)
+	for i := 0; ; i++ {
+		if _, err := os.Lstat(link); errors.Is(err, os.ErrNotExist) {
+			// plain directory
+			break
+		}
+		target, err := filepath.EvalSymlinks(link)
+		if errors.Is(err, os.ErrNotExist) && i < maxSwapRetries {
+			// ..data points to the data directory removed by the swap
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error while resolve %s: %s", link, err)
+		}
+		if target == d.target {
+			return d.files, nil
+		}
+		states, err := statFiles(target)
+		var files map[string]string
+		if err == nil {
+			files, err = d.readFiles(target, states)
+		}
+		if errors.Is(err, os.ErrNotExist) && i < maxSwapRetries {
+			// the data directory was removed by the swap, read the new one
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error while read %s: %s", target, err)
+		}
+		d.target, d.states, d.files = target, nil, files
+		return files, nil
+	}
+	states, err := statFiles(d.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: error while read dir: %s", tinyconf.ErrValueNotFound, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error while read dir: %s", err)
+	}
+	if d.isCached(states) {
+		return d.files, nil
+	}
+	files, err := d.readFiles(d.dir, states)
+	if err != nil {
+		return nil, fmt.Errorf("error while read dir: %s", err)
+	}
+	d.target, d.states, d.files = "", states, files
+	return files, nil
+}
+
+// getKey returns the file name of the field: `file` tag, `env` tag or the struct path, i.e. HTTP.Port.
+// Fields of maps and slices entries are always read by the struct path, i.e. DB.main.Host.
+func (d *fileDriver) getKey(field fmap.Field) (string, bool) {
+	tag := field.GetTag()
+	if tag.Get(d.name) == "-" {
+		return "", false
+	}
+	if !tinyconf.IsDynamicField(field) {
+		for _, name := range []string{d.name, "env"} {
+			if key := tag.Get(name); key != "" {
+				return key, true
+			}
+		}
+	}
+	return field.GetStructPath(), field.IsExported()
+}
+
+func (d *fileDriver) GetValue(field fmap.Field) (*tinyconf.Value, error) {
+	key, ok := d.getKey(field)
+	if !ok {
+		return nil, fmt.Errorf("%w: file key is not set for %s config field", tinyconf.ErrIncorrectTagSettings, field.GetStructPath())
+	}
+	files, err := d.load()
+	if err != nil {
+		return nil, err
+	}
+	fileVal, ok := files[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s file is not found in %s for %s config field", tinyconf.ErrValueNotFound, key, d.dir, field.GetStructPath())
+	}
+	value, err := tinyconf.DecodeField(field, fileVal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s file value for %s config field: %w", key, field.GetStructPath(), err)
+	}
+	return &tinyconf.Value{Source: filepath.Join(d.dir, key), Value: value}, nil
+}
+
+// GetKeys returns keys of map[string]T section entries or indexes of []T section items by files names,
+// i.e. main for DB.main.Host file of DB section.
+func (d *fileDriver) GetKeys(field fmap.Field) ([]string, error) {
+	files, err := d.load()
+	if err != nil {
+		return nil, err
+	}
+	prefix := field.GetStructPath() + "."
+	var keys []string
+	for name := range files {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		key := strings.Split(name[len(prefix):], ".")[0]
+		if !slices118.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w: no %s files in %s for %s config field", tinyconf.ErrValueNotFound, prefix, d.dir, field.GetStructPath())
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (d *fileDriver) GetName() string {
+	return d.name
+}
+
+// GenDoc returns data of Kubernetes ConfigMap with files of value fields of registered configs and default values,
+// values of hidden fields are empty.
+func (d *fileDriver) GenDoc(registers ...*tinyconf.Registered) string {
+	doc := "data:\n"
+	var keys []string
+	for _, register := range registers {
+		for _, configField := range register.GetFields() {
+			fld := configField.Field
+			if !tinyconf.IsValueField(fld) {
+				continue
+			}
+			key, ok := d.getKey(fld)
+			if !ok || slices118.Contains(keys, key) {
+				continue
+			}
+			keys = append(keys, key)
+			value := tinyconf.FormatFieldValue(fld, configField.Value)
+			if tinyconf.IsHiddenField(fld, false) {
+				value = ""
+			}
+			if fieldDoc := fld.GetTag().Get("doc"); fieldDoc != "" {
+				doc += "  #" + fieldDoc + "\n"
+			}
+			doc += fmt.Sprintf("  %s: %s\n", key, strconv.Quote(value))
+		}
+	}
+	return doc
+}
+
+// New returns the driver that reads values from files of the directory, i.e. Kubernetes ConfigMap or Secret
+// volume, every file is the value of the field. The file name is `file` tag, `env` tag or the struct path of the field.
+func New(dir string) (tinyconf.Driver, error) {
+	return &fileDriver{
+		name: "file",
+		dir:  dir,
+		read: os.ReadFile,
+	}, nil
+}
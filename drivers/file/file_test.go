@@ -0,0 +1,183 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/insei/fmap/v3"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/insei/tinyconf"
+	"github.com/insei/tinyconf/drivers/internal/drivertest"
+)
+
+type Server struct {
+	Host string
+	Port int
+}
+
+type Config struct {
+	Name     string        `file:"app-name" doc:"application name"`
+	Port     int           `env:"HTTP_PORT"`
+	Timeout  time.Duration `doc:"request timeout"`
+	Password string        `file:"password" hidden:"true"`
+	Skipped  string        `file:"-"`
+	DB       map[string]Server
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	assert.NoError(t, os.MkdirAll(dir, 0o700))
+	for name, data := range files {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(data), 0o600))
+	}
+}
+
+// writeVolume writes files like Kubernetes does: to the new timestamped directory, then ..data symlink is swapped
+// and keys symlinks point to ..data/<key>.
+func writeVolume(t *testing.T, dir, version string, files map[string]string) {
+	writeFiles(t, filepath.Join(dir, version), files)
+	tmpLink := filepath.Join(dir, "..data_tmp")
+	assert.NoError(t, os.Symlink(version, tmpLink))
+	assert.NoError(t, os.Rename(tmpLink, filepath.Join(dir, dataLink)))
+	for name := range files {
+		link := filepath.Join(dir, name)
+		if _, err := os.Lstat(link); err == nil {
+			continue
+		}
+		assert.NoError(t, os.Symlink(filepath.Join(dataLink, name), link))
+	}
+}
+
+func TestFileDriver_GetValue(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"app-name":     "app\n",
+		"HTTP_PORT":    "8080\r\n",
+		"Timeout":      "1m",
+		"Skipped":      "skipped",
+		"..hidden":     "hidden",
+		"DB.main.Host": "main.local",
+	})
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "subdir"), 0o700))
+	d, _ := New(dir)
+	assert.Equal(t, "file", d.GetName())
+	storage, _ := fmap.Get[Config]()
+	drivertest.GetValue(t, d, storage, []drivertest.Case{
+		{Path: "Name", Want: &tinyconf.Value{Source: filepath.Join(dir, "app-name"), Value: "app"}},
+		{Path: "Port", Want: &tinyconf.Value{Source: filepath.Join(dir, "HTTP_PORT"), Value: 8080}},
+		{Path: "Timeout", Want: &tinyconf.Value{Source: filepath.Join(dir, "Timeout"), Value: time.Minute}},
+		{Path: "Password", WantErr: tinyconf.ErrValueNotFound},
+		{Path: "Skipped", WantErr: tinyconf.ErrIncorrectTagSettings},
+	})
+
+	drivertest.GetKeys(t, d, storage.MustFind("DB"), []string{"main"})
+
+	writeFiles(t, dir, map[string]string{"HTTP_PORT": "http"})
+	_, err := d.GetValue(storage.MustFind("Port"))
+	assert.ErrorContains(t, err, "failed to parse HTTP_PORT file value for Port config field")
+}
+
+func TestFileDriver_MissingDir(t *testing.T) {
+	d, _ := New(filepath.Join(t.TempDir(), "missing"))
+	storage, _ := fmap.Get[Config]()
+	_, err := d.GetValue(storage.MustFind("Name"))
+	assert.ErrorIs(t, err, tinyconf.ErrValueNotFound)
+}
+
+func TestFileDriver_Parse(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"app-name":        "app",
+		"DB.main.Host":    "main.local",
+		"DB.main.Port":    "5432",
+		"DB.reports.Port": "5433",
+	})
+	d, _ := New(dir)
+	conf := &Config{}
+	drivertest.Parse(t, d, conf)
+	assert.Equal(t, "app", conf.Name)
+	assert.Equal(t, map[string]Server{"main": {Host: "main.local", Port: 5432}, "reports": {Port: 5433}}, conf.DB)
+}
+
+func TestFileDriver_DataSwap(t *testing.T) {
+	dir := t.TempDir()
+	writeVolume(t, dir, "..2024_01_01", map[string]string{"app-name": "v1", "HTTP_PORT": "1"})
+	d, _ := New(dir)
+	conf := &Config{}
+	m := drivertest.Parse(t, d, conf)
+	assert.Equal(t, "v1", conf.Name)
+	assert.Equal(t, 1, conf.Port)
+
+	// the new version is written, the old data directory is removed after the swap
+	writeVolume(t, dir, "..2024_01_02", map[string]string{"app-name": "v2", "HTTP_PORT": "2"})
+	assert.NoError(t, os.RemoveAll(filepath.Join(dir, "..2024_01_01")))
+	assert.NoError(t, m.Parse(conf))
+	assert.Equal(t, "v2", conf.Name)
+	assert.Equal(t, 2, conf.Port)
+
+	// files of the current version are cached until the swap
+	fileDriver := d.(*fileDriver)
+	assert.Equal(t, filepath.Join(dir, "..2024_01_02"), fileDriver.target)
+	assert.Equal(t, map[string]string{"app-name": "v2", "HTTP_PORT": "2"}, fileDriver.files)
+}
+
+func TestFileDriver_ReadsFilesOncePerParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(t *testing.T, dir, version string, files map[string]string)
+	}{
+		{name: "plain directory", write: func(t *testing.T, dir, _ string, files map[string]string) {
+			writeFiles(t, dir, files)
+		}},
+		{name: "volume", write: writeVolume},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tt.write(t, dir, "..2024_01_01", map[string]string{"app-name": "v1", "HTTP_PORT": "1"})
+			d, _ := New(dir)
+			reads := 0
+			d.(*fileDriver).read = func(path string) ([]byte, error) {
+				reads++
+				return os.ReadFile(path)
+			}
+			conf := &Config{}
+			m := drivertest.Parse(t, d, conf)
+			assert.Equal(t, 2, reads)
+			assert.NoError(t, m.Parse(conf))
+			assert.Equal(t, 2, reads)
+
+			tt.write(t, dir, "..2024_01_02", map[string]string{"app-name": "v2.0", "HTTP_PORT": "1"})
+			assert.NoError(t, m.Parse(conf))
+			assert.Equal(t, 4, reads)
+			assert.Equal(t, "v2.0", conf.Name)
+		})
+	}
+}
+
+func TestFileDriver_DanglingData(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.Symlink("..removed", filepath.Join(dir, dataLink)))
+	d, _ := New(dir)
+	storage, _ := fmap.Get[Config]()
+	_, err := d.GetValue(storage.MustFind("Name"))
+	assert.ErrorContains(t, err, "error while resolve")
+}
+
+func TestFileDriver_GenDoc(t *testing.T) {
+	d, _ := New("/etc/app")
+	storage, _ := fmap.Get[Config]()
+	conf := &Config{Name: "app", Port: 8080, Password: "secret"}
+	assert.Equal(t, `data:
+  #application name
+  app-name: "app"
+  HTTP_PORT: "8080"
+  #request timeout
+  Timeout: "0s"
+  password: ""
+  DB.<key>.Host: ""
+  DB.<key>.Port: "0"
+`, d.GenDoc(&tinyconf.Registered{Storage: storage, Config: conf}))
+}
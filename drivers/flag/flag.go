@@ -0,0 +1,186 @@
+package flag
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/insei/fmap/v3"
+
+	"github.com/insei/tinyconf"
+)
+
+type flagDriver struct {
+	name      string
+	fs        *flag.FlagSet
+	configs   []any
+	autoNames bool
+	// flags are flags registered by the driver by names
+	flags map[string]*value
+}
+
+// value is the flag.Value of the config field, the value is checked by the field decoder when the flag is set.
+type value struct {
+	field fmap.Field
+	value string
+	set   bool
+}
+
+func (v *value) String() string {
+	if v == nil {
+		return ""
+	}
+	return v.value
+}
+
+func (v *value) Set(s string) error {
+	if _, err := tinyconf.DecodeField(v.field, s); err != nil {
+		return err
+	}
+	v.value = s
+	v.set = true
+	return nil
+}
+
+// IsBoolFlag allows to set bool fields by the flag without value, i.e. -debug.
+func (v *value) IsBoolFlag() bool {
+	return v != nil && v.field != nil && v.field.GetDereferencedType().Kind() == reflect.Bool
+}
+
+// deriveName converts field struct path to flag name, e.g. HTTP.MaxBody -> http-max-body.
+func deriveName(structPath string) string {
+	return strings.ToLower(strings.Join(tinyconf.SplitWords(structPath), "-"))
+}
+
+// getName returns the flag name of the field, flags of dynamic sections entries are not supported,
+// because flags are registered before configs are parsed.
+func (d *flagDriver) getName(field fmap.Field) (string, bool) {
+	if tinyconf.IsDynamicField(field) {
+		return "", false
+	}
+	name, ok := field.GetTag().Lookup(d.name)
+	if name == "-" {
+		return "", false
+	}
+	if ok && name != "" {
+		return name, true
+	}
+	if !d.autoNames || !field.IsExported() {
+		return "", false
+	}
+	return deriveName(field.GetStructPath()), true
+}
+
+// define defines flags of registers value fields in the flag set, zero values and current values of hidden fields
+// are not used as defaults, so they are not shown in the help output.
+func (d *flagDriver) define(fs *flag.FlagSet, registers []*tinyconf.Registered, flags map[string]*value) error {
+	for _, register := range registers {
+		for _, configField := range register.GetFields() {
+			fld := configField.Field
+			if !tinyconf.IsValueField(fld) {
+				continue
+			}
+			name, ok := d.getName(fld)
+			if !ok {
+				continue
+			}
+			if _, ok = flags[name]; ok {
+				continue
+			}
+			if fs.Lookup(name) != nil {
+				return fmt.Errorf("flag -%s of %s config field is already defined", name, fld.GetStructPath())
+			}
+			v := &value{field: fld}
+			if !tinyconf.IsHiddenField(fld, false) && !isZero(configField.Value) {
+				v.value = tinyconf.FormatFieldValue(fld, configField.Value)
+			}
+			fs.Var(v, name, fld.GetTag().Get("doc"))
+			flags[name] = v
+		}
+	}
+	return nil
+}
+
+func isZero(val any) bool {
+	valOf := reflect.ValueOf(val)
+	return !valOf.IsValid() || valOf.IsZero()
+}
+
+func newRegistered(conf any) (*tinyconf.Registered, error) {
+	storage, err := fmap.GetFrom(conf)
+	if err != nil {
+		return nil, err
+	}
+	return &tinyconf.Registered{Storage: storage, Config: conf}, nil
+}
+
+// GetValue returns the value only for flags set in the command line, so values of drivers with lower priority
+// are kept for other fields.
+func (d *flagDriver) GetValue(field fmap.Field) (*tinyconf.Value, error) {
+	name, ok := d.getName(field)
+	if !ok {
+		return nil, fmt.Errorf("%w: flag tag is not set for %s config field", tinyconf.ErrIncorrectTagSettings, field.GetStructPath())
+	}
+	v, ok := d.flags[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: flag -%s is not registered for %s config field", tinyconf.ErrValueNotFound, name, field.GetStructPath())
+	}
+	if !v.set {
+		return nil, fmt.Errorf("%w: flag -%s is not set for %s config field", tinyconf.ErrValueNotFound, name, field.GetStructPath())
+	}
+	val, err := tinyconf.DecodeField(field, v.value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse flag -%s value for %s config field: %w", name, field.GetStructPath(), err)
+	}
+	return &tinyconf.Value{Source: "-" + name, Value: val}, nil
+}
+
+func (d *flagDriver) GetName() string {
+	return d.name
+}
+
+// GenDoc returns the help output of flags of registered configs, i.e. the output of flag.FlagSet PrintDefaults.
+func (d *flagDriver) GenDoc(registers ...*tinyconf.Registered) string {
+	fs := flag.NewFlagSet(d.fs.Name(), flag.ContinueOnError)
+	var out bytes.Buffer
+	fs.SetOutput(&out)
+	if err := d.define(fs, registers, map[string]*value{}); err != nil {
+		return err.Error()
+	}
+	fs.PrintDefaults()
+	return out.String()
+}
+
+// New returns the driver that reads values of flags set in the command line, flags are registered in the
+// flag set for value fields of configs from WithConfig options by `flag` tags, i.e. `flag:"http-port"`,
+// the usage of the flag is the `doc` tag. Parse the flag set after the driver is created:
+//
+//	d, err := flag.New(goflag.CommandLine, flag.WithConfig(&conf), flag.AutoNames())
+//	goflag.Parse()
+func New(fs *flag.FlagSet, opts ...Option) (tinyconf.Driver, error) {
+	if fs == nil {
+		return nil, fmt.Errorf("flag set is nil")
+	}
+	d := &flagDriver{
+		name:  "flag",
+		fs:    fs,
+		flags: map[string]*value{},
+	}
+	for _, opt := range opts {
+		opt.apply(d)
+	}
+	registers := make([]*tinyconf.Registered, 0, len(d.configs))
+	for _, conf := range d.configs {
+		register, err := newRegistered(conf)
+		if err != nil {
+			return nil, err
+		}
+		registers = append(registers, register)
+	}
+	if err := d.define(fs, registers, d.flags); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
@@ -0,0 +1,167 @@
+package flag
+
+import (
+	"flag"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/insei/fmap/v3"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/insei/tinyconf"
+	"github.com/insei/tinyconf/drivers/internal/drivertest"
+)
+
+type Config struct {
+	Name     string        `flag:"name" doc:"application name"`
+	Debug    bool          `doc:"debug mode"`
+	Password string        `flag:"password" hidden:"true" doc:"admin password"`
+	Skipped  string        `flag:"-"`
+	Timeout  time.Duration `doc:"request timeout"`
+	HTTP     struct {
+		Port int `flag:"http-port" doc:"http port"`
+		Auth struct {
+			Issuer string
+		}
+	}
+	Servers []struct {
+		Host string `flag:"host"`
+	}
+}
+
+func newFlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs
+}
+
+func Test_deriveName(t *testing.T) {
+	tests := map[string]string{
+		"Name":              "name",
+		"HTTP.Port":         "http-port",
+		"HTTP.Auth.Issuer":  "http-auth-issuer",
+		"HTTP.MaxBodySize":  "http-max-body-size",
+		"DB.ReplicaURLList": "db-replica-url-list",
+		"OAuth2Client":      "o-auth2-client",
+	}
+	for path, want := range tests {
+		assert.Equal(t, want, deriveName(path), path)
+	}
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(nil)
+	assert.Error(t, err)
+
+	fs := newFlagSet()
+	conf := &Config{Name: "app", Password: "secret"}
+	_, err = New(fs, WithConfig(conf), AutoNames())
+	assert.NoError(t, err)
+	for _, name := range []string{"name", "debug", "password", "timeout", "http-port", "http-auth-issuer"} {
+		assert.NotNil(t, fs.Lookup(name), name)
+	}
+	for _, name := range []string{"skipped", "host", "servers-0-host"} {
+		assert.Nil(t, fs.Lookup(name), name)
+	}
+	assert.Equal(t, "app", fs.Lookup("name").DefValue)
+	assert.Equal(t, "", fs.Lookup("password").DefValue)
+	assert.Equal(t, "application name", fs.Lookup("name").Usage)
+
+	// flags of several configs with the same name are registered once
+	_, err = New(newFlagSet(), WithConfig(conf), WithConfig(&Config{}))
+	assert.NoError(t, err)
+
+	fs = newFlagSet()
+	fs.String("name", "", "")
+	_, err = New(fs, WithConfig(conf))
+	assert.EqualError(t, err, "flag -name of Name config field is already defined")
+}
+
+func TestFlagDriver_GetValue(t *testing.T) {
+	fs := newFlagSet()
+	d, err := New(fs, WithConfig(&Config{}), AutoNames())
+	assert.NoError(t, err)
+	assert.NoError(t, fs.Parse([]string{"-debug", "--http-port=8080", "-timeout", "1m"}))
+
+	storage, _ := fmap.Get[Config]()
+	drivertest.GetValue(t, d, storage, []drivertest.Case{
+		{Path: "Debug", Want: &tinyconf.Value{Source: "-debug", Value: true}},
+		{Path: "HTTP.Port", Want: &tinyconf.Value{Source: "-http-port", Value: 8080}},
+		{Path: "Timeout", Want: &tinyconf.Value{Source: "-timeout", Value: time.Minute}},
+		{Path: "Name", WantErr: tinyconf.ErrValueNotFound},
+		{Path: "Skipped", WantErr: tinyconf.ErrIncorrectTagSettings},
+	})
+
+	d, _ = New(newFlagSet(), WithConfig(&Config{}))
+	_, err = d.GetValue(storage.MustFind("Debug"))
+	assert.ErrorIs(t, err, tinyconf.ErrIncorrectTagSettings)
+
+	type Other struct {
+		Port int `flag:"port"`
+	}
+	otherStorage, _ := fmap.Get[Other]()
+	_, err = d.GetValue(otherStorage.MustFind("Port"))
+	assert.ErrorIs(t, err, tinyconf.ErrValueNotFound)
+}
+
+func TestFlagDriver_InvalidValue(t *testing.T) {
+	fs := newFlagSet()
+	_, err := New(fs, WithConfig(&Config{}))
+	assert.NoError(t, err)
+	err = fs.Parse([]string{"-http-port", "http"})
+	assert.ErrorContains(t, err, `invalid value "http" for flag -http-port`)
+}
+
+func TestFlagDriver_Parse(t *testing.T) {
+	type Conf struct {
+		Name string `flag:"name"`
+		Port int    `flag:"port"`
+	}
+	fs := newFlagSet()
+	conf := &Conf{}
+	flagDriver, err := New(fs, WithConfig(conf))
+	assert.NoError(t, err)
+	assert.NoError(t, fs.Parse([]string{"-port", "8080"}))
+
+	m, _ := tinyconf.New(tinyconf.WithDriver(lowPriorityDriver{}), tinyconf.WithDriver(flagDriver))
+	assert.NoError(t, m.Register(conf))
+	assert.NoError(t, m.Parse(conf))
+	// the name is not set by flags, so the value of the driver with lower priority is kept
+	assert.Equal(t, &Conf{Name: "low", Port: 8080}, conf)
+}
+
+type lowPriorityDriver struct{}
+
+func (lowPriorityDriver) GenDoc(...*tinyconf.Registered) string { return "" }
+
+func (lowPriorityDriver) GetName() string { return "low" }
+
+func (lowPriorityDriver) GetValue(field fmap.Field) (*tinyconf.Value, error) {
+	switch field.GetStructPath() {
+	case "Name":
+		return &tinyconf.Value{Source: "low", Value: "low"}, nil
+	case "Port":
+		return &tinyconf.Value{Source: "low", Value: 80}, nil
+	}
+	return nil, tinyconf.ErrValueNotFound
+}
+
+func TestFlagDriver_GenDoc(t *testing.T) {
+	d, _ := New(newFlagSet(), AutoNames())
+	storage, _ := fmap.Get[Config]()
+	conf := &Config{Name: "app", Password: "secret", Timeout: time.Second}
+	assert.Equal(t, `  -debug
+    	debug mode
+  -http-auth-issuer value
+    	
+  -http-port value
+    	http port
+  -name value
+    	application name (default app)
+  -password value
+    	admin password
+  -timeout value
+    	request timeout (default 1s)
+`, d.GenDoc(&tinyconf.Registered{Storage: storage, Config: conf}))
+}
@@ -0,0 +1,31 @@
+package flag
+
+type Option interface {
+	apply(*flagDriver)
+}
+
+type configOption struct {
+	conf any
+}
+
+func (o configOption) apply(d *flagDriver) {
+	d.configs = append(d.configs, o.conf)
+}
+
+// WithConfig registers flags for value fields of the config, current values of the config are flags defaults.
+// Flags are registered by New, so the flag set must be parsed after the driver is created.
+func WithConfig(conf any) Option {
+	return configOption{conf: conf}
+}
+
+type autoNamesOption struct{}
+
+func (o autoNamesOption) apply(d *flagDriver) {
+	d.autoNames = true
+}
+
+// AutoNames enables flag names derivation from the field struct path for fields without flag tag,
+// e.g. HTTP.Auth.Issuer -> http-auth-issuer. Use `flag:"-"` tag to exclude field from the driver.
+func AutoNames() Option {
+	return autoNamesOption{}
+}
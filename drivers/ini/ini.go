@@ -0,0 +1,196 @@
+package ini
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/insei/fmap/v3"
+
+	"github.com/insei/tinyconf"
+	"github.com/insei/tinyconf/drivers/mapdriver"
+	"github.com/insei/tinyconf/slices118"
+	"github.com/insei/tinyconf/strings118"
+)
+
+type iniDriver struct {
+	name  string
+	cache *mapdriver.FileCache[map[string]string]
+}
+
+func decode(data []byte) (map[string]string, error) {
+	values, err := parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode ini: %w", err)
+	}
+	return values, nil
+}
+
+// getKey returns the dotted key of the field, `ini` tags of parent sections are prepended to the field tag.
+func (d *iniDriver) getKey(field fmap.Field) (string, bool) {
+	key := field.GetTagPath(d.name, true)
+	return key, key != ""
+}
+
+func (d *iniDriver) GetValue(field fmap.Field) (*tinyconf.Value, error) {
+	key, ok := d.getKey(field)
+	if !ok {
+		return nil, fmt.Errorf("%w: ini tag is not set for %s config field", tinyconf.ErrIncorrectTagSettings, field.GetStructPath())
+	}
+	values, err := d.cache.Load()
+	if err != nil {
+		return nil, err
+	}
+	iniVal, ok := values[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is not defined in ini config for %s config field", tinyconf.ErrValueNotFound, key, field.GetStructPath())
+	}
+	value, err := tinyconf.DecodeField(field, iniVal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ini value from key %s for %s config field: %w", key, field.GetStructPath(), err)
+	}
+	return &tinyconf.Value{Source: d.name, Value: value}, nil
+}
+
+// GetKeys returns keys of map[string]T section entries or indexes of []T section items, i.e. main for
+// [db.main] section or db.main.host key of `ini:"db"` section.
+func (d *iniDriver) GetKeys(field fmap.Field) ([]string, error) {
+	key, ok := d.getKey(field)
+	if !ok {
+		return nil, fmt.Errorf("%w: ini tag is not set for %s config field", tinyconf.ErrIncorrectTagSettings, field.GetStructPath())
+	}
+	values, err := d.cache.Load()
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for valueKey := range values {
+		rest, ok := strings118.CutPrefix(valueKey, key+".")
+		if !ok {
+			continue
+		}
+		entryKey := strings.Split(rest, ".")[0]
+		if !slices118.Contains(keys, entryKey) {
+			keys = append(keys, entryKey)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w: no %s keys in ini config for %s config field", tinyconf.ErrValueNotFound, key, field.GetStructPath())
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (d *iniDriver) GetName() string {
+	return d.name
+}
+
+type field struct {
+	key   string
+	value string
+	// unset is the nil value, it is documented by the commented key
+	unset bool
+	tag   reflect.StructTag
+}
+
+func (f field) genDoc() string {
+	line := escape(f.key, true) + " ="
+	switch {
+	case f.unset:
+		line = ";" + line
+	case f.value != "":
+		line += " " + escape(f.value, false)
+	}
+	return genComment(f.tag.Get("doc")) + line + "\n"
+}
+
+func genComment(doc string) string {
+	var comment string
+	for _, line := range strings.Split(doc, "\n") {
+		if line != "" {
+			comment += "; " + line + "\n"
+		}
+	}
+	return comment
+}
+
+func isNil(val any) bool {
+	valOf := reflect.ValueOf(val)
+	for valOf.Kind() == reflect.Ptr {
+		if valOf.IsNil() {
+			return true
+		}
+		valOf = valOf.Elem()
+	}
+	return !valOf.IsValid()
+}
+
+// GenDoc returns the ini document with default values, keys without section are written first and values of
+// nested sections are written in [parent.section] sections, docs are written as ';' comments.
+func (d *iniDriver) GenDoc(registers ...*tinyconf.Registered) string {
+	var sections []string
+	sectionDocs := map[string]string{}
+	sectionFields := map[string][]field{}
+	var paths []string
+	for _, register := range registers {
+		for _, configField := range register.GetFields() {
+			fld := configField.Field
+			path, ok := d.getKey(fld)
+			if !ok || slices118.Contains(paths, path) {
+				continue
+			}
+			paths = append(paths, path)
+			if tinyconf.IsSection(fld) {
+				sectionDocs[path] = fld.GetTag().Get("doc")
+				continue
+			}
+			section, key := "", path
+			if i := strings.LastIndex(path, "."); i >= 0 {
+				section, key = path[:i], path[i+1:]
+			}
+			if _, ok := sectionFields[section]; !ok {
+				sections = append(sections, section)
+			}
+			sectionFields[section] = append(sectionFields[section], field{
+				key:   key,
+				value: tinyconf.FormatFieldValue(fld, configField.Value),
+				unset: isNil(configField.Value),
+				tag:   fld.GetTag(),
+			})
+		}
+	}
+	// keys without section must be written before the first section header
+	slices118.SortStableFunc(sections, func(i, j string) int {
+		switch {
+		case i == "" && j != "":
+			return -1
+		case i != "" && j == "":
+			return 1
+		}
+		return 0
+	})
+	var doc string
+	for _, section := range sections {
+		if section != "" {
+			if doc != "" {
+				doc += "\n"
+			}
+			doc += genComment(sectionDocs[section]) + "[" + section + "]\n"
+		}
+		for _, f := range sectionFields[section] {
+			doc += f.genDoc()
+		}
+	}
+	return doc
+}
+
+// New returns the driver that reads values from the ini or java properties file by `ini` tag dotted keys,
+// i.e. `ini:"http.port"` field is read from port key of [http] section or http.port properties key. The file is read
+// again when it is changed, see mapdriver.FileCache.
+func New(file string) (tinyconf.Driver, error) {
+	return &iniDriver{
+		name:  "ini",
+		cache: &mapdriver.FileCache[map[string]string]{Path: file, Decode: decode},
+	}, nil
+}
@@ -0,0 +1,226 @@
+package ini
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/insei/fmap/v3"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/insei/tinyconf"
+	"github.com/insei/tinyconf/drivers/internal/drivertest"
+)
+
+type Server struct {
+	Host string `ini:"host" doc:"server host"`
+	Port int    `ini:"port"`
+}
+
+type Config struct {
+	Name      string            `ini:"name" doc:"application name"`
+	Token     *string           `ini:"token"`
+	Timeout   time.Duration     `ini:"http.timeout"`
+	HTTP      HTTP              `ini:"http" doc:"http server"`
+	Servers   []Server          `ini:"servers"`
+	Databases map[string]Server `ini:"db" key:"name" doc:"databases by name"`
+}
+
+type HTTP struct {
+	Port int `ini:"port" doc:"listen port"`
+	Auth struct {
+		Issuer string `ini:"issuer"`
+	} `ini:"auth"`
+}
+
+func Test_parse(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want map[string]string
+	}{
+		{
+			name: "ini",
+			src: `; comment
+# comment
+name = app
+empty =
+
+[http]
+port=8080
+quoted = "  padded  "
+
+[http.auth]
+issuer : me
+`,
+			want: map[string]string{
+				"name": "app", "empty": "", "http.port": "8080", "http.quoted": "  padded  ", "http.auth.issuer": "me",
+			},
+		},
+		{
+			name: "properties",
+			src: `! comment
+http.port 8080
+http.auth.issuer=me
+message = first \
+          second
+path = C:\\dir\\file
+escaped\ key\=x = \u00e9\t\n
+space = a\ ` + `
+override = 1
+override = 2
+`,
+			want: map[string]string{
+				"http.port": "8080", "http.auth.issuer": "me", "message": "first second", "path": `C:\dir\file`,
+				"escaped key=x": "é\t\n", "space": "a ", "override": "2",
+			},
+		},
+		{
+			name: "crlf",
+			src:  "[a]\r\nb = c\r\n",
+			want: map[string]string{"a.b": "c"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parse(tt.src)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_parseErrors(t *testing.T) {
+	_, err := parse("a = 1\n[section")
+	assert.EqualError(t, err, `ini: line 2: invalid section header "[section"`)
+	_, err = parse(`a = \u00`)
+	assert.EqualError(t, err, `ini: line 1: invalid unicode escape \u00`)
+	_, err = parse(`a = \uzzzz`)
+	assert.EqualError(t, err, `ini: line 1: invalid unicode escape \uzzzz`)
+}
+
+func Test_escape(t *testing.T) {
+	for _, value := range []string{"plain", " padded ", `C:\dir`, "#hash", "[x]", `"quoted"`, "a=b:c", "tab\tnew\nline"} {
+		got, err := parse("key = " + escape(value, false))
+		assert.NoError(t, err)
+		assert.Equal(t, value, got["key"], value)
+	}
+	got, err := parse(escape("odd key=:", true) + " = value")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", got["odd key=:"])
+}
+
+func TestIniDriver_GetName(t *testing.T) {
+	d, _ := New("config.ini")
+	assert.Equal(t, "ini", d.GetName())
+}
+
+func TestIniDriver_GetValue(t *testing.T) {
+	file := drivertest.WriteFile(t, "config.ini", `name = app
+[http]
+port = 8080
+timeout = 1m
+[http.auth]
+issuer = me
+`)
+	d, _ := New(file)
+	storage, _ := fmap.Get[Config]()
+	drivertest.GetValue(t, d, storage, []drivertest.Case{
+		{Path: "Name", Want: &tinyconf.Value{Value: "app"}},
+		{Path: "HTTP.Port", Want: &tinyconf.Value{Value: 8080}},
+		{Path: "HTTP.Auth.Issuer", Want: &tinyconf.Value{Value: "me"}},
+		{Path: "Timeout", Want: &tinyconf.Value{Value: time.Minute}},
+		{Path: "Token", WantErr: tinyconf.ErrValueNotFound},
+	})
+}
+
+func TestIniDriver_Errors(t *testing.T) {
+	type Untagged struct {
+		Name string
+		Port int `ini:"port"`
+	}
+	storage, _ := fmap.Get[Untagged]()
+	d, _ := New(filepath.Join(t.TempDir(), "missing.ini"))
+	_, err := d.GetValue(storage.MustFind("Name"))
+	assert.ErrorIs(t, err, tinyconf.ErrIncorrectTagSettings)
+	_, err = d.GetValue(storage.MustFind("Port"))
+	assert.ErrorIs(t, err, tinyconf.ErrValueNotFound)
+
+	d, _ = New(drivertest.WriteFile(t, "config.ini", "port = http"))
+	_, err = d.GetValue(storage.MustFind("Port"))
+	assert.ErrorContains(t, err, "failed to parse ini value from key port")
+
+	d, _ = New(drivertest.WriteFile(t, "config.ini", "[port"))
+	_, err = d.GetValue(storage.MustFind("Port"))
+	assert.ErrorContains(t, err, "failed to decode ini: ini: line 1")
+}
+
+func TestIniDriver_Parse(t *testing.T) {
+	file := drivertest.WriteFile(t, "config.properties", `servers.0.host = a.local
+servers.1.host = b.local
+servers.1.port = 81
+db.main.host = main.local
+db.main.port = 5432
+db.reports.host = reports.local
+`)
+	d, _ := New(file)
+	storage, _ := fmap.Get[Config]()
+	drivertest.GetKeys(t, d, storage.MustFind("Databases"), []string{"main", "reports"})
+
+	conf := &Config{}
+	drivertest.Parse(t, d, conf)
+	assert.Equal(t, []Server{{Host: "a.local"}, {Host: "b.local", Port: 81}}, conf.Servers)
+	assert.Equal(t, map[string]Server{"main": {Host: "main.local", Port: 5432}, "reports": {Host: "reports.local"}}, conf.Databases)
+}
+
+func TestIniDriver_ReadsFileOncePerParse(t *testing.T) {
+	file := drivertest.WriteFile(t, "config.ini", "name = app\n\n[http]\nport = 80\n")
+	d, _ := New(file)
+	reads := 0
+	cache := d.(*iniDriver).cache
+	cache.Decode = func(data []byte) (map[string]string, error) {
+		reads++
+		return decode(data)
+	}
+	conf := &Config{}
+	m := drivertest.Parse(t, d, conf)
+	assert.Equal(t, 1, reads)
+	assert.NoError(t, m.Parse(conf))
+	assert.Equal(t, 1, reads)
+
+	assert.NoError(t, os.WriteFile(file, []byte("name = changed\n"), 0o600))
+	assert.NoError(t, m.Parse(conf))
+	assert.Equal(t, 2, reads)
+	assert.Equal(t, "changed", conf.Name)
+}
+
+func TestIniDriver_GenDoc(t *testing.T) {
+	d, _ := New("config.ini")
+	storage, _ := fmap.Get[Config]()
+	conf := &Config{Name: "my app", Timeout: time.Minute, HTTP: HTTP{Port: 8080}}
+	assert.Equal(t, `; application name
+name = my app
+;token =
+
+; http server
+[http]
+timeout = 1m0s
+; listen port
+port = 8080
+
+[http.auth]
+issuer =
+
+[servers.0]
+; server host
+host =
+port = 0
+
+; databases by name
+[db.<name>]
+; server host
+host =
+port = 0
+`, d.GenDoc(&tinyconf.Registered{Storage: storage, Config: conf}))
+}
@@ -0,0 +1,168 @@
+package ini
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// parse decodes ini and java properties documents to the map of values by dotted keys, keys of [section] are
+// prefixed by the section name, i.e. port key of [http] section is http.port. Full line comments start with
+// '#', ';' or '!', the key is separated from the value by '=', ':' or whitespace, the line ending with
+// backslash is continued by the next line and the last value of the key wins.
+func parse(src string) (map[string]string, error) {
+	values := map[string]string{}
+	section := ""
+	lines := strings.Split(strings.ReplaceAll(strings.TrimPrefix(src, "\ufeff"), "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		lineNumber := i + 1
+		line := strings.TrimLeft(lines[i], " \t\f")
+		if line == "" || strings.ContainsAny(line[:1], "#;!") {
+			continue
+		}
+		for isContinued(line) && i+1 < len(lines) {
+			i++
+			line = line[:len(line)-1] + strings.TrimLeft(lines[i], " \t\f")
+		}
+		if line[0] == '[' {
+			end := strings.IndexByte(line, ']')
+			if end < 0 || strings.TrimSpace(line[end+1:]) != "" {
+				return nil, fmt.Errorf("ini: line %d: invalid section header %q", lineNumber, line)
+			}
+			section = strings.TrimSpace(line[1:end])
+			continue
+		}
+		key, value := splitKeyValue(line)
+		key, err := unescape(key)
+		if err != nil {
+			return nil, fmt.Errorf("ini: line %d: %w", lineNumber, err)
+		}
+		value = trimRight(value)
+		if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+			value = value[1 : len(value)-1]
+		}
+		if value, err = unescape(value); err != nil {
+			return nil, fmt.Errorf("ini: line %d: %w", lineNumber, err)
+		}
+		if section != "" {
+			key = section + "." + key
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+// trimRight trims trailing whitespace except the escaped one, i.e. `a\ ` value keeps the space.
+func trimRight(value string) string {
+	trimmed := strings.TrimRight(value, " \t\f")
+	if trimmed != value && isContinued(trimmed) {
+		return value[:len(trimmed)+1]
+	}
+	return trimmed
+}
+
+// isContinued reports whether the line ends with odd number of backslashes.
+func isContinued(line string) bool {
+	count := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		count++
+	}
+	return count%2 == 1
+}
+
+// splitKeyValue splits the line by the first unescaped '=', ':' or whitespace separator.
+func splitKeyValue(line string) (string, string) {
+	end := len(line)
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.IndexByte("=: \t\f", line[i]) >= 0 {
+			end = i
+			break
+		}
+	}
+	key := line[:end]
+	rest := strings.TrimLeft(line[end:], " \t\f")
+	if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+	return strings.TrimRight(key, " \t\f"), rest
+}
+
+func unescape(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if i+5 > len(s) {
+				return "", fmt.Errorf("invalid unicode escape \\%s", s[i:])
+			}
+			code, err := strconv.ParseUint(s[i+1:i+5], 16, 32)
+			if err != nil || !utf8.ValidRune(rune(code)) {
+				return "", fmt.Errorf("invalid unicode escape \\%s", s[i:i+5])
+			}
+			b.WriteRune(rune(code))
+			i += 4
+		default:
+			// other escaped characters, i.e. '\=', '\:' or '\\', are written as is
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), nil
+}
+
+// escape returns the value that is read back by parse as is.
+func escape(s string, isKey bool) string {
+	var b strings.Builder
+	for i, r := range s {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '=', ':':
+			if isKey {
+				b.WriteRune('\\')
+			}
+			b.WriteRune(r)
+		case ' ':
+			if isKey || i == 0 || i == len(s)-1 {
+				b.WriteRune('\\')
+			}
+			b.WriteRune(r)
+		case '#', '!', ';', '[', '"':
+			if i == 0 {
+				b.WriteRune('\\')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
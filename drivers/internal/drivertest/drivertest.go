@@ -0,0 +1,69 @@
+// Package drivertest is the conformance test helper of drivers, the same table of fields and expected values
+// is checked for every driver, so driver tests keep only format-specific behavior.
+package drivertest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/insei/fmap/v3"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/insei/tinyconf"
+)
+
+// Case is the expected result of GetValue for the field by the path.
+type Case struct {
+	Path string
+	// Want is the expected value, the driver name is expected as the source when Want.Source is empty.
+	Want    *tinyconf.Value
+	WantErr error
+}
+
+// GetValue checks GetValue of the driver for fields of the storage, every case is the subtest.
+func GetValue(t *testing.T, d tinyconf.Driver, storage fmap.Storage, cases []Case) {
+	t.Helper()
+	for _, tt := range cases {
+		t.Run(tt.Path, func(t *testing.T) {
+			val, err := d.GetValue(storage.MustFind(tt.Path))
+			if tt.WantErr != nil {
+				assert.ErrorIs(t, err, tt.WantErr)
+				return
+			}
+			want := *tt.Want
+			if want.Source == "" {
+				want.Source = d.GetName()
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, &want, val)
+		})
+	}
+}
+
+// GetKeys checks keys of the section field returned by the driver.
+func GetKeys(t *testing.T, d tinyconf.Driver, field fmap.Field, want []string) {
+	t.Helper()
+	keys, err := d.(tinyconf.KeysDriver).GetKeys(field)
+	assert.NoError(t, err)
+	assert.Equal(t, want, keys)
+}
+
+// Parse registers and parses the config by the manager with the only driver, the manager is returned
+// to parse the config again.
+func Parse(t *testing.T, d tinyconf.Driver, conf any) *tinyconf.Manager {
+	t.Helper()
+	m, err := tinyconf.New(tinyconf.WithDriver(d))
+	assert.NoError(t, err)
+	assert.NoError(t, m.Register(conf))
+	assert.NoError(t, m.Parse(conf))
+	return m
+}
+
+// WriteFile writes the file with the data to the temporary directory of the test and returns the file path.
+func WriteFile(t *testing.T, name, data string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(file, []byte(data), 0o600))
+	return file
+}
@@ -0,0 +1,172 @@
+package json
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/insei/fmap/v3"
+
+	"github.com/insei/tinyconf"
+	"github.com/insei/tinyconf/drivers/mapdriver"
+	"github.com/insei/tinyconf/slices118"
+)
+
+type jsonDriver struct {
+	mapdriver.Driver
+	cache *mapdriver.FileCache[map[string]any]
+}
+
+func decode(data []byte) (map[string]any, error) {
+	jsonMap := map[string]any{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// numbers are kept as written, i.e. large integers are not converted to float64
+	decoder.UseNumber()
+	if err := decoder.Decode(&jsonMap); err != nil {
+		return nil, fmt.Errorf("failed to decode json: %s", err)
+	}
+	return jsonMap, nil
+}
+
+// node is the object of the generated json document, value is the raw json of the value field.
+type node struct {
+	keys     []string
+	children map[string]*node
+	value    string
+	// list is the []T section, its child is the item template
+	list bool
+}
+
+func (n *node) child(key string) *node {
+	if n.children == nil {
+		n.children = map[string]*node{}
+	}
+	child, ok := n.children[key]
+	if !ok {
+		child = &node{}
+		n.children[key] = child
+		n.keys = append(n.keys, key)
+	}
+	return child
+}
+
+func (n *node) write(b *strings.Builder, depth int) {
+	if n.value != "" {
+		b.WriteString(n.value)
+		return
+	}
+	open, closing := "{", "}"
+	if n.list {
+		open, closing = "[", "]"
+	}
+	if len(n.keys) == 0 {
+		b.WriteString(open + closing)
+		return
+	}
+	indent := strings.Repeat("  ", depth+1)
+	b.WriteString(open + "\n")
+	for i, key := range n.keys {
+		b.WriteString(indent)
+		if !n.list {
+			b.WriteString(marshal(key))
+			b.WriteString(": ")
+		}
+		n.children[key].write(b, depth+1)
+		if i < len(n.keys)-1 {
+			b.WriteRune(',')
+		}
+		b.WriteRune('\n')
+	}
+	b.WriteString(strings.Repeat("  ", depth) + closing)
+}
+
+// formatValue returns the raw json of the field value, numbers and booleans are written as is,
+// values that are rendered in readable form (durations, byte sizes) are written as strings.
+func formatValue(field fmap.Field, val any) string {
+	valOf := reflect.ValueOf(val)
+	for valOf.Kind() == reflect.Ptr {
+		if valOf.IsNil() {
+			return "null"
+		}
+		valOf = valOf.Elem()
+	}
+	if !valOf.IsValid() {
+		return "null"
+	}
+	formatted := tinyconf.FormatFieldValue(field, val)
+	_, hasEncoding := field.GetTag().Lookup("encoding")
+	switch valOf.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		if json.Valid([]byte(formatted)) {
+			return formatted
+		}
+	case reflect.Slice, reflect.Map, reflect.Struct:
+		if field.GetTag().Get("encoding") == "json" && json.Valid([]byte(formatted)) {
+			return formatted
+		}
+		if !hasEncoding && valOf.Kind() != reflect.Struct {
+			if data := marshal(valOf.Interface()); data != "" {
+				return data
+			}
+		}
+	}
+	return marshal(formatted)
+}
+
+// marshal returns json of the value without html escaping, so placeholders like <name> stay readable,
+// it returns empty string if the value can't be marshaled.
+func marshal(val any) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(val); err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// GenDoc returns the json document skeleton of registered configs with current (default) values,
+// maps entries are documented by the placeholder key and slices by the single item.
+func (d *jsonDriver) GenDoc(registers ...*tinyconf.Registered) string {
+	root := &node{}
+	for _, register := range registers {
+		for _, configField := range register.GetFields() {
+			fld := configField.Field
+			path := fld.GetTagPath(d.Name, false)
+			if path == "" || slices118.Contains(strings.Split(path, "."), "-") {
+				continue
+			}
+			keys := strings.Split(path, ".")
+			parent := root
+			for _, key := range keys[:len(keys)-1] {
+				parent = parent.child(key)
+			}
+			if _, ok := parent.children[keys[len(keys)-1]]; ok {
+				continue
+			}
+			if tinyconf.IsSliceEntry(fld) {
+				parent.list = true
+			}
+			member := parent.child(keys[len(keys)-1])
+			if !tinyconf.IsSection(fld) {
+				member.value = formatValue(fld, configField.Value)
+			}
+		}
+	}
+	var b strings.Builder
+	root.write(&b, 0)
+	b.WriteRune('\n')
+	return b.String()
+}
+
+// New returns the driver that reads values from the json file by `json` tag paths, i.e. `json:"port"` field
+// of `json:"http"` section is read from {"http": {"port": 8080}}. The file is read again when it is changed,
+// see mapdriver.FileCache.
+func New(file string) (tinyconf.Driver, error) {
+	d := &jsonDriver{cache: &mapdriver.FileCache[map[string]any]{Path: file, Decode: decode}}
+	d.Driver = mapdriver.Driver{Name: "json", Load: d.cache.Load}
+	return d, nil
+}
@@ -0,0 +1,145 @@
+package json
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/insei/fmap/v3"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/insei/tinyconf"
+	"github.com/insei/tinyconf/drivers/internal/drivertest"
+)
+
+type Server struct {
+	Host string `json:"host"`
+	Port int    `json:"port"`
+}
+
+type Config struct {
+	Name    string             `json:"name"`
+	Size    int64              `json:"size"`
+	Enabled bool               `json:"enabled"`
+	Timeout time.Duration      `json:"timeout"`
+	Tags    []string           `json:"tags"`
+	Token   *string            `json:"token"`
+	Skipped string             `json:"-"`
+	HTTP    struct{ Port int } `json:"http"`
+	Servers []Server           `json:"servers"`
+	DB      map[string]Server  `json:"db" key:"name"`
+}
+
+func TestJsonDriver_GetName(t *testing.T) {
+	d, _ := New("config.json")
+	assert.Equal(t, "json", d.GetName())
+}
+
+func TestJsonDriver_GetValue(t *testing.T) {
+	file := drivertest.WriteFile(t, "config.json", `{
+  "name": "app",
+  "size": 9007199254740993,
+  "enabled": true,
+  "timeout": "1m30s",
+  "token": null,
+  "servers": [{"host": "a.local"}, {"host": "b.local", "port": 81}]
+}`)
+	d, _ := New(file)
+	storage, _ := fmap.Get[Config]()
+	drivertest.GetValue(t, d, storage, []drivertest.Case{
+		{Path: "Name", Want: &tinyconf.Value{Value: "app"}},
+		{Path: "Size", Want: &tinyconf.Value{Value: int64(9007199254740993)}},
+		{Path: "Enabled", Want: &tinyconf.Value{Value: true}},
+		{Path: "Timeout", Want: &tinyconf.Value{Value: 90 * time.Second}},
+		{Path: "Token", WantErr: tinyconf.ErrValueUnset},
+		{Path: "HTTP", WantErr: tinyconf.ErrValueNotFound},
+	})
+
+	drivertest.GetKeys(t, d, storage.MustFind("Servers"), []string{"0", "1"})
+}
+
+func TestJsonDriver_ParseScalarSequences(t *testing.T) {
+	type Config struct {
+		Tags   []string          `json:"tags"`
+		Ports  []int             `json:"ports"`
+		Labels map[string]string `json:"labels"`
+	}
+	d, _ := New(drivertest.WriteFile(t, "config.json", `{"tags": ["a", "b"], "ports": [80, 443], "labels": {"team": "core"}}`))
+	conf := &Config{}
+	drivertest.Parse(t, d, conf)
+	assert.Equal(t, &Config{Tags: []string{"a", "b"}, Ports: []int{80, 443}, Labels: map[string]string{"team": "core"}}, conf)
+}
+
+func TestJsonDriver_Errors(t *testing.T) {
+	storage, _ := fmap.Get[Config]()
+	d, _ := New(drivertest.WriteFile(t, "config.json", `{"name": `))
+	_, err := d.GetValue(storage.MustFind("Name"))
+	assert.ErrorContains(t, err, "failed to decode json")
+
+	d, _ = New(drivertest.WriteFile(t, "config.json", `{"size": "big"}`))
+	_, err = d.GetValue(storage.MustFind("Size"))
+	assert.Error(t, err)
+}
+
+func TestJsonDriver_Parse(t *testing.T) {
+	file := drivertest.WriteFile(t, "config.json", `{
+  "name": "app",
+  "servers": [{"host": "a.local"}, {"host": "b.local", "port": 81}],
+  "db": {"main": {"host": "main.local", "port": 5432}}
+}`)
+	d, _ := New(file)
+	conf := &Config{}
+	drivertest.Parse(t, d, conf)
+	assert.Equal(t, "app", conf.Name)
+	assert.Equal(t, []Server{{Host: "a.local"}, {Host: "b.local", Port: 81}}, conf.Servers)
+	assert.Equal(t, map[string]Server{"main": {Host: "main.local", Port: 5432}}, conf.DB)
+}
+
+func TestJsonDriver_ReadsFileOncePerParse(t *testing.T) {
+	file := drivertest.WriteFile(t, "config.json", `{"name": "app", "size": 1, "tags": ["a"]}`)
+	d, _ := New(file)
+	reads := 0
+	cache := d.(*jsonDriver).cache
+	cache.Decode = func(data []byte) (map[string]any, error) {
+		reads++
+		return decode(data)
+	}
+	conf := &Config{}
+	m := drivertest.Parse(t, d, conf)
+	assert.Equal(t, 1, reads)
+	assert.NoError(t, m.Parse(conf))
+	assert.Equal(t, 1, reads)
+
+	assert.NoError(t, os.WriteFile(file, []byte(`{"name": "changed"}`), 0o600))
+	assert.NoError(t, m.Parse(conf))
+	assert.Equal(t, 2, reads)
+	assert.Equal(t, "changed", conf.Name)
+}
+
+func TestJsonDriver_GenDoc(t *testing.T) {
+	d, _ := New("config.json")
+	storage, _ := fmap.Get[Config]()
+	conf := &Config{Name: "app", Size: 10, Timeout: time.Minute, Tags: []string{"a"}}
+	assert.Equal(t, `{
+  "name": "app",
+  "size": 10,
+  "enabled": false,
+  "timeout": "1m0s",
+  "tags": ["a"],
+  "token": null,
+  "http": {},
+  "servers": [
+    {
+      "host": "",
+      "port": 0
+    }
+  ],
+  "db": {
+    "<name>": {
+      "host": "",
+      "port": 0
+    }
+  }
+}
+`, d.GenDoc(&tinyconf.Registered{Storage: storage, Config: conf}))
+}
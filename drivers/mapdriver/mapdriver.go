@@ -0,0 +1,190 @@
+// Package mapdriver is the format-agnostic core of drivers that read values from documents decoded to nested
+// maps and sequences (yaml, json), values are addressed by the dot separated path of the format tag.
+package mapdriver
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/insei/fmap/v3"
+
+	"github.com/insei/tinyconf"
+)
+
+// getChildNode returns the child of the mapping by the key or the item of the sequence by the index key.
+func getChildNode(node any, key string) (any, bool) {
+	switch casted := node.(type) {
+	case map[string]interface{}:
+		val, ok := casted[key]
+		return val, ok
+	case []interface{}:
+		index, err := strconv.Atoi(key)
+		if err != nil || index < 0 || index >= len(casted) {
+			return nil, false
+		}
+		return casted[index], true
+	}
+	return nil, false
+}
+
+// GetNode returns the node of the document by the dot separated path, sequences items are addressed by indexes.
+// The format is the name of the document format used in errors.
+func GetNode(format string, doc any, path string) (any, error) {
+	node := doc
+	for _, key := range strings.Split(path, ".") {
+		child, ok := getChildNode(node, key)
+		if !ok {
+			// missing or null parent node means that the value is not configured at all
+			return nil, fmt.Errorf("%w: value not found in %s config", tinyconf.ErrValueNotFound, format)
+		}
+		node = child
+	}
+	return node, nil
+}
+
+func getFieldNode(format string, field fmap.Field, doc any) (any, error) {
+	path := field.GetTagPath(format, true)
+	if path == "" {
+		return nil, fmt.Errorf("%w: '%s' tag is not set", tinyconf.ErrIncorrectTagSettings, format)
+	}
+	return GetNode(format, doc, path)
+}
+
+// GetValue returns the raw value of the field by the path of the format tag, null value is reported with
+// tinyconf.ErrValueUnset for nullable fields (see tinyconf.IsNullableField) and with tinyconf.ErrValueNotFound
+// for others.
+func GetValue(format string, field fmap.Field, doc any) (any, error) {
+	val, err := getFieldNode(format, field, doc)
+	if err != nil {
+		return nil, err
+	}
+	if val == nil && !tinyconf.IsNullableField(field) {
+		return nil, fmt.Errorf("%w: value is null in %s config", tinyconf.ErrValueNotFound, format)
+	}
+	if val == nil {
+		return nil, fmt.Errorf("%w: value is null in %s config", tinyconf.ErrValueUnset, format)
+	}
+	return val, nil
+}
+
+// GetKeys returns keys of the mapping of map[string]T section field or indexes of the sequence of []T section field.
+func GetKeys(format string, field fmap.Field, doc any) ([]string, error) {
+	val, err := getFieldNode(format, field, doc)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	switch casted := val.(type) {
+	case map[string]interface{}:
+		for key := range casted {
+			keys = append(keys, key)
+		}
+	case []interface{}:
+		for i := range casted {
+			keys = append(keys, strconv.Itoa(i))
+		}
+	default:
+		return nil, fmt.Errorf("%w: value is not a mapping or a sequence in %s config", tinyconf.ErrValueNotFound, format)
+	}
+	return keys, nil
+}
+
+// ReadFile reads the document file, missing file is reported with tinyconf.ErrValueNotFound.
+func ReadFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: error while open file: %s", tinyconf.ErrValueNotFound, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error while open file: %s", err)
+	}
+	return data, nil
+}
+
+// FileCache keeps the document decoded from the file by Decode. The file is read again only when its modification
+// time or size is changed, so it is read once per Parse instead of once per field and changes are seen by the next Parse.
+type FileCache[T any] struct {
+	Path   string
+	Decode func(data []byte) (T, error)
+
+	mu      sync.Mutex
+	doc     T
+	loaded  bool
+	modTime time.Time
+	size    int64
+}
+
+// Load returns the cached document or reads and decodes the changed file, missing file is reported
+// with tinyconf.ErrValueNotFound like ReadFile does.
+func (c *FileCache[T]) Load() (T, error) {
+	var doc T
+	info, err := os.Stat(c.Path)
+	if os.IsNotExist(err) {
+		return doc, fmt.Errorf("%w: error while open file: %s", tinyconf.ErrValueNotFound, err)
+	}
+	if err != nil {
+		return doc, fmt.Errorf("error while open file: %s", err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.loaded && c.modTime.Equal(info.ModTime()) && c.size == info.Size() {
+		return c.doc, nil
+	}
+	data, err := ReadFile(c.Path)
+	if err != nil {
+		return doc, err
+	}
+	if doc, err = c.Decode(data); err != nil {
+		return doc, err
+	}
+	c.doc, c.loaded, c.modTime, c.size = doc, true, info.ModTime(), info.Size()
+	return doc, nil
+}
+
+// ConvertValue converts the raw document value to the field type, structured values are converted
+// with json.Unmarshaler implementation.
+func ConvertValue(field fmap.Field, val any) (any, error) {
+	return tinyconf.DecodeAnyField(field, val)
+}
+
+// Driver implements tinyconf.Driver value lookups over the document returned by Load, the driver name is
+// the format tag name. Format drivers embed it and implement GenDoc.
+type Driver struct {
+	Name string
+	Load func() (map[string]any, error)
+}
+
+func (d *Driver) GetValue(field fmap.Field) (*tinyconf.Value, error) {
+	doc, err := d.Load()
+	if err != nil {
+		return nil, err
+	}
+	val, err := GetValue(d.Name, field, doc)
+	if err != nil {
+		return nil, err
+	}
+	val, err = ConvertValue(field, val)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert %s map value to field type value: %w", d.Name, err)
+	}
+	return &tinyconf.Value{
+		Source: d.Name,
+		Value:  val,
+	}, nil
+}
+
+func (d *Driver) GetKeys(field fmap.Field) ([]string, error) {
+	doc, err := d.Load()
+	if err != nil {
+		return nil, err
+	}
+	return GetKeys(d.Name, field, doc)
+}
+
+func (d *Driver) GetName() string {
+	return d.Name
+}
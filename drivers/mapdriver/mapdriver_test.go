@@ -0,0 +1,404 @@
+package mapdriver
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/insei/fmap/v3"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/insei/tinyconf"
+)
+
+type Config struct {
+	Name    string `doc:"no format tag"`
+	Port    int    `conf:"port"`
+	Servers []struct {
+		Host string `conf:"host"`
+	} `conf:"servers"`
+}
+
+func TestGetNode(t *testing.T) {
+	doc := map[string]any{"list": []any{map[string]any{"key": "value"}}}
+	val, err := GetNode("conf", doc, "list.0.key")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", val)
+	for _, path := range []string{"list.1.key", "list.a.key", "list.-1.key", "list.0.key.more", "missing"} {
+		_, err = GetNode("conf", doc, path)
+		assert.ErrorIs(t, err, tinyconf.ErrValueNotFound, path)
+		assert.ErrorContains(t, err, "conf config", path)
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config")
+	_, err := ReadFile(file)
+	assert.ErrorIs(t, err, tinyconf.ErrValueNotFound)
+
+	assert.NoError(t, os.WriteFile(file, []byte("data"), 0o600))
+	data, err := ReadFile(file)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("data"), data)
+
+	// values are not found in the directory, but the error is not hidden
+	_, err = ReadFile(filepath.Dir(file))
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, tinyconf.ErrValueNotFound)
+}
+
+func TestFileCache(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config")
+	reads := 0
+	cache := &FileCache[string]{Path: file, Decode: func(data []byte) (string, error) {
+		reads++
+		if string(data) == "invalid" {
+			return "", errors.New("invalid document")
+		}
+		return string(data), nil
+	}}
+	_, err := cache.Load()
+	assert.ErrorIs(t, err, tinyconf.ErrValueNotFound)
+
+	assert.NoError(t, os.WriteFile(file, []byte("data"), 0o600))
+	for i := 0; i < 3; i++ {
+		doc, err := cache.Load()
+		assert.NoError(t, err)
+		assert.Equal(t, "data", doc)
+	}
+	assert.Equal(t, 1, reads)
+
+	// changed files are read again, invalid documents are not cached
+	assert.NoError(t, os.WriteFile(file, []byte("invalid"), 0o600))
+	_, err = cache.Load()
+	assert.ErrorContains(t, err, "invalid document")
+	_, err = cache.Load()
+	assert.Error(t, err)
+	assert.Equal(t, 3, reads)
+
+	assert.NoError(t, os.WriteFile(file, []byte("new data"), 0o600))
+	doc, err := cache.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "new data", doc)
+	assert.Equal(t, 4, reads)
+
+	assert.NoError(t, os.Remove(file))
+	_, err = cache.Load()
+	assert.ErrorIs(t, err, tinyconf.ErrValueNotFound)
+}
+
+func TestConvertValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		getField func() fmap.Field
+		val      any
+		want     any
+		wantErr  bool
+	}{
+		{
+			name: "Convert string to int",
+			getField: func() fmap.Field {
+				storage, _ := fmap.Get[struct {
+					Test int
+				}]()
+				return storage.MustFind("Test")
+			},
+			val:     "123",
+			want:    123,
+			wantErr: false,
+		},
+		{
+			name: "Incompatible types",
+			getField: func() fmap.Field {
+				storage, _ := fmap.Get[struct {
+					Test int
+				}]()
+				return storage.MustFind("Test")
+			},
+			val:     "test",
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "Already same types",
+			getField: func() fmap.Field {
+				storage, _ := fmap.Get[struct {
+					Test string
+				}]()
+				return storage.MustFind("Test")
+			},
+			val:     "test",
+			want:    "test",
+			wantErr: false,
+		},
+		{
+			name: "convertible type",
+			getField: func() fmap.Field {
+				storage, _ := fmap.Get[struct {
+					Test int
+				}]()
+				return storage.MustFind("Test")
+			},
+			val:     float64(123),
+			want:    123,
+			wantErr: false,
+		},
+		{
+			name: "non convertible type",
+			getField: func() fmap.Field {
+				storage, _ := fmap.Get[struct {
+					Test int32
+				}]()
+				return storage.MustFind("Test")
+			},
+			val:     int64(math.MaxInt64),
+			wantErr: true,
+		},
+		{
+			name: "byte size unit",
+			getField: func() fmap.Field {
+				storage, _ := fmap.Get[struct {
+					Test tinyconf.ByteSize
+				}]()
+				return storage.MustFind("Test")
+			},
+			val:  "10MiB",
+			want: 10 * tinyconf.MiB,
+		},
+		{
+			name: "percent unit",
+			getField: func() fmap.Field {
+				storage, _ := fmap.Get[struct {
+					Test tinyconf.Percent
+				}]()
+				return storage.MustFind("Test")
+			},
+			val:  "75%",
+			want: tinyconf.Percent(0.75),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ConvertValue(tt.getField(), tt.val)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ConvertValue() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ConvertValue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetValue(t *testing.T) {
+	tests := []struct {
+		name          string
+		doc           map[string]any
+		getField      func() fmap.Field
+		expectedValue any
+		wantErr       bool
+		expectedErr   error
+	}{
+		{
+			name: "NonExistingConfTag",
+			doc:  nil,
+			getField: func() fmap.Field {
+				storage, _ := fmap.Get[struct {
+					Test int
+				}]()
+				return storage.MustFind("Test")
+			},
+			expectedValue: nil,
+			wantErr:       true,
+		},
+		{
+			name: "NonExistingValueInNonExistingDoc",
+			doc:  nil,
+			getField: func() fmap.Field {
+				storage, _ := fmap.Get[struct {
+					Test int `conf:"nonexisting"`
+				}]()
+				return storage.MustFind("Test")
+			},
+			expectedValue: nil,
+			wantErr:       true,
+		},
+		{
+			name: "NonExistingFieldInExistingDoc",
+			doc:  map[string]any{"existent": "value"},
+			getField: func() fmap.Field {
+				storage, _ := fmap.Get[struct {
+					Test int `conf:"nonexistent"`
+				}]()
+				return storage.MustFind("Test")
+			},
+			expectedValue: nil,
+			wantErr:       true,
+		},
+		{
+			name: "ExistingNonEmptyField",
+			doc:  map[string]any{"existent": "value"},
+			getField: func() fmap.Field {
+				storage, _ := fmap.Get[struct {
+					Test int `conf:"existent"`
+				}]()
+				return storage.MustFind("Test")
+			},
+			expectedValue: "value",
+			wantErr:       false,
+		},
+		{
+			name: "NestedExistingNonEmptyField",
+			doc:  map[string]any{"existent": map[string]any{"nested": "value"}},
+			getField: func() fmap.Field {
+				storage, _ := fmap.Get[struct {
+					Test struct {
+						Nested string `conf:"nested"`
+					} `conf:"existent"`
+				}]()
+				return storage.MustFind("Test.Nested")
+			},
+			expectedValue: "value",
+			wantErr:       false,
+		},
+		{
+			name: "NestedNonExistingField",
+			doc:  map[string]any{"existent": map[string]any{"nested": "value"}},
+			getField: func() fmap.Field {
+				storage, _ := fmap.Get[struct {
+					Test struct {
+						Nonested string `conf:"nonested"`
+					} `conf:"existent"`
+				}]()
+				return storage.MustFind("Test.Nonested")
+			},
+			expectedValue: nil,
+			wantErr:       true,
+		},
+		{
+			name: "NullField",
+			doc:  map[string]any{"existent": nil},
+			getField: func() fmap.Field {
+				storage, _ := fmap.Get[struct {
+					Test *int `conf:"existent"`
+				}]()
+				return storage.MustFind("Test")
+			},
+			wantErr:     true,
+			expectedErr: tinyconf.ErrValueUnset,
+		},
+		{
+			name: "NullNotNullableField",
+			doc:  map[string]any{"existent": nil},
+			getField: func() fmap.Field {
+				storage, _ := fmap.Get[struct {
+					Test int `conf:"existent"`
+				}]()
+				return storage.MustFind("Test")
+			},
+			wantErr:     true,
+			expectedErr: tinyconf.ErrValueNotFound,
+		},
+		{
+			name: "NestedFieldOfMissingParent",
+			doc:  map[string]any{"nested": "value"},
+			getField: func() fmap.Field {
+				storage, _ := fmap.Get[struct {
+					Test struct {
+						Nested string `conf:"nested"`
+					} `conf:"existent"`
+				}]()
+				return storage.MustFind("Test.Nested")
+			},
+			wantErr:     true,
+			expectedErr: tinyconf.ErrValueNotFound,
+		},
+		{
+			name: "NestedFieldOfNullParent",
+			doc:  map[string]any{"existent": nil},
+			getField: func() fmap.Field {
+				storage, _ := fmap.Get[struct {
+					Test struct {
+						Nested string `conf:"nested"`
+					} `conf:"existent"`
+				}]()
+				return storage.MustFind("Test.Nested")
+			},
+			wantErr:     true,
+			expectedErr: tinyconf.ErrValueNotFound,
+		},
+		{
+			name: "NestedNonExistingFieldWithEmptyMap",
+			doc:  map[string]any{"existent": "value"},
+			getField: func() fmap.Field {
+				storage, _ := fmap.Get[struct {
+					Test struct {
+						Nonested string `conf:"nonesting"`
+					} `conf:"existent"`
+				}]()
+				return storage.MustFind("Test.Nonested")
+			},
+			expectedValue: nil,
+			wantErr:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetValue("conf", tt.getField(), tt.doc)
+			if tt.wantErr {
+				assert.Error(t, err)
+				if tt.expectedErr != nil {
+					assert.ErrorIs(t, err, tt.expectedErr)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedValue, got)
+		})
+	}
+}
+
+func TestGetKeys(t *testing.T) {
+	type Config struct {
+		DB map[string]struct {
+			Host string `conf:"host"`
+		} `conf:"db"`
+	}
+	storage, _ := fmap.Get[Config]()
+	_, err := GetKeys("conf", storage.MustFind("DB"), map[string]any{})
+	assert.ErrorIs(t, err, tinyconf.ErrValueNotFound)
+	_, err = GetKeys("conf", storage.MustFind("DB"), map[string]any{"db": "scalar"})
+	assert.ErrorIs(t, err, tinyconf.ErrValueNotFound)
+	keys, err := GetKeys("conf", storage.MustFind("DB"), map[string]any{"db": map[string]any{"a": nil}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a"}, keys)
+}
+
+func TestDriver(t *testing.T) {
+	storage, _ := fmap.Get[Config]()
+	doc := map[string]any{"port": "8080", "servers": []any{map[string]any{"host": nil}}}
+	d := &Driver{Name: "conf", Load: func() (map[string]any, error) { return doc, nil }}
+	assert.Equal(t, "conf", d.GetName())
+
+	val, err := d.GetValue(storage.MustFind("Port"))
+	assert.NoError(t, err)
+	assert.Equal(t, &tinyconf.Value{Source: "conf", Value: 8080}, val)
+
+	_, err = d.GetValue(storage.MustFind("Name"))
+	assert.ErrorIs(t, err, tinyconf.ErrIncorrectTagSettings)
+
+	keys, err := d.GetKeys(storage.MustFind("Servers"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0"}, keys)
+
+	_, err = d.GetKeys(storage.MustFind("Port"))
+	assert.ErrorIs(t, err, tinyconf.ErrValueNotFound)
+
+	doc["port"] = "http"
+	_, err = d.GetValue(storage.MustFind("Port"))
+	assert.ErrorContains(t, err, "failed to convert conf map value")
+}
@@ -0,0 +1,102 @@
+package remote
+
+import (
+	"encoding/base64"
+	"net/http"
+	"time"
+)
+
+type Option interface {
+	apply(*remoteDriver)
+}
+
+type headerOption struct {
+	key   string
+	value string
+}
+
+func (o headerOption) apply(d *remoteDriver) {
+	d.header.Set(o.key, o.value)
+}
+
+// WithHeader sets the header of requests, i.e. WithHeader("X-Api-Key", key).
+func WithHeader(key, value string) Option {
+	return headerOption{key: key, value: value}
+}
+
+// WithBearerToken sets Authorization: Bearer <token> header of requests.
+func WithBearerToken(token string) Option {
+	return headerOption{key: "Authorization", value: "Bearer " + token}
+}
+
+// WithBasicAuth sets Authorization: Basic header of requests.
+func WithBasicAuth(username, password string) Option {
+	credentials := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+	return headerOption{key: "Authorization", value: "Basic " + credentials}
+}
+
+type clientOption struct {
+	client *http.Client
+}
+
+func (o clientOption) apply(d *remoteDriver) {
+	d.client = o.client
+}
+
+// WithClient sets the http client of requests, i.e. with custom TLS settings, the default client has 10s timeout.
+func WithClient(client *http.Client) Option {
+	return clientOption{client: client}
+}
+
+type retryOption struct {
+	attempts int
+	backoff  time.Duration
+}
+
+func (o retryOption) apply(d *remoteDriver) {
+	d.attempts, d.backoff = o.attempts, o.backoff
+}
+
+// WithRetry sets the number of attempts of the request and the delay before the second attempt, the delay is doubled
+// for every next attempt. Requests are retried on network errors, 429 and 5xx responses, the default is 3 attempts
+// with 1s backoff.
+func WithRetry(attempts int, backoff time.Duration) Option {
+	return retryOption{attempts: attempts, backoff: backoff}
+}
+
+type cacheOption struct {
+	path string
+}
+
+func (o cacheOption) apply(d *remoteDriver) {
+	d.cacheFile = o.path
+}
+
+// WithCache sets the file of the last successfully fetched document, it is used by New when the endpoint is unreachable.
+func WithCache(path string) Option {
+	return cacheOption{path: path}
+}
+
+type jsonTagsOption struct{}
+
+func (o jsonTagsOption) apply(d *remoteDriver) {
+	d.tag = "json"
+}
+
+// JSONTags enables lookup of values by `json` tag paths instead of `yaml` tag paths.
+func JSONTags() Option {
+	return jsonTagsOption{}
+}
+
+type intervalOption struct {
+	interval time.Duration
+}
+
+func (o intervalOption) apply(d *remoteDriver) {
+	d.interval = o.interval
+}
+
+// WithInterval sets the interval of document polls by Watch, the default is 1m.
+func WithInterval(interval time.Duration) Option {
+	return intervalOption{interval: interval}
+}
@@ -0,0 +1,263 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/insei/fmap/v3"
+	"gopkg.in/yaml.v3"
+
+	"github.com/insei/tinyconf"
+	jsondriver "github.com/insei/tinyconf/drivers/json"
+	"github.com/insei/tinyconf/drivers/mapdriver"
+	yamldriver "github.com/insei/tinyconf/drivers/yaml"
+)
+
+// errUnavailable is returned when the endpoint is unreachable: network errors, 429 and 5xx responses.
+var errUnavailable = errors.New("endpoint is unavailable")
+
+type remoteDriver struct {
+	name      string
+	url       string
+	tag       string
+	header    http.Header
+	client    *http.Client
+	attempts  int
+	backoff   time.Duration
+	cacheFile string
+	interval  time.Duration
+
+	mu     sync.RWMutex
+	doc    map[string]any
+	etag   string
+	source string
+}
+
+type response struct {
+	body        []byte
+	etag        string
+	notModified bool
+}
+
+// request requests the document once, the ETag of the current document is sent in If-None-Match header.
+func (d *remoteDriver) request(ctx context.Context, etag string) (*response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range d.header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Accept", "application/yaml, application/json;q=0.9, */*;q=0.8")
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errUnavailable, err)
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotModified:
+		return &response{etag: etag, notModified: true}, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError:
+		return nil, fmt.Errorf("%w: %s", errUnavailable, resp.Status)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("unexpected response status: %s", resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: error while read response: %s", errUnavailable, err)
+	}
+	return &response{body: body, etag: resp.Header.Get("ETag")}, nil
+}
+
+// fetch requests the document, the request is retried with exponential backoff while the endpoint is unavailable.
+func (d *remoteDriver) fetch(ctx context.Context, etag string) (*response, error) {
+	backoff := d.backoff
+	for attempt := 1; ; attempt++ {
+		resp, err := d.request(ctx, etag)
+		if err == nil || !errors.Is(err, errUnavailable) || attempt >= d.attempts {
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch %s: %w", redact(d.url), err)
+			}
+			return resp, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("failed to fetch %s: %w", redact(d.url), err)
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func decode(data []byte) (map[string]any, error) {
+	doc := map[string]any{}
+	// json documents are decoded by yaml decoder too, json is the subset of yaml
+	if err := yaml.NewDecoder(bytes.NewReader(data)).Decode(&doc); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to decode document: %s", err)
+	}
+	return doc, nil
+}
+
+// writeCache replaces the cache file by the document atomically, so the cache is never partially written.
+func (d *remoteDriver) writeCache(body []byte) error {
+	if d.cacheFile == "" {
+		return nil
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(d.cacheFile), filepath.Base(d.cacheFile)+".*")
+	if err != nil {
+		return fmt.Errorf("error while write cache: %s", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(body); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error while write cache: %s", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("error while write cache: %s", err)
+	}
+	if err = os.Rename(tmp.Name(), d.cacheFile); err != nil {
+		return fmt.Errorf("error while write cache: %s", err)
+	}
+	return nil
+}
+
+// init fetches the document, the cached document is used if the endpoint is unavailable.
+func (d *remoteDriver) init(ctx context.Context) error {
+	resp, err := d.fetch(ctx, "")
+	if err != nil {
+		if d.cacheFile == "" || !errors.Is(err, errUnavailable) {
+			return err
+		}
+		body, cacheErr := os.ReadFile(d.cacheFile)
+		if cacheErr != nil {
+			return fmt.Errorf("%s, error while read cache: %s", err, cacheErr)
+		}
+		doc, decodeErr := decode(body)
+		if decodeErr != nil {
+			return fmt.Errorf("%s, error while read cache: %s", err, decodeErr)
+		}
+		d.doc, d.source = doc, d.cacheFile
+		return nil
+	}
+	doc, err := decode(resp.body)
+	if err != nil {
+		return err
+	}
+	d.doc, d.etag = doc, resp.etag
+	return d.writeCache(resp.body)
+}
+
+// Poll requests the document with If-None-Match header of the current document ETag and reports whether
+// the document is changed, see tinyconf.Watcher.
+func (d *remoteDriver) Poll(ctx context.Context) (bool, error) {
+	d.mu.RLock()
+	etag := d.etag
+	d.mu.RUnlock()
+	resp, err := d.fetch(ctx, etag)
+	if err != nil {
+		return false, err
+	}
+	if resp.notModified {
+		return false, nil
+	}
+	doc, err := decode(resp.body)
+	if err != nil {
+		return false, err
+	}
+	d.mu.Lock()
+	d.doc, d.etag, d.source = doc, resp.etag, redact(d.url)
+	d.mu.Unlock()
+	return true, d.writeCache(resp.body)
+}
+
+// Watch polls the document every interval (see WithInterval) until ctx is done, see tinyconf.Watcher.
+func (d *remoteDriver) Watch(ctx context.Context, fn func(error)) {
+	tinyconf.WatchPolls(ctx, func() time.Duration { return d.interval }, d.Poll, fn)
+}
+
+func (d *remoteDriver) load() (map[string]any, string) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.doc, d.source
+}
+
+func (d *remoteDriver) GetValue(field fmap.Field) (*tinyconf.Value, error) {
+	doc, source := d.load()
+	val, err := mapdriver.GetValue(d.tag, field, doc)
+	if err != nil {
+		return nil, err
+	}
+	val, err = mapdriver.ConvertValue(field, val)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert %s value to field type value: %w", source, err)
+	}
+	return &tinyconf.Value{Source: source, Value: val}, nil
+}
+
+func (d *remoteDriver) GetKeys(field fmap.Field) ([]string, error) {
+	doc, _ := d.load()
+	return mapdriver.GetKeys(d.tag, field, doc)
+}
+
+func (d *remoteDriver) GetName() string {
+	return d.name
+}
+
+// GenDoc returns the document served by the endpoint, it is generated by the yaml driver or by the json driver
+// with JSONTags option.
+func (d *remoteDriver) GenDoc(registers ...*tinyconf.Registered) string {
+	newDriver := yamldriver.New
+	if d.tag == "json" {
+		newDriver = jsondriver.New
+	}
+	driver, err := newDriver("")
+	if err != nil {
+		return ""
+	}
+	return driver.GenDoc(registers...)
+}
+
+// redact hides the password of the url userinfo, the url is the source of values.
+func redact(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return u.Redacted()
+}
+
+// New returns the driver that reads values by `yaml` tag paths from the yaml or json document served by the url.
+// The document is fetched by New, the cached document (see WithCache) is used when the endpoint is unreachable,
+// the driver implements tinyconf.Watcher to reload the document.
+func New(rawURL string, opts ...Option) (tinyconf.Driver, error) {
+	d := &remoteDriver{
+		name:     "remote",
+		url:      rawURL,
+		tag:      "yaml",
+		header:   http.Header{},
+		client:   &http.Client{Timeout: 10 * time.Second},
+		attempts: 3,
+		backoff:  time.Second,
+		interval: time.Minute,
+		source:   redact(rawURL),
+	}
+	for _, opt := range opts {
+		opt.apply(d)
+	}
+	if err := d.init(context.Background()); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
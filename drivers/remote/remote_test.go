@@ -0,0 +1,277 @@
+package remote
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/insei/fmap/v3"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/insei/tinyconf"
+	"github.com/insei/tinyconf/drivers/internal/drivertest"
+)
+
+type Server struct {
+	Host string `yaml:"host" json:"host"`
+}
+
+type Config struct {
+	Name string `yaml:"name" json:"name" doc:"application name"`
+	HTTP struct {
+		Port    int           `yaml:"port" json:"port"`
+		Timeout time.Duration `yaml:"timeout" json:"timeout"`
+	} `yaml:"http" json:"http"`
+	DB map[string]Server `yaml:"db" json:"db"`
+}
+
+// configServer serves the document with the ETag of its version, the document is changed by set.
+type configServer struct {
+	mu       sync.Mutex
+	doc      string
+	version  int
+	requests int32
+	// failures is the number of requests that fail with 503 before the document is served
+	failures int32
+}
+
+func (s *configServer) set(doc string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.doc = doc
+	s.version++
+}
+
+func (s *configServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	atomic.AddInt32(&s.requests, 1)
+	if atomic.AddInt32(&s.failures, -1) >= 0 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	etag := `"v` + strconv.Itoa(s.version) + `"`
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	_, _ = w.Write([]byte(s.doc))
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []Option
+		handler  http.HandlerFunc
+		wantName string
+		wantErr  string
+	}{
+		{
+			name: "yaml",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte("name: app\n"))
+			},
+			wantName: "app",
+		},
+		{
+			name: "json",
+			opts: []Option{JSONTags()},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"name": "app"}`))
+			},
+			wantName: "app",
+		},
+		{
+			name: "bearer token",
+			opts: []Option{WithBearerToken("token")},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "Bearer token" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				_, _ = w.Write([]byte("name: app\n"))
+			},
+			wantName: "app",
+		},
+		{
+			name: "basic auth",
+			opts: []Option{WithBasicAuth("user", "pass"), WithHeader("X-Env", "prod")},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				user, pass, ok := r.BasicAuth()
+				if !ok || user != "user" || pass != "pass" || r.Header.Get("X-Env") != "prod" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				_, _ = w.Write([]byte("name: app\n"))
+			},
+			wantName: "app",
+		},
+		{
+			name: "unauthorized",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnauthorized)
+			},
+			wantErr: "unexpected response status: 401 Unauthorized",
+		},
+		{
+			name: "invalid document",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte("- a\n- b\n"))
+			},
+			wantErr: "failed to decode document",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+			d, err := New(server.URL, tt.opts...)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			storage, _ := fmap.Get[Config]()
+			val, err := d.GetValue(storage.MustFind("Name"))
+			assert.NoError(t, err)
+			assert.Equal(t, &tinyconf.Value{Source: server.URL, Value: tt.wantName}, val)
+		})
+	}
+}
+
+func TestNew_Retry(t *testing.T) {
+	s := &configServer{doc: "name: app\n", failures: 2}
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	d, err := New(server.URL, WithRetry(3, time.Millisecond))
+	assert.NoError(t, err)
+	assert.Equal(t, "remote", d.GetName())
+	assert.Equal(t, int32(3), atomic.LoadInt32(&s.requests))
+
+	atomic.StoreInt32(&s.failures, 3)
+	_, err = New(server.URL, WithRetry(3, time.Millisecond))
+	assert.ErrorContains(t, err, "failed to fetch "+server.URL+": endpoint is unavailable: 503 Service Unavailable")
+}
+
+func TestNew_Cache(t *testing.T) {
+	cacheFile := filepath.Join(t.TempDir(), "config.yaml")
+	s := &configServer{doc: "name: app\n"}
+	server := httptest.NewServer(s)
+	_, err := New(server.URL, WithCache(cacheFile))
+	assert.NoError(t, err)
+	data, err := os.ReadFile(cacheFile)
+	assert.NoError(t, err)
+	assert.Equal(t, "name: app\n", string(data))
+
+	// the endpoint is unreachable at startup
+	server.Close()
+	d, err := New(server.URL, WithCache(cacheFile), WithRetry(2, time.Millisecond))
+	assert.NoError(t, err)
+	storage, _ := fmap.Get[Config]()
+	val, err := d.GetValue(storage.MustFind("Name"))
+	assert.NoError(t, err)
+	assert.Equal(t, &tinyconf.Value{Source: cacheFile, Value: "app"}, val)
+
+	_, err = New(server.URL, WithCache(filepath.Join(t.TempDir(), "missing.yaml")), WithRetry(1, 0))
+	assert.ErrorContains(t, err, "error while read cache")
+}
+
+func TestNew_CacheNotUsedOnClientErrors(t *testing.T) {
+	cacheFile := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(cacheFile, []byte("name: cached\n"), 0o600))
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	_, err := New(server.URL, WithCache(cacheFile))
+	assert.ErrorContains(t, err, "unexpected response status: 404 Not Found")
+}
+
+func TestRemoteDriver_Poll(t *testing.T) {
+	s := &configServer{}
+	s.set("name: v1\nhttp:\n  port: 80\n")
+	server := httptest.NewServer(s)
+	defer server.Close()
+	cacheFile := filepath.Join(t.TempDir(), "config.yaml")
+	d, err := New(server.URL, WithCache(cacheFile))
+	assert.NoError(t, err)
+	conf := &Config{}
+	m := drivertest.Parse(t, d, conf)
+	assert.Equal(t, "v1", conf.Name)
+
+	watcher := d.(tinyconf.Watcher)
+	changed, err := watcher.Poll(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, changed)
+
+	s.set("name: v2\nhttp:\n  port: 8080\n  timeout: 1m\ndb:\n  main:\n    host: main.local\n")
+	changed, err = watcher.Poll(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.NoError(t, m.Parse(conf))
+	assert.Equal(t, "v2", conf.Name)
+	assert.Equal(t, 8080, conf.HTTP.Port)
+	assert.Equal(t, time.Minute, conf.HTTP.Timeout)
+	assert.Equal(t, map[string]Server{"main": {Host: "main.local"}}, conf.DB)
+	data, _ := os.ReadFile(cacheFile)
+	assert.Contains(t, string(data), "name: v2")
+
+	// the current document is kept when the new one is invalid
+	s.set("- invalid")
+	changed, err = watcher.Poll(context.Background())
+	assert.ErrorContains(t, err, "failed to decode document")
+	assert.False(t, changed)
+	assert.NoError(t, m.Parse(conf))
+	assert.Equal(t, "v2", conf.Name)
+}
+
+func TestRemoteDriver_Watch(t *testing.T) {
+	s := &configServer{}
+	s.set("name: v1\n")
+	server := httptest.NewServer(s)
+	defer server.Close()
+	d, err := New(server.URL, WithInterval(time.Millisecond))
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := make(chan error, 1)
+	go d.(tinyconf.Watcher).Watch(ctx, func(err error) {
+		select {
+		case changes <- err:
+		default:
+		}
+	})
+	s.set("name: v2\n")
+	select {
+	case err := <-changes:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("document change is not reported")
+	}
+	storage, _ := fmap.Get[Config]()
+	val, err := d.GetValue(storage.MustFind("Name"))
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", val.Value)
+}
+
+func TestRemoteDriver_GenDoc(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+	storage, _ := fmap.Get[Config]()
+	register := &tinyconf.Registered{Storage: storage, Config: &Config{Name: "app"}}
+
+	d, _ := New(server.URL)
+	assert.Contains(t, d.GenDoc(register), "name: app")
+	d, _ = New(server.URL, JSONTags())
+	assert.Contains(t, d.GenDoc(register), `"name": "app"`)
+}
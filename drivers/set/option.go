@@ -0,0 +1,45 @@
+package set
+
+type Option interface {
+	apply(*setDriver)
+}
+
+type valuesOption struct {
+	values []string
+}
+
+func (o valuesOption) apply(d *setDriver) {
+	d.args = append(d.args, o.values...)
+}
+
+// WithValues adds --set argument values, every value is the comma separated list of path=value pairs,
+// i.e. WithValues("http.port=9090,http.auth.issuer=me").
+func WithValues(values ...string) Option {
+	return valuesOption{values: values}
+}
+
+// WithArgs adds values of --set arguments from the command line arguments, i.e. WithArgs(os.Args[1:]).
+func WithArgs(args []string) Option {
+	return valuesOption{values: Args(args)}
+}
+
+type configOption struct {
+	name string
+	conf any
+}
+
+func (o configOption) apply(d *setDriver) {
+	d.configs = append(d.configs, o)
+}
+
+// WithConfig sets the config to validate paths against its fields, unknown paths and values that can't be converted
+// to the field type are reported by New. The option or WithNamedConfig is required, it can be repeated for several configs.
+func WithConfig(conf any) Option {
+	return configOption{conf: conf}
+}
+
+// WithNamedConfig sets the config registered by Manager.RegisterNamed to validate paths against its fields,
+// paths of its fields start with the name, i.e. primary.host for WithNamedConfig("primary", &db).
+func WithNamedConfig(name string, conf any) Option {
+	return configOption{name: name, conf: conf}
+}
@@ -0,0 +1,126 @@
+package set
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/insei/tinyconf/strings118"
+)
+
+// pair is the path=value pair of --set argument, the path is normalized to dotted form.
+type pair struct {
+	path  string
+	value string
+}
+
+var indexRe = regexp.MustCompile(`\[(\d+)]`)
+
+// normalizePath converts list indices to dotted segments, i.e. servers[0].host -> servers.0.host.
+func normalizePath(path string) string {
+	return indexRe.ReplaceAllString(path, ".$1")
+}
+
+// parsePairs parses comma separated path=value pairs of --set argument. Values in single or double quotes
+// can contain commas and equal signs, backslash escapes the next character outside of single quotes,
+// i.e. a=1,b="x,y",c=x\,y.
+func parsePairs(arg string) ([]pair, error) {
+	var pairs []pair
+	var current strings.Builder
+	var path string
+	hasPath := false
+	var quote rune
+	escaped := false
+	flush := func() error {
+		if !hasPath {
+			if current.Len() == 0 {
+				return fmt.Errorf("invalid --set %q: empty path=value pair", arg)
+			}
+			return fmt.Errorf("invalid --set %q: expected path=value, got %q", arg, current.String())
+		}
+		path = normalizePath(strings.TrimSpace(path))
+		if path == "" || strings.Contains(path, "..") || strings.HasPrefix(path, ".") || strings.HasSuffix(path, ".") {
+			return fmt.Errorf("invalid --set %q: invalid path %q", arg, path)
+		}
+		pairs = append(pairs, pair{path: path, value: current.String()})
+		current.Reset()
+		hasPath = false
+		return nil
+	}
+	for _, r := range arg {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+				continue
+			}
+			current.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '=' && !hasPath:
+			path = current.String()
+			hasPath = true
+			current.Reset()
+		case r == ',':
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("invalid --set %q: unterminated quote", arg)
+	}
+	if escaped {
+		current.WriteRune('\\')
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return pairs, nil
+}
+
+// Args returns values of --set arguments, they can be passed as --set a=1, --set=a=1, -set a=1 or -set=a=1.
+func Args(args []string) []string {
+	var values []string
+	for i := 0; i < len(args); i++ {
+		arg := strings.TrimPrefix(args[i], "-")
+		arg = strings.TrimPrefix(arg, "-")
+		if len(arg) == len(args[i]) {
+			continue
+		}
+		if arg == "set" && i+1 < len(args) {
+			values = append(values, args[i+1])
+			i++
+			continue
+		}
+		if value, ok := strings118.CutPrefix(arg, "set="); ok {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// Values is the flag.Value of repeated --set arguments, i.e. flag.Var(&values, "set", "set config value").
+type Values []string
+
+func (v *Values) String() string {
+	if v == nil {
+		return ""
+	}
+	return strings.Join(*v, ",")
+}
+
+func (v *Values) Set(s string) error {
+	if _, err := parsePairs(s); err != nil {
+		return err
+	}
+	*v = append(*v, s)
+	return nil
+}
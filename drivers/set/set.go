@@ -0,0 +1,316 @@
+package set
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/insei/fmap/v3"
+
+	"github.com/insei/tinyconf"
+	"github.com/insei/tinyconf/slices118"
+	"github.com/insei/tinyconf/strings118"
+)
+
+var (
+	// ErrUnknownPath is returned by New if the path of --set pair doesn't match any field of configs from WithConfig options.
+	ErrUnknownPath = errors.New("unknown path")
+	// ErrNoConfig is returned by New without WithConfig and WithNamedConfig options, paths can't be validated without configs.
+	ErrNoConfig = errors.New("no config to validate --set paths, use WithConfig or WithNamedConfig option")
+)
+
+type setDriver struct {
+	name    string
+	args    []string
+	configs []configOption
+	// values are pairs by canonical paths, the last pair of the path wins
+	values map[string]pair
+}
+
+// getPaths returns the yaml path and the struct path of the field, the field is matched by any of them.
+func getPaths(field fmap.Field) []string {
+	paths := []string{field.GetStructPath()}
+	if yamlPath := field.GetTagPath("yaml", false); yamlPath != "" {
+		paths = append(paths, yamlPath)
+	}
+	return paths
+}
+
+func (d *setDriver) lookup(field fmap.Field) (pair, bool) {
+	for _, path := range getPaths(field) {
+		if p, ok := d.values[path]; ok {
+			return p, true
+		}
+	}
+	return pair{}, false
+}
+
+// lookupItems returns pairs of scalar slice items by indexes, i.e. tags.0 for the tags field.
+func (d *setDriver) lookupItems(field fmap.Field) map[int]pair {
+	items := map[int]pair{}
+	for _, prefix := range getPaths(field) {
+		for path, p := range d.values {
+			rest, ok := strings118.CutPrefix(path, prefix+".")
+			if !ok {
+				continue
+			}
+			if index, err := strconv.Atoi(rest); err == nil {
+				items[index] = p
+			}
+		}
+	}
+	return items
+}
+
+func (d *setDriver) GetValue(field fmap.Field) (*tinyconf.Value, error) {
+	p, ok := d.lookup(field)
+	items := d.lookupItems(field)
+	if !ok && len(items) == 0 {
+		return nil, fmt.Errorf("%w: --set is not defined for %s config field", tinyconf.ErrValueNotFound, field.GetStructPath())
+	}
+	var value any
+	var sources []string
+	if ok {
+		var err error
+		value, err = tinyconf.DecodeField(field, p.value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse --set %s value for %s config field: %w", p.path, field.GetStructPath(), err)
+		}
+		sources = append(sources, p.path)
+	}
+	if len(items) > 0 {
+		var err error
+		value, sources, err = setItems(field, value, items, sources)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &tinyconf.Value{Source: "--set " + strings.Join(sources, ","), Value: value}, nil
+}
+
+// setItems sets items of the scalar slice by indexes, the slice is extended by zero items up to the highest index.
+func setItems(field fmap.Field, value any, items map[int]pair, sources []string) (any, []string, error) {
+	indexes := make([]int, 0, len(items))
+	for index := range items {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	typeOf := field.GetType()
+	slice := reflect.MakeSlice(typeOf, 0, indexes[len(indexes)-1]+1)
+	if value != nil {
+		slice = reflect.AppendSlice(slice, reflect.ValueOf(value))
+	}
+	for _, index := range indexes {
+		p := items[index]
+		item, err := tinyconf.Decode(p.value, typeOf.Elem())
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse --set %s value for %s config field item: %w", p.path, field.GetStructPath(), err)
+		}
+		if index >= slice.Len() {
+			slice = reflect.AppendSlice(slice, reflect.MakeSlice(typeOf, index+1-slice.Len(), index+1-slice.Len()))
+		}
+		slice.Index(index).Set(reflect.ValueOf(item))
+		sources = append(sources, p.path)
+	}
+	return slice.Interface(), sources, nil
+}
+
+// GetKeys returns keys of map[string]T section entries or indexes of []T section items from paths,
+// i.e. main for db.main.host path.
+func (d *setDriver) GetKeys(field fmap.Field) ([]string, error) {
+	var keys []string
+	for _, prefix := range getPaths(field) {
+		for path := range d.values {
+			rest, ok := strings118.CutPrefix(path, prefix+".")
+			if !ok {
+				continue
+			}
+			key := strings.Split(rest, ".")[0]
+			if !slices118.Contains(keys, key) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w: no --set keys for %s config field", tinyconf.ErrValueNotFound, field.GetStructPath())
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (d *setDriver) GetName() string {
+	return d.name
+}
+
+// GenDoc returns --set arguments of value fields of registered configs with default values and docs,
+// maps keys and slices indexes are placeholders, i.e. --set db.<name>.host= or --set servers.0.host=.
+func (d *setDriver) GenDoc(registers ...*tinyconf.Registered) string {
+	var doc string
+	var paths []string
+	for _, register := range registers {
+		for _, configField := range register.GetFields() {
+			fld := configField.Field
+			if !tinyconf.IsValueField(fld) {
+				continue
+			}
+			fieldPaths := getPaths(fld)
+			path := fieldPaths[len(fieldPaths)-1]
+			if slices118.Contains(paths, path) {
+				continue
+			}
+			paths = append(paths, path)
+			value := tinyconf.FormatFieldValue(fld, configField.Value)
+			if tinyconf.IsHiddenField(fld, false) {
+				value = ""
+			}
+			doc += fmt.Sprintf("--set %s=%s\n", path, value)
+			if fieldDoc := fld.GetTag().Get("doc"); fieldDoc != "" {
+				doc += "\t" + fieldDoc + "\n"
+			}
+		}
+	}
+	return doc
+}
+
+// matchTemplate reports whether the path matches the path of the template field, map keys placeholders
+// (<key>) match any segment and slice items placeholders (0) match any index.
+func matchTemplate(path, template string) bool {
+	segments := strings.Split(path, ".")
+	templateSegments := strings.Split(template, ".")
+	if len(segments) != len(templateSegments) {
+		return false
+	}
+	for i, segment := range segments {
+		templateSegment := templateSegments[i]
+		switch {
+		case segment == templateSegment:
+		case strings.HasPrefix(templateSegment, "<") && strings.HasSuffix(templateSegment, ">"):
+		case templateSegment == "0" && isIndex(segment):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func isIndex(segment string) bool {
+	index, err := strconv.Atoi(segment)
+	return err == nil && index >= 0
+}
+
+// getFields returns value fields of configs of WithConfig and WithNamedConfig options.
+func (d *setDriver) getFields() ([]fmap.Field, error) {
+	var fields []fmap.Field
+	for _, conf := range d.configs {
+		storage, err := fmap.GetFrom(conf.conf)
+		if err != nil {
+			return nil, err
+		}
+		register := &tinyconf.Registered{Storage: storage, Config: conf.conf, Name: conf.name}
+		for _, configField := range register.GetFields() {
+			if tinyconf.IsValueField(configField.Field) {
+				fields = append(fields, configField.Field)
+			}
+		}
+	}
+	return fields, nil
+}
+
+// canonicalPath returns the path of the field in one form for struct paths and yaml paths of pairs: the yaml path
+// or the struct path of fields without yaml path, map keys and slice indexes are taken from the path of the pair.
+func canonicalPath(field fmap.Field, path string) string {
+	fieldPaths := getPaths(field)
+	segments := strings.Split(fieldPaths[len(fieldPaths)-1], ".")
+	for i, segment := range strings.Split(path, ".") {
+		templateSegment := segments[i]
+		switch {
+		case strings.HasPrefix(templateSegment, "<") && strings.HasSuffix(templateSegment, ">"):
+			segments[i] = segment
+		case templateSegment == "0" && isIndex(segment):
+			index, _ := strconv.Atoi(segment)
+			segments[i] = strconv.Itoa(index)
+		}
+	}
+	return strings.Join(segments, ".")
+}
+
+// resolve returns the canonical path of the pair and checks that the value can be converted to the type of the field,
+// items of scalar slices are addressed by indexes, i.e. tags.0 for []string tags field.
+func resolve(fields []fmap.Field, p pair) (string, error) {
+	if field := findField(fields, p.path); field != nil {
+		if _, err := tinyconf.DecodeField(field, p.value); err != nil {
+			return "", fmt.Errorf("invalid --set %s value for %s config field: %w", p.path, field.GetStructPath(), err)
+		}
+		return canonicalPath(field, p.path), nil
+	}
+	if i := strings.LastIndex(p.path, "."); i > 0 && isIndex(p.path[i+1:]) {
+		field := findField(fields, p.path[:i])
+		if field != nil && field.GetType().Kind() == reflect.Slice {
+			if _, err := tinyconf.Decode(p.value, field.GetType().Elem()); err != nil {
+				return "", fmt.Errorf("invalid --set %s value for %s config field item: %w", p.path, field.GetStructPath(), err)
+			}
+			index, _ := strconv.Atoi(p.path[i+1:])
+			return canonicalPath(field, p.path[:i]) + "." + strconv.Itoa(index), nil
+		}
+	}
+	return "", fmt.Errorf("%w: --set %s doesn't match any config field", ErrUnknownPath, p.path)
+}
+
+// set stores the pair by the canonical path, items of the scalar slice set before are replaced by the whole slice.
+func (d *setDriver) set(path string, p pair) {
+	for itemPath := range d.values {
+		if rest, ok := strings118.CutPrefix(itemPath, path+"."); ok && isIndex(rest) {
+			delete(d.values, itemPath)
+		}
+	}
+	d.values[path] = p
+}
+
+func findField(fields []fmap.Field, path string) fmap.Field {
+	for _, field := range fields {
+		for _, template := range getPaths(field) {
+			if matchTemplate(path, template) {
+				return field
+			}
+		}
+	}
+	return nil
+}
+
+// New returns the driver of --set path=value pairs, i.e. --set http.port=9090 --set servers[0].host=a.local.
+// Paths are yaml paths or struct paths of fields, list indices are written in brackets or as dotted segments.
+// At least one WithConfig or WithNamedConfig option is required, so typos in paths are reported by New instead
+// of being ignored.
+func New(opts ...Option) (tinyconf.Driver, error) {
+	d := &setDriver{
+		name:   "set",
+		values: map[string]pair{},
+	}
+	for _, opt := range opts {
+		opt.apply(d)
+	}
+	if len(d.configs) == 0 {
+		return nil, ErrNoConfig
+	}
+	fields, err := d.getFields()
+	if err != nil {
+		return nil, err
+	}
+	for _, arg := range d.args {
+		pairs, err := parsePairs(arg)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range pairs {
+			path, err := resolve(fields, p)
+			if err != nil {
+				return nil, err
+			}
+			d.set(path, p)
+		}
+	}
+	return d, nil
+}
@@ -0,0 +1,264 @@
+package set
+
+import (
+	"flag"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/insei/fmap/v3"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/insei/tinyconf"
+	"github.com/insei/tinyconf/drivers/internal/drivertest"
+)
+
+type Server struct {
+	Host string `yaml:"host" doc:"server host"`
+	Port int    `yaml:"port"`
+}
+
+type Config struct {
+	Name string `yaml:"name" doc:"application name"`
+	HTTP struct {
+		Port    int           `yaml:"port" doc:"http port"`
+		Timeout time.Duration `yaml:"timeout"`
+		Auth    struct {
+			Issuer string `yaml:"issuer"`
+			Secret string `yaml:"secret" hidden:"true"`
+		} `yaml:"auth"`
+	} `yaml:"http"`
+	Servers []Server          `yaml:"servers"`
+	DB      map[string]Server `yaml:"db" key:"name"`
+}
+
+type Lists struct {
+	Tags  []string `yaml:"tags" encoding:"json"`
+	Ports []int
+}
+
+func Test_parsePairs(t *testing.T) {
+	tests := []struct {
+		arg     string
+		want    []pair
+		wantErr string
+	}{
+		{arg: "a=1", want: []pair{{path: "a", value: "1"}}},
+		{arg: "a=1,b.c=2", want: []pair{{path: "a", value: "1"}, {path: "b.c", value: "2"}}},
+		{arg: "a=", want: []pair{{path: "a", value: ""}}},
+		{arg: "servers[1].host=x", want: []pair{{path: "servers.1.host", value: "x"}}},
+		{arg: `a="x,y=z"`, want: []pair{{path: "a", value: "x,y=z"}}},
+		{arg: `a='x\y'`, want: []pair{{path: "a", value: `x\y`}}},
+		{arg: `a="say \"hi\""`, want: []pair{{path: "a", value: `say "hi"`}}},
+		{arg: `a=x\,y`, want: []pair{{path: "a", value: "x,y"}}},
+		{arg: `a=b=c`, want: []pair{{path: "a", value: "b=c"}}},
+		{arg: "a", wantErr: `invalid --set "a": expected path=value, got "a"`},
+		{arg: "a=1,", wantErr: `invalid --set "a=1,": empty path=value pair`},
+		{arg: "=1", wantErr: `invalid --set "=1": invalid path ""`},
+		{arg: "a..b=1", wantErr: `invalid --set "a..b=1": invalid path "a..b"`},
+		{arg: `a="x`, wantErr: `invalid --set "a=\"x": unterminated quote`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			got, err := parsePairs(tt.arg)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestArgs(t *testing.T) {
+	args := []string{"-v", "--set", "a=1", "--set=b=2", "-set", "c=3", "-set=d=4", "set", "e=5", "--set"}
+	assert.Equal(t, []string{"a=1", "b=2", "c=3", "d=4"}, Args(args))
+}
+
+func TestValues(t *testing.T) {
+	var values Values
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Var(&values, "set", "set config value")
+	assert.NoError(t, fs.Parse([]string{"-set", "a=1", "-set", "b=2,c=3"}))
+	assert.Equal(t, Values{"a=1", "b=2,c=3"}, values)
+	assert.Equal(t, "a=1,b=2,c=3", values.String())
+	assert.Error(t, fs.Parse([]string{"-set", "a"}))
+}
+
+func TestNew(t *testing.T) {
+	conf := &Config{}
+	tests := []struct {
+		name    string
+		opts    []Option
+		wantErr string
+	}{
+		{name: "yaml paths", opts: []Option{WithValues("http.port=9090,http.auth.issuer=me"), WithConfig(conf)}},
+		{name: "struct paths", opts: []Option{WithValues("HTTP.Port=9090", "Servers[2].Host=x"), WithConfig(conf)}},
+		{name: "map and list", opts: []Option{WithValues("db.main.host=x", "servers[0].port=1"), WithConfig(conf)}},
+		{name: "no config", opts: []Option{WithValues("http.port=9090")}, wantErr: ErrNoConfig.Error()},
+		{
+			name:    "unknown path",
+			opts:    []Option{WithValues("http.prot=9090"), WithConfig(conf)},
+			wantErr: "unknown path: --set http.prot doesn't match any config field",
+		},
+		{
+			name:    "section path",
+			opts:    []Option{WithValues("http=1"), WithConfig(conf)},
+			wantErr: "unknown path: --set http doesn't match any config field",
+		},
+		{
+			name:    "invalid index",
+			opts:    []Option{WithValues("servers.a.host=x"), WithConfig(conf)},
+			wantErr: "unknown path: --set servers.a.host doesn't match any config field",
+		},
+		{
+			name:    "invalid value",
+			opts:    []Option{WithArgs([]string{"--set", "http.port=http"}), WithConfig(conf)},
+			wantErr: "invalid --set http.port value for HTTP.Port config field",
+		},
+		{name: "invalid pair", opts: []Option{WithValues("http.port"), WithConfig(conf)}, wantErr: "expected path=value"},
+		{name: "scalar slice items", opts: []Option{WithValues("tags[0]=x", "Ports.1=80"), WithConfig(&Lists{})}},
+		{
+			name:    "invalid scalar slice item",
+			opts:    []Option{WithValues("Ports[0]=http"), WithConfig(&Lists{})},
+			wantErr: "invalid --set Ports.0 value for Ports config field item",
+		},
+		{
+			name:    "scalar index",
+			opts:    []Option{WithValues("name[0]=x"), WithConfig(conf)},
+			wantErr: "unknown path: --set name.0 doesn't match any config field",
+		},
+		{name: "named config", opts: []Option{WithValues("primary.host=x", "primary.Port=1"), WithNamedConfig("primary", &Server{})}},
+		{
+			name:    "named config without name",
+			opts:    []Option{WithValues("host=x"), WithNamedConfig("primary", &Server{})},
+			wantErr: "unknown path: --set host doesn't match any config field",
+		},
+		{
+			name:    "invalid named config value",
+			opts:    []Option{WithValues("primary.port=http"), WithNamedConfig("primary", &Server{})},
+			wantErr: "invalid --set primary.port value for primary.Port config field",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.opts...)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+	_, err := New(WithValues("x=1"), WithConfig(conf))
+	assert.ErrorIs(t, err, ErrUnknownPath)
+}
+
+func TestSetDriver_GetValue(t *testing.T) {
+	d, err := New(WithValues("http.port=9090", "HTTP.Timeout=1m", "name=first", "name=last"), WithConfig(&Config{}))
+	assert.NoError(t, err)
+	assert.Equal(t, "set", d.GetName())
+	storage, _ := fmap.Get[Config]()
+	drivertest.GetValue(t, d, storage, []drivertest.Case{
+		{Path: "HTTP.Port", Want: &tinyconf.Value{Source: "--set http.port", Value: 9090}},
+		{Path: "HTTP.Timeout", Want: &tinyconf.Value{Source: "--set HTTP.Timeout", Value: time.Minute}},
+		{Path: "Name", Want: &tinyconf.Value{Source: "--set name", Value: "last"}},
+		{Path: "HTTP.Auth.Issuer", WantErr: tinyconf.ErrValueNotFound},
+	})
+}
+
+func TestSetDriver_LastPairWins(t *testing.T) {
+	d, err := New(WithValues("HTTP.Port=1", "http.port=2", "db.main.host=a,DB.main.Host=b", "servers[01].port=1,servers.1.port=2"),
+		WithConfig(&Config{}))
+	assert.NoError(t, err)
+	storage, _ := fmap.Get[Config]()
+	drivertest.GetValue(t, d, storage, []drivertest.Case{
+		{Path: "HTTP.Port", Want: &tinyconf.Value{Source: "--set http.port", Value: 2}},
+	})
+	conf := &Config{}
+	drivertest.Parse(t, d, conf)
+	assert.Equal(t, 2, conf.HTTP.Port)
+	assert.Equal(t, map[string]Server{"main": {Host: "b"}}, conf.DB)
+	assert.Equal(t, []Server{{}, {Port: 2}}, conf.Servers)
+}
+
+func TestSetDriver_ScalarSliceItems(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		want   *Lists
+		source string
+	}{
+		{name: "items", values: []string{"tags[1]=b,tags[0]=a", "Ports[2]=443"},
+			want: &Lists{Tags: []string{"a", "b"}, Ports: []int{0, 0, 443}}, source: "--set tags.0,tags.1"},
+		{name: "items of the whole value", values: []string{`tags='["a","b","c"]'`, "tags[1]=x,tags[3]=d"},
+			want: &Lists{Tags: []string{"a", "x", "c", "d"}}, source: "--set tags,tags.1,tags.3"},
+		{name: "whole value after items", values: []string{"tags[1]=x", `tags='["a"]'`},
+			want: &Lists{Tags: []string{"a"}}, source: "--set tags"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := New(WithValues(tt.values...), WithConfig(&Lists{}))
+			assert.NoError(t, err)
+			storage, _ := fmap.Get[Lists]()
+			drivertest.GetValue(t, d, storage, []drivertest.Case{
+				{Path: "Tags", Want: &tinyconf.Value{Source: tt.source, Value: tt.want.Tags}},
+			})
+			conf := &Lists{}
+			drivertest.Parse(t, d, conf)
+			assert.Equal(t, tt.want, conf)
+		})
+	}
+}
+
+func TestSetDriver_ParseNamed(t *testing.T) {
+	primary, replica := &Server{}, &Server{}
+	d, err := New(WithValues("primary.host=primary.local,replica.Host=replica.local,replica.port=5433"),
+		WithNamedConfig("primary", primary), WithNamedConfig("replica", replica))
+	assert.NoError(t, err)
+	m, _ := tinyconf.New(tinyconf.WithDriver(d))
+	assert.NoError(t, m.RegisterNamed("primary", primary))
+	assert.NoError(t, m.RegisterNamed("replica", replica))
+	assert.NoError(t, m.Parse(primary))
+	assert.NoError(t, m.Parse(replica))
+	assert.Equal(t, &Server{Host: "primary.local"}, primary)
+	assert.Equal(t, &Server{Host: "replica.local", Port: 5433}, replica)
+}
+
+func TestSetDriver_Parse(t *testing.T) {
+	conf := &Config{}
+	d, err := New(WithArgs([]string{
+		"--set", "http.port=9090,http.auth.issuer=me",
+		"--set", "servers[0].host=a.local,servers[1].host=b.local,servers[1].port=81",
+		"--set", "db.main.host=main.local",
+	}), WithConfig(conf))
+	assert.NoError(t, err)
+	drivertest.Parse(t, d, conf)
+	assert.Equal(t, 9090, conf.HTTP.Port)
+	assert.Equal(t, "me", conf.HTTP.Auth.Issuer)
+	assert.Equal(t, []Server{{Host: "a.local"}, {Host: "b.local", Port: 81}}, conf.Servers)
+	assert.Equal(t, map[string]Server{"main": {Host: "main.local"}}, conf.DB)
+}
+
+func TestSetDriver_GenDoc(t *testing.T) {
+	d, _ := New(WithConfig(&Config{}))
+	storage, _ := fmap.Get[Config]()
+	conf := &Config{Name: "app"}
+	conf.HTTP.Auth.Secret = "secret"
+	assert.Equal(t, `--set name=app
+	application name
+--set http.port=0
+	http port
+--set http.timeout=0s
+--set http.auth.issuer=
+--set http.auth.secret=
+--set servers.0.host=
+	server host
+--set servers.0.port=0
+--set db.<name>.host=
+	server host
+--set db.<name>.port=0
+`, d.GenDoc(&tinyconf.Registered{Storage: storage, Config: conf}))
+}
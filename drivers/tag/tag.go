@@ -3,7 +3,6 @@ package tag
 import (
 	"fmt"
 
-	"github.com/insei/cast"
 	"github.com/insei/fmap/v3"
 	"github.com/insei/tinyconf"
 )
@@ -21,9 +20,9 @@ func (d defaultTagDriver) GetValue(field fmap.Field) (*tinyconf.Value, error) {
 	if valueStr == "" {
 		return nil, fmt.Errorf("%w: %s tag is set, but has empty value for %s config field", tinyconf.ErrIncorrectTagSettings, d.tag, field.GetStructPath())
 	}
-	value, err := cast.ToReflect(valueStr, field.GetType())
+	value, err := tinyconf.DecodeField(field, valueStr)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse value from tag: %s", err)
+		return nil, fmt.Errorf("failed to parse value from tag: %w", err)
 	}
 	return &tinyconf.Value{Source: d.tag, Value: value}, err
 }
@@ -32,6 +31,11 @@ func (d defaultTagDriver) GetName() string {
 	return d.name
 }
 
+// IsDefaults reports that tag values are default values, see tinyconf.DefaultsDriver.
+func (d defaultTagDriver) IsDefaults() bool {
+	return true
+}
+
 func (d defaultTagDriver) GenDoc(registers ...*tinyconf.Registered) string {
 	return ""
 }
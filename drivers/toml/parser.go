@@ -0,0 +1,597 @@
+package toml
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// parser decodes TOML v1.0 documents to nested maps: tables are map[string]any, arrays and arrays of tables
+// are []any, values are string, int64, float64, bool, time.Time and localDatetime. Offset datetimes are time.Time
+// with their offset, local datetimes and dates are localDatetime and local times are kept as strings, i.e. "07:32:00".
+type parser struct {
+	src  string
+	pos  int
+	root map[string]any
+	// current is the table of the last header, path is its dotted path
+	current map[string]any
+	path    string
+	// tables are explicitly defined tables, they can't be defined twice
+	tables map[string]bool
+	// inline are inline tables and static arrays, they can't be extended
+	inline map[string]bool
+}
+
+func parse(src string) (map[string]any, error) {
+	p := &parser{
+		src:    strings.TrimPrefix(src, "\ufeff"),
+		root:   map[string]any{},
+		tables: map[string]bool{},
+		inline: map[string]bool{},
+	}
+	p.current = p.root
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	return p.root, nil
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	line := strings.Count(p.src[:p.pos], "\n") + 1
+	return fmt.Errorf("toml: line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *parser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *parser) skipSpaces() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+func (p *parser) skipComment() {
+	if p.peek() != '#' {
+		return
+	}
+	for !p.eof() && p.peek() != '\n' {
+		p.pos++
+	}
+}
+
+// skipNewline consumes LF or CRLF and reports whether the line ended.
+func (p *parser) skipNewline() bool {
+	if strings.HasPrefix(p.src[p.pos:], "\r\n") {
+		p.pos += 2
+		return true
+	}
+	if p.peek() == '\n' {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// skipBlank skips spaces, comments and newlines inside arrays.
+func (p *parser) skipBlank() {
+	for {
+		p.skipSpaces()
+		p.skipComment()
+		if !p.skipNewline() {
+			return
+		}
+	}
+}
+
+func (p *parser) parse() error {
+	for {
+		p.skipSpaces()
+		p.skipComment()
+		if p.eof() {
+			return nil
+		}
+		if p.skipNewline() {
+			continue
+		}
+		var err error
+		if p.peek() == '[' {
+			err = p.parseTable()
+		} else {
+			err = p.parseKeyValue(p.current, p.path)
+		}
+		if err != nil {
+			return err
+		}
+		p.skipSpaces()
+		p.skipComment()
+		if !p.eof() && !p.skipNewline() {
+			return p.errorf("expected the end of line, got %q", p.peek())
+		}
+	}
+}
+
+func (p *parser) parseTable() error {
+	p.pos++
+	isArray := p.peek() == '['
+	if isArray {
+		p.pos++
+	}
+	p.skipSpaces()
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	closing := "]"
+	if isArray {
+		closing = "]]"
+	}
+	if !strings.HasPrefix(p.src[p.pos:], closing) {
+		return p.errorf("expected %q after the table name", closing)
+	}
+	p.pos += len(closing)
+
+	table, err := p.walk(p.root, "", keys[:len(keys)-1])
+	if err != nil {
+		return err
+	}
+	key := keys[len(keys)-1]
+	path := joinPath(keys)
+	p.path = path
+	if p.inline[path] {
+		return p.errorf("%s can't be extended", path)
+	}
+	existing, ok := table[key]
+	if isArray {
+		if !ok {
+			existing = []any{}
+		}
+		items, isItems := existing.([]any)
+		if !isItems {
+			return p.errorf("%s is not an array of tables", path)
+		}
+		p.current = map[string]any{}
+		table[key] = append(items, p.current)
+		// tables and values of the previous item can be defined again in the new one
+		for defined := range p.tables {
+			if strings.HasPrefix(defined, path+".") {
+				delete(p.tables, defined)
+			}
+		}
+		for defined := range p.inline {
+			if strings.HasPrefix(defined, path+".") {
+				delete(p.inline, defined)
+			}
+		}
+		return nil
+	}
+	if p.tables[path] {
+		return p.errorf("table %s is already defined", path)
+	}
+	p.tables[path] = true
+	if !ok {
+		p.current = map[string]any{}
+		table[key] = p.current
+		return nil
+	}
+	current, isTable := existing.(map[string]any)
+	if !isTable {
+		return p.errorf("%s is already defined as a value", path)
+	}
+	p.current = current
+	return nil
+}
+
+// walk returns the table by the dotted keys from the table, missing tables are created,
+// the last item is used for arrays of tables.
+func (p *parser) walk(table map[string]any, prefix string, keys []string) (map[string]any, error) {
+	for _, key := range keys {
+		prefix = joinPath([]string{prefix, key})
+		if p.inline[prefix] {
+			return nil, p.errorf("%s can't be extended", prefix)
+		}
+		switch casted := table[key].(type) {
+		case nil:
+			child := map[string]any{}
+			table[key] = child
+			table = child
+		case map[string]any:
+			table = casted
+		case []any:
+			if len(casted) == 0 {
+				return nil, p.errorf("%s is not a table", prefix)
+			}
+			last, ok := casted[len(casted)-1].(map[string]any)
+			if !ok {
+				return nil, p.errorf("%s is not a table", prefix)
+			}
+			table = last
+		default:
+			return nil, p.errorf("%s is already defined as a value", prefix)
+		}
+	}
+	return table, nil
+}
+
+func joinPath(keys []string) string {
+	var parts []string
+	for _, key := range keys {
+		if key != "" {
+			parts = append(parts, key)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+func (p *parser) parseKeyValue(table map[string]any, prefix string) error {
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	if p.peek() != '=' {
+		return p.errorf("expected '=' after the key %s", joinPath(keys))
+	}
+	p.pos++
+	p.skipSpaces()
+	table, err = p.walk(table, prefix, keys[:len(keys)-1])
+	if err != nil {
+		return err
+	}
+	key := keys[len(keys)-1]
+	if _, ok := table[key]; ok {
+		return p.errorf("key %s is already defined", joinPath(keys))
+	}
+	path := joinPath(append([]string{prefix}, keys...))
+	val, err := p.parseValue(path)
+	if err != nil {
+		return err
+	}
+	table[key] = val
+	return nil
+}
+
+func isBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// parseKey parses bare, quoted and dotted keys, trailing spaces are consumed.
+func (p *parser) parseKey() ([]string, error) {
+	var keys []string
+	for {
+		p.skipSpaces()
+		var key string
+		switch c := p.peek(); {
+		case c == '"':
+			str, err := p.parseBasicString()
+			if err != nil {
+				return nil, err
+			}
+			key = str
+		case c == '\'':
+			str, err := p.parseLiteralString()
+			if err != nil {
+				return nil, err
+			}
+			key = str
+		case isBareKeyChar(c):
+			start := p.pos
+			for !p.eof() && isBareKeyChar(p.peek()) {
+				p.pos++
+			}
+			key = p.src[start:p.pos]
+		default:
+			return nil, p.errorf("expected a key, got %q", c)
+		}
+		keys = append(keys, key)
+		p.skipSpaces()
+		if p.peek() != '.' {
+			return keys, nil
+		}
+		p.pos++
+	}
+}
+
+func (p *parser) parseValue(path string) (any, error) {
+	switch c := p.peek(); {
+	case strings.HasPrefix(p.src[p.pos:], `"""`):
+		return p.parseMultilineString(`"""`)
+	case strings.HasPrefix(p.src[p.pos:], `'''`):
+		return p.parseMultilineString(`'''`)
+	case c == '"':
+		return p.parseBasicString()
+	case c == '\'':
+		return p.parseLiteralString()
+	case c == '[':
+		p.inline[path] = true
+		return p.parseArray(path)
+	case c == '{':
+		p.inline[path] = true
+		return p.parseInlineTable(path)
+	case strings.HasPrefix(p.src[p.pos:], "true"):
+		p.pos += 4
+		return true, nil
+	case strings.HasPrefix(p.src[p.pos:], "false"):
+		p.pos += 5
+		return false, nil
+	case c == 0 || c == '\n' || c == '\r' || c == '#':
+		return nil, p.errorf("expected a value")
+	}
+	return p.parseScalar()
+}
+
+func (p *parser) parseArray(path string) ([]any, error) {
+	p.pos++
+	items := []any{}
+	for {
+		p.skipBlank()
+		if p.peek() == ']' {
+			p.pos++
+			return items, nil
+		}
+		if p.eof() {
+			return nil, p.errorf("unterminated array")
+		}
+		val, err := p.parseValue(path + "." + strconv.Itoa(len(items)))
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, val)
+		p.skipBlank()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+		default:
+			return nil, p.errorf("expected ',' or ']' in array")
+		}
+	}
+}
+
+func (p *parser) parseInlineTable(path string) (map[string]any, error) {
+	p.pos++
+	table := map[string]any{}
+	p.skipSpaces()
+	if p.peek() == '}' {
+		p.pos++
+		return table, nil
+	}
+	for {
+		if err := p.parseKeyValue(table, path); err != nil {
+			return nil, err
+		}
+		p.skipSpaces()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			return table, nil
+		default:
+			return nil, p.errorf("expected ',' or '}' in inline table")
+		}
+	}
+}
+
+func (p *parser) parseLiteralString() (string, error) {
+	p.pos++
+	end := strings.IndexAny(p.src[p.pos:], "'\n")
+	if end < 0 || p.src[p.pos+end] != '\'' {
+		return "", p.errorf("unterminated string")
+	}
+	str := p.src[p.pos : p.pos+end]
+	p.pos += end + 1
+	return str, nil
+}
+
+func (p *parser) parseBasicString() (string, error) {
+	p.pos++
+	var b strings.Builder
+	for {
+		if p.eof() || p.peek() == '\n' {
+			return "", p.errorf("unterminated string")
+		}
+		c := p.peek()
+		switch c {
+		case '"':
+			p.pos++
+			return b.String(), nil
+		case '\\':
+			if err := p.parseEscape(&b); err != nil {
+				return "", err
+			}
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+// parseMultilineString parses basic and literal multiline strings, the newline after opening delimiter is trimmed and
+// line ending backslash of basic strings trims the following whitespace and newlines.
+func (p *parser) parseMultilineString(delimiter string) (string, error) {
+	p.pos += len(delimiter)
+	p.skipNewline()
+	var b strings.Builder
+	for {
+		if p.eof() {
+			return "", p.errorf("unterminated string")
+		}
+		if strings.HasPrefix(p.src[p.pos:], delimiter) {
+			p.pos += len(delimiter)
+			// up to two quotes are allowed right before the closing delimiter
+			for i := 0; i < 2 && p.peek() == delimiter[0]; i++ {
+				b.WriteByte(delimiter[0])
+				p.pos++
+			}
+			return b.String(), nil
+		}
+		c := p.peek()
+		if c != '\\' || delimiter == `'''` {
+			b.WriteByte(c)
+			p.pos++
+			continue
+		}
+		rest := strings.TrimLeft(p.src[p.pos+1:], " \t")
+		if strings.HasPrefix(rest, "\n") || strings.HasPrefix(rest, "\r\n") {
+			p.pos = len(p.src) - len(strings.TrimLeft(rest, " \t\r\n"))
+			continue
+		}
+		if err := p.parseEscape(&b); err != nil {
+			return "", err
+		}
+	}
+}
+
+func (p *parser) parseEscape(b *strings.Builder) error {
+	p.pos++
+	c := p.peek()
+	p.pos++
+	switch c {
+	case 'b':
+		b.WriteByte('\b')
+	case 't':
+		b.WriteByte('\t')
+	case 'n':
+		b.WriteByte('\n')
+	case 'f':
+		b.WriteByte('\f')
+	case 'r':
+		b.WriteByte('\r')
+	case '"':
+		b.WriteByte('"')
+	case '\\':
+		b.WriteByte('\\')
+	case 'u', 'U':
+		size := 4
+		if c == 'U' {
+			size = 8
+		}
+		if p.pos+size > len(p.src) {
+			return p.errorf("invalid unicode escape")
+		}
+		code, err := strconv.ParseUint(p.src[p.pos:p.pos+size], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(code)) {
+			return p.errorf("invalid unicode escape \\%c%s", c, p.src[p.pos:p.pos+size])
+		}
+		b.WriteRune(rune(code))
+		p.pos += size
+	default:
+		return p.errorf("invalid escape sequence \\%c", c)
+	}
+	return nil
+}
+
+func isScalarChar(c byte) bool {
+	return isBareKeyChar(c) || c == '+' || c == '.' || c == ':'
+}
+
+// parseScalar parses numbers and datetimes, datetimes may contain space between date and time.
+func (p *parser) parseScalar() (any, error) {
+	start := p.pos
+	for !p.eof() && isScalarChar(p.peek()) {
+		p.pos++
+	}
+	token := p.src[start:p.pos]
+	if len(token) == 10 && token[4] == '-' && token[7] == '-' &&
+		p.pos+1 < len(p.src) && p.peek() == ' ' && p.src[p.pos+1] >= '0' && p.src[p.pos+1] <= '9' {
+		p.pos++
+		for !p.eof() && isScalarChar(p.peek()) {
+			p.pos++
+		}
+		token = p.src[start:p.pos]
+	}
+	if token == "" {
+		return nil, p.errorf("expected a value, got %q", p.peek())
+	}
+	if val, ok := parseDatetime(token); ok {
+		return val, nil
+	}
+	if val, ok := parseFloatSpecial(token); ok {
+		return val, nil
+	}
+	number := strings.ReplaceAll(token, "_", "")
+	if strings.Contains(token, "__") || strings.HasPrefix(token, "_") || strings.HasSuffix(token, "_") {
+		return nil, p.errorf("invalid number %s", token)
+	}
+	if len(number) > 2 && number[0] == '0' && strings.ContainsRune("xob", rune(number[1])) {
+		base := map[byte]int{'x': 16, 'o': 8, 'b': 2}[number[1]]
+		val, err := strconv.ParseInt(number[2:], base, 64)
+		if err != nil {
+			return nil, p.errorf("invalid integer %s", token)
+		}
+		return val, nil
+	}
+	digits := strings.TrimLeft(number, "+-")
+	if len(digits) > 1 && digits[0] == '0' && digits[1] != '.' && digits[1] != 'e' && digits[1] != 'E' {
+		return nil, p.errorf("leading zeros are not allowed in %s", token)
+	}
+	if strings.ContainsAny(number, ".eE") {
+		val, err := strconv.ParseFloat(number, 64)
+		if err != nil {
+			return nil, p.errorf("invalid float %s", token)
+		}
+		return val, nil
+	}
+	val, err := strconv.ParseInt(number, 10, 64)
+	if err != nil {
+		return nil, p.errorf("invalid value %s", token)
+	}
+	return val, nil
+}
+
+func parseFloatSpecial(token string) (float64, bool) {
+	switch strings.TrimLeft(token, "+-") {
+	case "inf":
+		if strings.HasPrefix(token, "-") {
+			return math.Inf(-1), true
+		}
+		return math.Inf(1), true
+	case "nan":
+		return math.NaN(), true
+	}
+	return 0, false
+}
+
+func parseDatetime(token string) (any, bool) {
+	if _, err := time.Parse("15:04:05.999999999", token); err == nil {
+		// local time has no date, it is kept as written
+		return token, true
+	}
+	if len(token) < 10 || token[4] != '-' || token[7] != '-' {
+		return nil, false
+	}
+	value := strings.ToUpper(token)
+	if len(value) > 10 && (value[10] == ' ' || value[10] == 'T') {
+		value = value[:10] + "T" + value[11:]
+	}
+	if val, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return val, true
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05.999999999", "2006-01-02"} {
+		if val, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return localDatetime{time: val, text: token}, true
+		}
+	}
+	return nil, false
+}
+
+// localDatetime is the local datetime or date without offset, text is the value as written, i.e. "1979-05-27",
+// time is the value in the local time zone.
+type localDatetime struct {
+	time time.Time
+	text string
+}
+
+func (d localDatetime) String() string {
+	return d.text
+}
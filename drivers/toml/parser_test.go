@@ -0,0 +1,197 @@
+package toml
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parse(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want map[string]any
+	}{
+		{
+			name: "key values",
+			src: `# comment
+name = "app" # trailing comment
+port = 8_080
+hex = 0xff
+oct = 0o17
+bin = 0b101
+neg = -3
+ratio = 0.75
+exp = 5e+3
+enabled = true
+disabled = false
+"quoted key" = 1
+'literal key' = 2
+`,
+			want: map[string]any{
+				"name": "app", "port": int64(8080), "hex": int64(255), "oct": int64(15), "bin": int64(5),
+				"neg": int64(-3), "ratio": 0.75, "exp": 5000.0, "enabled": true, "disabled": false,
+				"quoted key": int64(1), "literal key": int64(2),
+			},
+		},
+		{
+			name: "strings",
+			src: `basic = "tab\tquote\" slash\\ \u00e9 \U0001F600"
+literal = 'C:\path'
+multiline = """
+first
+second"""
+trimmed = """one \
+    two"""
+raw = '''
+line \n'''
+quotes = """a""""
+`,
+			want: map[string]any{
+				"basic":     "tab\tquote\" slash\\ é 😀",
+				"literal":   `C:\path`,
+				"multiline": "first\nsecond",
+				"trimmed":   "one two",
+				"raw":       `line \n`,
+				"quotes":    `a"`,
+			},
+		},
+		{
+			name: "tables and dotted keys",
+			src: `http.port = 80
+[db]
+host = "localhost"
+pool.size = 10
+
+[db.replica]
+host = "replica"
+
+[a."b.c"]
+d = 1
+`,
+			want: map[string]any{
+				"http": map[string]any{"port": int64(80)},
+				"db": map[string]any{
+					"host":    "localhost",
+					"pool":    map[string]any{"size": int64(10)},
+					"replica": map[string]any{"host": "replica"},
+				},
+				"a": map[string]any{"b.c": map[string]any{"d": int64(1)}},
+			},
+		},
+		{
+			name: "arrays and inline tables",
+			src: `ports = [ 80, 443, ] # trailing comma
+nested = [[1, 2], ["a"]]
+multiline = [
+  "a", # comment
+  "b",
+]
+point = { x = 1, y.z = 2 }
+empty = {}
+`,
+			want: map[string]any{
+				"ports":     []any{int64(80), int64(443)},
+				"nested":    []any{[]any{int64(1), int64(2)}, []any{"a"}},
+				"multiline": []any{"a", "b"},
+				"point":     map[string]any{"x": int64(1), "y": map[string]any{"z": int64(2)}},
+				"empty":     map[string]any{},
+			},
+		},
+		{
+			name: "arrays of tables",
+			src: `[[servers]]
+host = "a"
+[servers.tls]
+enabled = true
+
+[[servers]]
+host = "b"
+[servers.tls]
+enabled = false
+`,
+			want: map[string]any{
+				"servers": []any{
+					map[string]any{"host": "a", "tls": map[string]any{"enabled": true}},
+					map[string]any{"host": "b", "tls": map[string]any{"enabled": false}},
+				},
+			},
+		},
+		{
+			name: "datetimes",
+			src: `offset = 1979-05-27T07:32:00Z
+space = 1979-05-27 07:32:00.5-07:00
+local = 1979-05-27T07:32:00
+date = 1979-05-27
+time = 07:32:00
+`,
+			want: map[string]any{
+				"offset": time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC),
+				"space":  time.Date(1979, 5, 27, 7, 32, 0, 500000000, time.FixedZone("", -7*60*60)),
+				"local":  localDatetime{time: time.Date(1979, 5, 27, 7, 32, 0, 0, time.Local), text: "1979-05-27T07:32:00"},
+				"date":   localDatetime{time: time.Date(1979, 5, 27, 0, 0, 0, 0, time.Local), text: "1979-05-27"},
+				"time":   "07:32:00",
+			},
+		},
+		{
+			name: "crlf",
+			src:  "a = 1\r\n[b]\r\nc = 2\r\n",
+			want: map[string]any{"a": int64(1), "b": map[string]any{"c": int64(2)}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parse(tt.src)
+			assert.NoError(t, err)
+			for key, want := range tt.want {
+				if wantTime, ok := want.(time.Time); ok {
+					gotTime, isTime := got[key].(time.Time)
+					assert.True(t, isTime, key)
+					assert.True(t, wantTime.Equal(gotTime), "%s: %v != %v", key, wantTime, gotTime)
+					continue
+				}
+				assert.Equal(t, want, got[key], key)
+			}
+			assert.Len(t, got, len(tt.want))
+		})
+	}
+}
+
+func Test_parseSpecialFloats(t *testing.T) {
+	got, err := parse("a = inf\nb = -inf\nc = nan\n")
+	assert.NoError(t, err)
+	assert.Equal(t, math.Inf(1), got["a"])
+	assert.Equal(t, math.Inf(-1), got["b"])
+	assert.True(t, math.IsNaN(got["c"].(float64)))
+}
+
+func Test_parseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{name: "duplicate key", src: "a = 1\na = 2", want: "line 2: key a is already defined"},
+		{name: "duplicate table", src: "[a]\n[a]", want: "line 2: table a is already defined"},
+		{name: "table over value", src: "a = 1\n[a]", want: "a is already defined as a value"},
+		{name: "extend inline table", src: "a = {b = 1}\n[a.c]", want: "a can't be extended"},
+		{name: "extend inline by dotted key", src: "a = {b = 1}\na.c = 2", want: "a can't be extended"},
+		{name: "missing value", src: "a =\n", want: "line 1: expected a value"},
+		{name: "missing equals", src: "a 1", want: "expected '=' after the key a"},
+		{name: "unterminated string", src: `a = "b`, want: "unterminated string"},
+		{name: "unterminated array", src: "a = [1,", want: "unterminated array"},
+		{name: "invalid escape", src: `a = "\q"`, want: `invalid escape sequence \q`},
+		{name: "leading zeros", src: "a = 012", want: "leading zeros are not allowed"},
+		{name: "invalid underscore", src: "a = 1__0", want: "invalid number 1__0"},
+		{name: "garbage after value", src: "a = 1 b", want: "expected the end of line"},
+		{name: "invalid table name", src: "[a", want: `expected "]" after the table name`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parse(tt.src)
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
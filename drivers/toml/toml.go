@@ -0,0 +1,329 @@
+package toml
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/insei/fmap/v3"
+
+	"github.com/insei/tinyconf"
+	"github.com/insei/tinyconf/drivers/mapdriver"
+	"github.com/insei/tinyconf/slices118"
+)
+
+type tomlDriver struct {
+	mapdriver.Driver
+	cache *mapdriver.FileCache[map[string]any]
+}
+
+func decode(data []byte) (map[string]any, error) {
+	tomlMap, err := parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode toml: %w", err)
+	}
+	return tomlMap, nil
+}
+
+var typeOfTime = reflect.TypeOf(time.Time{})
+
+// isTimeType reports whether the type is time.Time or a pointer to it.
+func isTimeType(typeOf reflect.Type) bool {
+	for typeOf.Kind() == reflect.Ptr {
+		typeOf = typeOf.Elem()
+	}
+	return typeOf == typeOfTime
+}
+
+// convertValToType converts toml value to the field type. Offset datetimes are converted to RFC 3339 strings
+// for fields of other types than time.Time, i.e. *time.Time or string. Local datetimes and dates are set to
+// time fields in the local time zone and are kept as written for other fields, i.e. "1979-05-27".
+func convertValToType(field fmap.Field, val any) (any, error) {
+	switch datetime := val.(type) {
+	case time.Time:
+		if field.GetType() != typeOfTime {
+			val = datetime.Format(time.RFC3339Nano)
+		}
+	case localDatetime:
+		switch {
+		case field.GetType() == typeOfTime:
+			val = datetime.time
+		case isTimeType(field.GetType()):
+			val = datetime.time.Format(time.RFC3339Nano)
+		default:
+			val = datetime.text
+		}
+	}
+	return mapdriver.ConvertValue(field, val)
+}
+
+func (d *tomlDriver) GetValue(field fmap.Field) (*tinyconf.Value, error) {
+	tomlMap, err := d.cache.Load()
+	if err != nil {
+		return nil, err
+	}
+	val, err := mapdriver.GetValue(d.Name, field, tomlMap)
+	if err != nil {
+		return nil, err
+	}
+	val, err = convertValToType(field, val)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert toml map value to field type value: %w", err)
+	}
+	return &tinyconf.Value{
+		Source: d.Name,
+		Value:  val,
+	}, nil
+}
+
+// node is the table of the generated toml document.
+type node struct {
+	keys     []string
+	children map[string]*node
+	doc      string
+	// value is the toml of the value field, it is empty for tables and unset values
+	value string
+	table bool
+	// list is the []T section, its child is the item template
+	list bool
+}
+
+func (n *node) child(key string) *node {
+	if n.children == nil {
+		n.children = map[string]*node{}
+	}
+	child, ok := n.children[key]
+	if !ok {
+		child = &node{}
+		n.children[key] = child
+		n.keys = append(n.keys, key)
+	}
+	return child
+}
+
+func (n *node) hasOnlyTables() bool {
+	for _, child := range n.children {
+		if !child.table {
+			return false
+		}
+	}
+	return len(n.keys) > 0
+}
+
+func writeDoc(b *strings.Builder, doc string) {
+	for _, line := range strings.Split(doc, "\n") {
+		if line != "" {
+			b.WriteString("# " + line + "\n")
+		}
+	}
+}
+
+// write writes values of the table and then its sub tables, as toml requires.
+func (n *node) write(b *strings.Builder, path []string) {
+	for _, key := range n.keys {
+		child := n.children[key]
+		if child.table {
+			continue
+		}
+		writeDoc(b, child.doc)
+		if child.value == "" {
+			b.WriteString("# " + formatKey(key) + " =\n")
+			continue
+		}
+		b.WriteString(formatKey(key) + " = " + child.value + "\n")
+	}
+	for _, key := range n.keys {
+		child := n.children[key]
+		if !child.table {
+			continue
+		}
+		childPath := append(slices118.Clone(path), formatKey(key))
+		if !child.list && child.hasOnlyTables() {
+			// the header of the table without values is omitted, its doc is written before the first sub table
+			first := child.children[child.keys[0]]
+			if child.doc != first.doc {
+				first.doc = strings.TrimSpace(child.doc + "\n" + first.doc)
+			}
+			child.write(b, childPath)
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteRune('\n')
+		}
+		writeDoc(b, child.doc)
+		if !child.list {
+			b.WriteString("[" + strings.Join(childPath, ".") + "]\n")
+			child.write(b, childPath)
+			continue
+		}
+		for _, itemKey := range child.keys {
+			b.WriteString("[[" + strings.Join(childPath, ".") + "]]\n")
+			child.children[itemKey].write(b, childPath)
+		}
+	}
+}
+
+func isBareKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if !isBareKeyChar(key[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func formatKey(key string) string {
+	if isBareKey(key) {
+		return key
+	}
+	return quote(key)
+}
+
+// quote returns toml basic string, control characters are escaped by \u sequences.
+func quote(s string) string {
+	var b strings.Builder
+	b.WriteRune('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				b.WriteString(fmt.Sprintf(`\u%04X`, r))
+				continue
+			}
+			b.WriteRune(r)
+		}
+	}
+	b.WriteRune('"')
+	return b.String()
+}
+
+// formatValue returns the toml of the value, it returns empty string for nil values, that can't be written in toml.
+func formatValue(field fmap.Field, val any) string {
+	valOf := reflect.ValueOf(val)
+	for valOf.Kind() == reflect.Ptr {
+		if valOf.IsNil() {
+			return ""
+		}
+		valOf = valOf.Elem()
+	}
+	if !valOf.IsValid() {
+		return ""
+	}
+	if _, hasEncoding := field.GetTag().Lookup("encoding"); hasEncoding {
+		return quote(tinyconf.FormatFieldValue(field, val))
+	}
+	return formatReflectValue(field, valOf)
+}
+
+func formatReflectValue(field fmap.Field, valOf reflect.Value) string {
+	if valOf.Type() == typeOfTime {
+		return valOf.Interface().(time.Time).Format(time.RFC3339Nano)
+	}
+	formatted := tinyconf.FormatFieldValue(field, valOf.Interface())
+	switch valOf.Kind() {
+	case reflect.Bool:
+		return formatted
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		// unit-aware types, i.e. durations and byte sizes, are written as strings
+		if _, err := strconv.ParseInt(formatted, 10, 64); err == nil {
+			return formatted
+		}
+	case reflect.Float32, reflect.Float64:
+		float := valOf.Float()
+		if _, err := strconv.ParseFloat(formatted, 64); err != nil || math.IsInf(float, 0) || math.IsNaN(float) {
+			break
+		}
+		if !strings.ContainsAny(formatted, ".eE") {
+			formatted += ".0"
+		}
+		return formatted
+	case reflect.Slice:
+		if valOf.Type().Elem().Kind() == reflect.Uint8 {
+			break
+		}
+		items := make([]string, 0, valOf.Len())
+		for i := 0; i < valOf.Len(); i++ {
+			items = append(items, formatReflectValue(field, reflect.ValueOf(valOf.Index(i).Interface())))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case reflect.Map:
+		if valOf.Type().Key().Kind() != reflect.String {
+			break
+		}
+		keys := make([]string, 0, valOf.Len())
+		for _, key := range valOf.MapKeys() {
+			keys = append(keys, key.String())
+		}
+		sort.Strings(keys)
+		items := make([]string, 0, len(keys))
+		for _, key := range keys {
+			item := reflect.ValueOf(valOf.MapIndex(reflect.ValueOf(key).Convert(valOf.Type().Key())).Interface())
+			items = append(items, formatKey(key)+" = "+formatReflectValue(field, item))
+		}
+		return "{" + strings.Join(items, ", ") + "}"
+	}
+	return quote(formatted)
+}
+
+// GenDoc returns the toml document with default values, values are documented by `doc` tag comments,
+// maps entries are documented by the placeholder key and slices by the single item.
+func (d *tomlDriver) GenDoc(registers ...*tinyconf.Registered) string {
+	root := &node{table: true}
+	for _, register := range registers {
+		for _, configField := range register.GetFields() {
+			fld := configField.Field
+			path := fld.GetTagPath(d.Name, false)
+			if path == "" || slices118.Contains(strings.Split(path, "."), "-") {
+				continue
+			}
+			keys := strings.Split(path, ".")
+			parent := root
+			for _, key := range keys[:len(keys)-1] {
+				parent = parent.child(key)
+				parent.table = true
+			}
+			if _, ok := parent.children[keys[len(keys)-1]]; ok {
+				continue
+			}
+			if tinyconf.IsSliceEntry(fld) {
+				parent.list = true
+			}
+			member := parent.child(keys[len(keys)-1])
+			member.doc = fld.GetTag().Get("doc")
+			member.table = tinyconf.IsSection(fld)
+			if !member.table {
+				member.value = formatValue(fld, configField.Value)
+			}
+		}
+	}
+	var b strings.Builder
+	root.write(&b, nil)
+	return b.String()
+}
+
+// New returns the driver that reads values from the toml file by `toml` tag paths, i.e. `toml:"port"` field
+// of `toml:"http"` section is read from port key of [http] table or http.port dotted key. The file is read again
+// when it is changed, see mapdriver.FileCache.
+func New(file string) (tinyconf.Driver, error) {
+	d := &tomlDriver{cache: &mapdriver.FileCache[map[string]any]{Path: file, Decode: decode}}
+	d.Driver = mapdriver.Driver{Name: "toml", Load: d.cache.Load}
+	return d, nil
+}
@@ -0,0 +1,232 @@
+package toml
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/insei/fmap/v3"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/insei/tinyconf"
+	"github.com/insei/tinyconf/drivers/internal/drivertest"
+)
+
+type Server struct {
+	Host string `toml:"host" doc:"server host"`
+	Port int    `toml:"port"`
+}
+
+type Config struct {
+	Name      string            `toml:"name" doc:"application name"`
+	Workers   uint8             `toml:"workers"`
+	Ratio     float64           `toml:"ratio"`
+	Timeout   time.Duration     `toml:"timeout"`
+	Started   time.Time         `toml:"started"`
+	Deadline  *time.Time        `toml:"deadline"`
+	Birthday  string            `toml:"birthday"`
+	Tags      []string          `toml:"tags"`
+	Labels    map[string]string `toml:"labels"`
+	Skipped   string            `toml:"-"`
+	HTTP      HTTP              `toml:"http" doc:"http server"`
+	Servers   []Server          `toml:"servers" doc:"upstream servers"`
+	Databases map[string]Server `toml:"db" key:"name" doc:"databases by name"`
+}
+
+type HTTP struct {
+	Port int `toml:"port"`
+	Auth struct {
+		Issuer string `toml:"issuer"`
+	} `toml:"auth"`
+}
+
+func TestTomlDriver_GetName(t *testing.T) {
+	d, _ := New("config.toml")
+	assert.Equal(t, "toml", d.GetName())
+}
+
+func TestTomlDriver_GetValue(t *testing.T) {
+	file := drivertest.WriteFile(t, "config.toml", `name = "app"
+workers = 4
+ratio = 1
+timeout = "1m30s"
+started = 2024-01-02T03:04:05Z
+deadline = 2024-01-02T03:04:05+02:00
+birthday = 1979-05-27T07:32:00Z
+http.auth.issuer = "me"
+
+[http]
+port = 8080
+
+[[servers]]
+host = "a.local"
+`)
+	d, _ := New(file)
+	storage, _ := fmap.Get[Config]()
+	deadline := time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("", 2*60*60))
+	drivertest.GetValue(t, d, storage, []drivertest.Case{
+		{Path: "Name", Want: &tinyconf.Value{Value: "app"}},
+		{Path: "Workers", Want: &tinyconf.Value{Value: uint8(4)}},
+		{Path: "Ratio", Want: &tinyconf.Value{Value: 1.0}},
+		{Path: "Timeout", Want: &tinyconf.Value{Value: 90 * time.Second}},
+		{Path: "Started", Want: &tinyconf.Value{Value: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}},
+		{Path: "Birthday", Want: &tinyconf.Value{Value: "1979-05-27T07:32:00Z"}},
+		{Path: "HTTP.Port", Want: &tinyconf.Value{Value: 8080}},
+		{Path: "HTTP.Auth.Issuer", Want: &tinyconf.Value{Value: "me"}},
+		{Path: "Tags", WantErr: tinyconf.ErrValueNotFound},
+	})
+
+	val, err := d.GetValue(storage.MustFind("Deadline"))
+	assert.NoError(t, err)
+	assert.True(t, deadline.Equal(*val.Value.(*time.Time)))
+
+	drivertest.GetKeys(t, d, storage.MustFind("Servers"), []string{"0"})
+}
+
+func TestTomlDriver_LocalDatetimes(t *testing.T) {
+	type Config struct {
+		Date     string     `toml:"date"`
+		Local    string     `toml:"local"`
+		Clock    string     `toml:"clock"`
+		Offset   string     `toml:"offset"`
+		Started  time.Time  `toml:"started"`
+		Deadline *time.Time `toml:"deadline"`
+		Holidays []string   `toml:"holidays"`
+	}
+	d, _ := New(drivertest.WriteFile(t, "config.toml", `date = 1979-05-27
+local = 1979-05-27 07:32:00
+clock = 07:32:00
+offset = 1979-05-27T07:32:00+02:00
+started = 1979-05-27T07:32:00
+deadline = 1979-05-27
+holidays = [1979-01-01, 1979-12-25]
+`))
+	conf := &Config{}
+	drivertest.Parse(t, d, conf)
+	assert.Equal(t, "1979-05-27", conf.Date)
+	assert.Equal(t, "1979-05-27 07:32:00", conf.Local)
+	assert.Equal(t, "07:32:00", conf.Clock)
+	assert.Equal(t, "1979-05-27T07:32:00+02:00", conf.Offset)
+	assert.Equal(t, time.Date(1979, 5, 27, 7, 32, 0, 0, time.Local), conf.Started)
+	if assert.NotNil(t, conf.Deadline) {
+		assert.True(t, time.Date(1979, 5, 27, 0, 0, 0, 0, time.Local).Equal(*conf.Deadline))
+	}
+	assert.Equal(t, []string{"1979-01-01", "1979-12-25"}, conf.Holidays)
+}
+
+func TestTomlDriver_ParseScalarArrays(t *testing.T) {
+	type Config struct {
+		Tags   []string          `toml:"tags"`
+		Ports  []int             `toml:"ports"`
+		Labels map[string]string `toml:"labels"`
+	}
+	d, _ := New(drivertest.WriteFile(t, "config.toml", `tags = ["a", "b"]
+ports = [80, 443]
+labels = { team = "core" }
+`))
+	conf := &Config{}
+	drivertest.Parse(t, d, conf)
+	assert.Equal(t, &Config{Tags: []string{"a", "b"}, Ports: []int{80, 443}, Labels: map[string]string{"team": "core"}}, conf)
+}
+
+func TestTomlDriver_Errors(t *testing.T) {
+	storage, _ := fmap.Get[Config]()
+	d, _ := New(drivertest.WriteFile(t, "config.toml", "name = "))
+	_, err := d.GetValue(storage.MustFind("Name"))
+	assert.ErrorContains(t, err, "failed to decode toml: toml: line 1: expected a value")
+
+	d, _ = New(drivertest.WriteFile(t, "config.toml", "workers = 300"))
+	_, err = d.GetValue(storage.MustFind("Workers"))
+	assert.ErrorContains(t, err, "failed to convert toml map value")
+}
+
+func TestTomlDriver_Parse(t *testing.T) {
+	file := drivertest.WriteFile(t, "config.toml", `[[servers]]
+host = "a.local"
+
+[[servers]]
+host = "b.local"
+port = 81
+
+[db.main]
+host = "main.local"
+port = 5432
+`)
+	d, _ := New(file)
+	conf := &Config{}
+	drivertest.Parse(t, d, conf)
+	assert.Equal(t, []Server{{Host: "a.local"}, {Host: "b.local", Port: 81}}, conf.Servers)
+	assert.Equal(t, map[string]Server{"main": {Host: "main.local", Port: 5432}}, conf.Databases)
+}
+
+func TestTomlDriver_ReadsFileOncePerParse(t *testing.T) {
+	file := drivertest.WriteFile(t, "config.toml", "name = \"app\"\ntags = [\"a\"]\n\n[http]\nport = 80\n")
+	d, _ := New(file)
+	reads := 0
+	cache := d.(*tomlDriver).cache
+	cache.Decode = func(data []byte) (map[string]any, error) {
+		reads++
+		return decode(data)
+	}
+	conf := &Config{}
+	m := drivertest.Parse(t, d, conf)
+	assert.Equal(t, 1, reads)
+	assert.NoError(t, m.Parse(conf))
+	assert.Equal(t, 1, reads)
+
+	assert.NoError(t, os.WriteFile(file, []byte("name = \"changed\"\n"), 0o600))
+	assert.NoError(t, m.Parse(conf))
+	assert.Equal(t, 2, reads)
+	assert.Equal(t, "changed", conf.Name)
+}
+
+func TestTomlDriver_GenDoc(t *testing.T) {
+	d, _ := New("config.toml")
+	storage, _ := fmap.Get[Config]()
+	conf := &Config{
+		Name:    "app \"main\"",
+		Workers: 4,
+		Ratio:   1,
+		Timeout: time.Minute,
+		Started: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Tags:    []string{"a", "b"},
+		Labels:  map[string]string{"team": "core", "env.name": "prod"},
+		HTTP:    HTTP{Port: 8080},
+	}
+	doc := d.GenDoc(&tinyconf.Registered{Storage: storage, Config: conf})
+	assert.Equal(t, `# application name
+name = "app \"main\""
+workers = 4
+ratio = 1.0
+timeout = "1m0s"
+started = 2024-01-02T03:04:05Z
+# deadline =
+birthday = ""
+tags = ["a", "b"]
+labels = {"env.name" = "prod", team = "core"}
+
+# http server
+[http]
+port = 8080
+
+[http.auth]
+issuer = ""
+
+# upstream servers
+[[servers]]
+# server host
+host = ""
+port = 0
+
+# databases by name
+[db."<name>"]
+# server host
+host = ""
+port = 0
+`, doc)
+
+	// generated document is valid toml
+	tomlMap, err := parse(doc)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(8080), tomlMap["http"].(map[string]any)["port"])
+}
@@ -0,0 +1,91 @@
+package vault
+
+import (
+	"net/http"
+	"time"
+)
+
+type Option interface {
+	apply(*vaultDriver)
+}
+
+type tokenOption struct {
+	token string
+}
+
+func (o tokenOption) apply(d *vaultDriver) {
+	d.token = o.token
+}
+
+// WithToken sets the token of requests, it is renewed before expiry if it is renewable.
+func WithToken(token string) Option {
+	return tokenOption{token: token}
+}
+
+type appRoleOption struct {
+	roleID   string
+	secretID string
+}
+
+func (o appRoleOption) apply(d *vaultDriver) {
+	d.roleID, d.secretID = o.roleID, o.secretID
+}
+
+// WithAppRole enables the login by AppRole auth method mounted at auth/approle, the token of the login is renewed
+// before expiry and the driver logs in again when the token can't be renewed.
+func WithAppRole(roleID, secretID string) Option {
+	return appRoleOption{roleID: roleID, secretID: secretID}
+}
+
+type mountOption struct {
+	mount string
+}
+
+func (o mountOption) apply(d *vaultDriver) {
+	d.mount = o.mount
+}
+
+// WithMount sets the path of KV v2 secrets engine, the default is secret.
+func WithMount(mount string) Option {
+	return mountOption{mount: mount}
+}
+
+type namespaceOption struct {
+	namespace string
+}
+
+func (o namespaceOption) apply(d *vaultDriver) {
+	d.namespace = o.namespace
+}
+
+// WithNamespace sets the namespace of requests, it is sent in X-Vault-Namespace header.
+func WithNamespace(namespace string) Option {
+	return namespaceOption{namespace: namespace}
+}
+
+type refreshOption struct {
+	refresh time.Duration
+}
+
+func (o refreshOption) apply(d *vaultDriver) {
+	d.refresh = o.refresh
+}
+
+// WithRefresh sets the interval of secrets reads, secrets with leases are read again before the lease expiry.
+// The default is 5m.
+func WithRefresh(refresh time.Duration) Option {
+	return refreshOption{refresh: refresh}
+}
+
+type clientOption struct {
+	client *http.Client
+}
+
+func (o clientOption) apply(d *vaultDriver) {
+	d.client = o.client
+}
+
+// WithClient sets the http client of requests, i.e. with custom TLS settings, the default client has 10s timeout.
+func WithClient(client *http.Client) Option {
+	return clientOption{client: client}
+}
@@ -0,0 +1,465 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/insei/fmap/v3"
+
+	"github.com/insei/tinyconf"
+	"github.com/insei/tinyconf/slices118"
+	"github.com/insei/tinyconf/strings118"
+)
+
+// apiError is the error response of Vault API.
+type apiError struct {
+	code   int
+	status string
+	errors []string
+}
+
+func (e *apiError) Error() string {
+	if len(e.errors) == 0 {
+		return "vault: " + e.status
+	}
+	return "vault: " + e.status + ": " + strings.Join(e.errors, ", ")
+}
+
+// secret is the cached secret of the path, it is read again at refreshAt and is valid until expiresAt if it is leased.
+type secret struct {
+	data      map[string]any
+	refreshAt time.Time
+	expiresAt time.Time
+}
+
+type vaultDriver struct {
+	name      string
+	addr      string
+	mount     string
+	namespace string
+	roleID    string
+	secretID  string
+	refresh   time.Duration
+	client    *http.Client
+	now       func() time.Time
+
+	// authMu serializes token renewals and logins, mu guards the token and cached secrets, it isn't held
+	// during requests
+	authMu sync.Mutex
+	mu     sync.Mutex
+	token  string
+	// renewable and renewAt are the token renewal settings, the token without TTL is never renewed
+	renewable bool
+	renewAt   time.Time
+	secrets   map[string]*secret
+}
+
+// authResponse is the auth part of the login and the token renewal responses.
+type authResponse struct {
+	Auth struct {
+		ClientToken   string `json:"client_token"`
+		LeaseDuration int64  `json:"lease_duration"`
+		Renewable     bool   `json:"renewable"`
+	} `json:"auth"`
+}
+
+// do sends the request with the token to Vault API and decodes the response to out.
+func (d *vaultDriver) do(ctx context.Context, token, method, path string, body, out any) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, d.addr+"/v1/"+path, reqBody)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if d.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", d.namespace)
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("vault: %s", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := &apiError{code: resp.StatusCode, status: resp.Status}
+		var errResp struct {
+			Errors []string `json:"errors"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&errResp) == nil {
+			apiErr.errors = errResp.Errors
+		}
+		return apiErr
+	}
+	decoder := json.NewDecoder(resp.Body)
+	// numbers are kept as written, i.e. large integers are not converted to float64
+	decoder.UseNumber()
+	if err = decoder.Decode(out); err != nil {
+		return fmt.Errorf("vault: failed to decode response: %s", err)
+	}
+	return nil
+}
+
+// renewAfter returns the time of the renewal of the lease, it is renewed after 2/3 of the duration.
+func (d *vaultDriver) renewAfter(seconds int64) time.Time {
+	if seconds <= 0 {
+		return time.Time{}
+	}
+	return d.now().Add(time.Duration(seconds) * time.Second * 2 / 3)
+}
+
+// setAuth sets the token of the login or the renewal response and returns it.
+func (d *vaultDriver) setAuth(auth authResponse) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.token = auth.Auth.ClientToken
+	d.renewable = auth.Auth.Renewable
+	d.renewAt = d.renewAfter(auth.Auth.LeaseDuration)
+	return d.token
+}
+
+// login logs in by AppRole auth method and returns the new token.
+func (d *vaultDriver) login(ctx context.Context) (string, error) {
+	var auth authResponse
+	body := map[string]string{"role_id": d.roleID, "secret_id": d.secretID}
+	if err := d.do(ctx, "", http.MethodPost, "auth/approle/login", body, &auth); err != nil {
+		return "", fmt.Errorf("failed to login by approle: %w", err)
+	}
+	return d.setAuth(auth), nil
+}
+
+// lookupToken reads TTL of the token.
+func (d *vaultDriver) lookupToken(ctx context.Context) error {
+	var lookup struct {
+		Data struct {
+			TTL       int64 `json:"ttl"`
+			Renewable bool  `json:"renewable"`
+		} `json:"data"`
+	}
+	d.mu.Lock()
+	token := d.token
+	d.mu.Unlock()
+	if err := d.do(ctx, token, http.MethodGet, "auth/token/lookup-self", nil, &lookup); err != nil {
+		return fmt.Errorf("failed to lookup token: %w", err)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.renewable = lookup.Data.Renewable
+	d.renewAt = d.renewAfter(lookup.Data.TTL)
+	return nil
+}
+
+func (d *vaultDriver) isAppRole() bool {
+	return d.roleID != ""
+}
+
+// ensureToken returns the token, it is renewed if it is due and the driver logs in again by AppRole if the token
+// can't be renewed.
+func (d *vaultDriver) ensureToken(ctx context.Context) (string, error) {
+	d.authMu.Lock()
+	defer d.authMu.Unlock()
+	d.mu.Lock()
+	token, renewable, renewAt := d.token, d.renewable, d.renewAt
+	d.mu.Unlock()
+	if renewAt.IsZero() || d.now().Before(renewAt) {
+		return token, nil
+	}
+	if renewable {
+		var auth authResponse
+		err := d.do(ctx, token, http.MethodPost, "auth/token/renew-self", map[string]string{}, &auth)
+		if err == nil {
+			return d.setAuth(auth), nil
+		}
+		if !d.isAppRole() {
+			return "", fmt.Errorf("failed to renew token: %w", err)
+		}
+	}
+	if d.isAppRole() {
+		return d.login(ctx)
+	}
+	// the token isn't renewable, it is used until expiry
+	d.mu.Lock()
+	d.renewAt = time.Time{}
+	d.mu.Unlock()
+	return token, nil
+}
+
+// readSecret reads the latest version of the secret of KV v2 secrets engine.
+func (d *vaultDriver) readSecret(ctx context.Context, token, path string) (*secret, error) {
+	var resp struct {
+		LeaseDuration int64 `json:"lease_duration"`
+		Data          struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	err := d.do(ctx, token, http.MethodGet, d.mount+"/data/"+path, nil, &resp)
+	var apiErr *apiError
+	if errors.As(err, &apiErr) && apiErr.code == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s secret is not found in %s", tinyconf.ErrValueNotFound, path, d.mount)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s secret: %w", path, err)
+	}
+	s := &secret{data: resp.Data.Data}
+	if s.data == nil {
+		// the latest version of the secret is deleted
+		return nil, fmt.Errorf("%w: %s secret is deleted in %s", tinyconf.ErrValueNotFound, path, d.mount)
+	}
+	if resp.LeaseDuration > 0 {
+		s.refreshAt = d.renewAfter(resp.LeaseDuration)
+		s.expiresAt = d.now().Add(time.Duration(resp.LeaseDuration) * time.Second)
+	} else {
+		s.refreshAt = d.now().Add(d.refresh)
+	}
+	return s, nil
+}
+
+// storeSecret caches the read secret, the cached secret is evicted if the secret is not found. It reports whether
+// the cached secret is changed.
+func (d *vaultDriver) storeSecret(path string, s *secret, err error) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	cached, ok := d.secrets[path]
+	switch {
+	case errors.Is(err, tinyconf.ErrValueNotFound):
+		delete(d.secrets, path)
+		return ok
+	case err != nil:
+		return false
+	}
+	d.secrets[path] = s
+	return !ok || !reflect.DeepEqual(cached.data, s.data)
+}
+
+// getSecret returns the cached secret or reads it if it is not cached or due to refresh. The cached secret is returned
+// if it can't be read and it isn't expired, it is evicted if the secret is not found.
+func (d *vaultDriver) getSecret(path string) (*secret, error) {
+	d.mu.Lock()
+	cached, ok := d.secrets[path]
+	d.mu.Unlock()
+	if ok && d.now().Before(cached.refreshAt) {
+		return cached, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	token, err := d.ensureToken(ctx)
+	var s *secret
+	if err == nil {
+		s, err = d.readSecret(ctx, token, path)
+	}
+	d.storeSecret(path, s, err)
+	if err != nil {
+		if ok && !errors.Is(err, tinyconf.ErrValueNotFound) &&
+			(cached.expiresAt.IsZero() || d.now().Before(cached.expiresAt)) {
+			return cached, nil
+		}
+		return nil, err
+	}
+	return s, nil
+}
+
+// Poll renews the token and reads secrets, if they are due, and reports whether values of secrets are changed,
+// see tinyconf.Watcher. Secrets that are not found anymore are evicted, all due secrets are read even if some
+// of them can't be read, errors of them are returned together.
+func (d *vaultDriver) Poll(ctx context.Context) (bool, error) {
+	token, err := d.ensureToken(ctx)
+	if err != nil {
+		return false, err
+	}
+	d.mu.Lock()
+	paths := make([]string, 0, len(d.secrets))
+	for path, cached := range d.secrets {
+		if !d.now().Before(cached.refreshAt) {
+			paths = append(paths, path)
+		}
+	}
+	d.mu.Unlock()
+	sort.Strings(paths)
+	var changed bool
+	var errs []error
+	for _, path := range paths {
+		s, err := d.readSecret(ctx, token, path)
+		if err != nil && !errors.Is(err, tinyconf.ErrValueNotFound) {
+			errs = append(errs, err)
+		}
+		changed = d.storeSecret(path, s, err) || changed
+	}
+	return changed, joinErrors(errs)
+}
+
+// joinErrors returns the first error extended by messages of others, nil is returned if there are no errors.
+func joinErrors(errs []error) error {
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+	messages := make([]string, 0, len(errs)-1)
+	for _, err := range errs[1:] {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Errorf("%w; %s", errs[0], strings.Join(messages, "; "))
+}
+
+// nextPoll returns the time of the next token renewal or secret refresh.
+func (d *vaultDriver) nextPoll() time.Time {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	next := d.now().Add(d.refresh)
+	if !d.renewAt.IsZero() && d.renewAt.Before(next) {
+		next = d.renewAt
+	}
+	for _, s := range d.secrets {
+		if s.refreshAt.Before(next) {
+			next = s.refreshAt
+		}
+	}
+	return next
+}
+
+// Watch polls until ctx is done, it waits for the next renewal of the token or refresh of secrets between polls,
+// see tinyconf.Watcher.
+func (d *vaultDriver) Watch(ctx context.Context, fn func(error)) {
+	tinyconf.WatchPolls(ctx, func() time.Duration { return time.Until(d.nextPoll()) }, d.Poll, fn)
+}
+
+// getPath returns the secret path and the key of `vault:"path#key"` tag, fields of maps and slices entries are not
+// supported, because the tag is the same for all entries.
+func (d *vaultDriver) getPath(field fmap.Field) (string, string, bool) {
+	if tinyconf.IsDynamicField(field) {
+		return "", "", false
+	}
+	path, key, ok := strings.Cut(field.GetTag().Get(d.name), "#")
+	path = strings.Trim(path, "/")
+	if !ok || path == "" || key == "" {
+		return "", "", false
+	}
+	return path, key, true
+}
+
+func (d *vaultDriver) GetValue(field fmap.Field) (*tinyconf.Value, error) {
+	path, key, ok := d.getPath(field)
+	if !ok {
+		return nil, fmt.Errorf("%w: vault tag path#key is not set for %s config field", tinyconf.ErrIncorrectTagSettings, field.GetStructPath())
+	}
+	s, err := d.getSecret(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w for %s config field", err, field.GetStructPath())
+	}
+	source := d.mount + "/" + path + "#" + key
+	secretVal, ok := s.data[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is not found in vault for %s config field", tinyconf.ErrValueNotFound, source, field.GetStructPath())
+	}
+	if secretVal == nil && !tinyconf.IsNullableField(field) {
+		return nil, fmt.Errorf("%w: %s is null in vault for %s config field", tinyconf.ErrValueNotFound, source, field.GetStructPath())
+	}
+	if secretVal == nil {
+		return nil, fmt.Errorf("%w: %s is null in vault for %s config field", tinyconf.ErrValueUnset, source, field.GetStructPath())
+	}
+	value, err := tinyconf.DecodeAnyField(field, secretVal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert %s value for %s config field: %w", source, field.GetStructPath(), err)
+	}
+	return &tinyconf.Value{Source: source, Value: value, Hidden: tinyconf.IsHiddenField(field, true)}, nil
+}
+
+func (d *vaultDriver) GetName() string {
+	return d.name
+}
+
+// GenDoc returns `vault kv put` commands of secrets of registered configs, values are empty unless fields
+// have `hidden:"false"` tag.
+func (d *vaultDriver) GenDoc(registers ...*tinyconf.Registered) string {
+	var paths []string
+	pairs := map[string][]string{}
+	docs := map[string][]string{}
+	for _, register := range registers {
+		for _, configField := range register.GetFields() {
+			fld := configField.Field
+			if !tinyconf.IsValueField(fld) {
+				continue
+			}
+			path, key, ok := d.getPath(fld)
+			if !ok {
+				continue
+			}
+			if !slices118.Contains(paths, path) {
+				paths = append(paths, path)
+			}
+			pair := key + "="
+			if !tinyconf.IsHiddenField(fld, true) {
+				pair += strings118.ShellQuote(tinyconf.FormatFieldValue(fld, configField.Value))
+			} else {
+				pair += "''"
+			}
+			if slices118.ContainsFunc(pairs[path], func(p string) bool { return strings.HasPrefix(p, key+"=") }) {
+				continue
+			}
+			pairs[path] = append(pairs[path], pair)
+			if fieldDoc := fld.GetTag().Get("doc"); fieldDoc != "" {
+				docs[path] = append(docs[path], "#"+key+": "+fieldDoc)
+			}
+		}
+	}
+	var doc string
+	for _, path := range paths {
+		for _, fieldDoc := range docs[path] {
+			doc += fieldDoc + "\n"
+		}
+		doc += fmt.Sprintf("vault kv put -mount=%s %s %s\n", d.mount, path, strings.Join(pairs[path], " "))
+	}
+	return doc
+}
+
+// New returns the driver that reads values of secrets of KV v2 secrets engine by `vault:"path#key"` tag,
+// i.e. `vault:"app/db#password"` field is read from password key of secret/data/app/db. Values are hidden in logs
+// unless the field has `hidden:"false"` tag. The token is checked or the driver logs in by AppRole in New.
+func New(addr string, opts ...Option) (tinyconf.Driver, error) {
+	d := &vaultDriver{
+		name:    "vault",
+		addr:    strings.TrimSuffix(addr, "/"),
+		mount:   "secret",
+		refresh: 5 * time.Minute,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		now:     time.Now,
+		secrets: map[string]*secret{},
+	}
+	for _, opt := range opts {
+		opt.apply(d)
+	}
+	d.mount = strings.Trim(d.mount, "/")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	switch {
+	case d.isAppRole():
+		if _, err := d.login(ctx); err != nil {
+			return nil, err
+		}
+	case d.token != "":
+		if err := d.lookupToken(ctx); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("vault token or approle credentials are not set")
+	}
+	return d, nil
+}
@@ -0,0 +1,407 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/insei/fmap/v3"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/insei/tinyconf"
+	"github.com/insei/tinyconf/drivers/internal/drivertest"
+)
+
+type Config struct {
+	User     string        `vault:"app/db#user" hidden:"false" doc:"database user"`
+	Password string        `vault:"app/db#password" doc:"database password"`
+	Port     int           `vault:"app/db#port"`
+	Timeout  time.Duration `vault:"/app/api/#timeout"`
+	APIKey   string        `vault:"app/api#key"`
+	Missing  string        `vault:"app/missing#key"`
+	NoKey    string        `vault:"app/db"`
+	Name     string
+}
+
+// vaultStub is the stand-in of Vault HTTP API with token and AppRole auth methods and KV v2 secrets engine.
+type vaultStub struct {
+	mu       sync.Mutex
+	roleID   string
+	secretID string
+	// tokens are TTLs of valid tokens
+	tokens    map[string]int
+	renewable bool
+	secrets   map[string]map[string]any
+	leases    map[string]int
+	// failures are paths of secrets that can't be read
+	failures map[string]bool
+	logins   int
+	renewals int
+	reads    int
+}
+
+func newVaultStub() *vaultStub {
+	return &vaultStub{
+		roleID:    "role",
+		secretID:  "secret",
+		tokens:    map[string]int{"root": 0, "app": 3600},
+		renewable: true,
+		secrets: map[string]map[string]any{
+			"app/db":  {"user": "app", "password": "secret", "port": json.Number("5432")},
+			"app/api": {"timeout": "1m", "key": "key"},
+		},
+		leases:   map[string]int{},
+		failures: map[string]bool{},
+	}
+}
+
+func (s *vaultStub) setSecret(path string, data map[string]any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if data == nil {
+		delete(s.secrets, path)
+		return
+	}
+	s.secrets[path] = data
+}
+
+func (s *vaultStub) setFailure(path string, failure bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[path] = failure
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeErrors(w http.ResponseWriter, status int, errs ...string) {
+	writeJSON(w, status, map[string][]string{"errors": errs})
+}
+
+func (s *vaultStub) auth(token string, ttl int) map[string]any {
+	return map[string]any{"auth": map[string]any{"client_token": token, "lease_duration": ttl, "renewable": s.renewable}}
+}
+
+func (s *vaultStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path := strings.TrimPrefix(r.URL.Path, "/v1/")
+	if path == "auth/approle/login" && r.Method == http.MethodPost {
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["role_id"] != s.roleID || body["secret_id"] != s.secretID {
+			writeErrors(w, http.StatusBadRequest, "invalid role or secret ID")
+			return
+		}
+		s.logins++
+		token := "approle-" + strconv.Itoa(s.logins)
+		s.tokens[token] = 60
+		writeJSON(w, http.StatusOK, s.auth(token, 60))
+		return
+	}
+	token := r.Header.Get("X-Vault-Token")
+	ttl, ok := s.tokens[token]
+	if !ok {
+		writeErrors(w, http.StatusForbidden, "permission denied")
+		return
+	}
+	switch {
+	case path == "auth/token/lookup-self" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, map[string]any{"data": map[string]any{"ttl": ttl, "renewable": s.renewable}})
+	case path == "auth/token/renew-self" && r.Method == http.MethodPost:
+		if !s.renewable {
+			writeErrors(w, http.StatusBadRequest, "lease is not renewable")
+			return
+		}
+		s.renewals++
+		writeJSON(w, http.StatusOK, s.auth(token, ttl))
+	case strings.HasPrefix(path, "secret/data/") && r.Method == http.MethodGet:
+		s.reads++
+		if s.failures[strings.TrimPrefix(path, "secret/data/")] {
+			writeErrors(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		data, ok := s.secrets[strings.TrimPrefix(path, "secret/data/")]
+		if !ok {
+			writeErrors(w, http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"lease_duration": s.leases[strings.TrimPrefix(path, "secret/data/")],
+			"data":           map[string]any{"data": data, "metadata": map[string]any{"version": 1}},
+		})
+	default:
+		writeErrors(w, http.StatusNotFound)
+	}
+}
+
+// advance moves the clock of the driver forward.
+func advance(d tinyconf.Driver, duration time.Duration) {
+	d.(*vaultDriver).mu.Lock()
+	defer d.(*vaultDriver).mu.Unlock()
+	now := d.(*vaultDriver).now()
+	d.(*vaultDriver).now = func() time.Time { return now.Add(duration) }
+}
+
+func TestNew(t *testing.T) {
+	stub := newVaultStub()
+	server := httptest.NewServer(stub)
+	defer server.Close()
+	tests := []struct {
+		name    string
+		opts    []Option
+		wantErr string
+	}{
+		{name: "token", opts: []Option{WithToken("app")}},
+		{name: "root token", opts: []Option{WithToken("root")}},
+		{name: "approle", opts: []Option{WithAppRole("role", "secret")}},
+		{name: "no auth", wantErr: "vault token or approle credentials are not set"},
+		{name: "invalid token", opts: []Option{WithToken("invalid")}, wantErr: "failed to lookup token: vault: 403 Forbidden: permission denied"},
+		{
+			name:    "invalid approle",
+			opts:    []Option{WithAppRole("role", "invalid")},
+			wantErr: "failed to login by approle: vault: 400 Bad Request: invalid role or secret ID",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := New(server.URL, tt.opts...)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "vault", d.GetName())
+		})
+	}
+}
+
+func TestVaultDriver_GetValue(t *testing.T) {
+	stub := newVaultStub()
+	server := httptest.NewServer(stub)
+	defer server.Close()
+	d, err := New(server.URL, WithToken("app"))
+	assert.NoError(t, err)
+	storage, _ := fmap.Get[Config]()
+	drivertest.GetValue(t, d, storage, []drivertest.Case{
+		{Path: "User", Want: &tinyconf.Value{Source: "secret/app/db#user", Value: "app"}},
+		{Path: "Password", Want: &tinyconf.Value{Source: "secret/app/db#password", Value: "secret", Hidden: true}},
+		{Path: "Port", Want: &tinyconf.Value{Source: "secret/app/db#port", Value: 5432, Hidden: true}},
+		{Path: "Timeout", Want: &tinyconf.Value{Source: "secret/app/api#timeout", Value: time.Minute, Hidden: true}},
+		{Path: "Missing", WantErr: tinyconf.ErrValueNotFound},
+		{Path: "NoKey", WantErr: tinyconf.ErrIncorrectTagSettings},
+		{Path: "Name", WantErr: tinyconf.ErrIncorrectTagSettings},
+	})
+	// secrets are cached by paths
+	assert.Equal(t, 3, stub.reads)
+
+	stub.setSecret("app/api", map[string]any{})
+	advance(d, 10*time.Minute)
+	_, err = d.GetValue(storage.MustFind("APIKey"))
+	assert.ErrorIs(t, err, tinyconf.ErrValueNotFound)
+	assert.EqualError(t, err, "value was not found: secret/app/api#key is not found in vault for APIKey config field")
+}
+
+func TestVaultDriver_Parse(t *testing.T) {
+	stub := newVaultStub()
+	server := httptest.NewServer(stub)
+	defer server.Close()
+	d, err := New(server.URL, WithAppRole("role", "secret"))
+	assert.NoError(t, err)
+	conf := &Config{Name: "app"}
+	drivertest.Parse(t, d, conf)
+	assert.Equal(t, &Config{User: "app", Password: "secret", Port: 5432, Timeout: time.Minute, APIKey: "key", Name: "app"}, conf)
+}
+
+func TestVaultDriver_TokenRenewal(t *testing.T) {
+	stub := newVaultStub()
+	server := httptest.NewServer(stub)
+	defer server.Close()
+	storage, _ := fmap.Get[Config]()
+
+	d, err := New(server.URL, WithToken("app"))
+	assert.NoError(t, err)
+	// the token is renewed after 2/3 of its TTL
+	advance(d, 30*time.Minute)
+	_, err = d.(tinyconf.Watcher).Poll(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, stub.renewals)
+	advance(d, 15*time.Minute)
+	_, err = d.(tinyconf.Watcher).Poll(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, stub.renewals)
+
+	// the root token without TTL is never renewed
+	d, err = New(server.URL, WithToken("root"))
+	assert.NoError(t, err)
+	advance(d, 24*time.Hour)
+	_, err = d.GetValue(storage.MustFind("Password"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, stub.renewals)
+
+	// the driver logs in again by approle when the token can't be renewed
+	d, err = New(server.URL, WithAppRole("role", "secret"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, stub.logins)
+	stub.mu.Lock()
+	stub.renewable = false
+	stub.mu.Unlock()
+	advance(d, 50*time.Second)
+	val, err := d.GetValue(storage.MustFind("Password"))
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", val.Value)
+	assert.Equal(t, 2, stub.logins)
+	assert.Equal(t, "approle-2", d.(*vaultDriver).token)
+
+	// the static token that can't be renewed is reported
+	stub.mu.Lock()
+	stub.renewable = true
+	stub.mu.Unlock()
+	d, err = New(server.URL, WithToken("app"))
+	assert.NoError(t, err)
+	stub.mu.Lock()
+	stub.renewable = false
+	stub.mu.Unlock()
+	advance(d, time.Hour)
+	_, err = d.(tinyconf.Watcher).Poll(context.Background())
+	assert.EqualError(t, err, "failed to renew token: vault: 400 Bad Request: lease is not renewable")
+}
+
+func TestVaultDriver_Refresh(t *testing.T) {
+	stub := newVaultStub()
+	stub.leases["app/db"] = 60
+	server := httptest.NewServer(stub)
+	defer server.Close()
+	storage, _ := fmap.Get[Config]()
+	d, err := New(server.URL, WithToken("root"), WithRefresh(time.Hour))
+	assert.NoError(t, err)
+	watcher := d.(tinyconf.Watcher)
+	_, err = d.GetValue(storage.MustFind("Password"))
+	assert.NoError(t, err)
+	_, err = d.GetValue(storage.MustFind("APIKey"))
+	assert.NoError(t, err)
+
+	// the leased secret is read again before expiry
+	stub.setSecret("app/db", map[string]any{"password": "new"})
+	advance(d, 30*time.Second)
+	changed, err := watcher.Poll(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, changed)
+	advance(d, 15*time.Second)
+	changed, err = watcher.Poll(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	val, err := d.GetValue(storage.MustFind("Password"))
+	assert.NoError(t, err)
+	assert.Equal(t, "new", val.Value)
+	assert.Equal(t, 3, stub.reads)
+
+	// the cached secret is used until expiry when it can't be read
+	server.Close()
+	advance(d, 45*time.Second)
+	val, err = d.GetValue(storage.MustFind("Password"))
+	assert.NoError(t, err)
+	assert.Equal(t, "new", val.Value)
+	advance(d, 30*time.Second)
+	_, err = d.GetValue(storage.MustFind("Password"))
+	assert.ErrorContains(t, err, "failed to read app/db secret")
+	// secrets without leases are used until the next successful read
+	advance(d, time.Hour)
+	val, err = d.GetValue(storage.MustFind("APIKey"))
+	assert.NoError(t, err)
+	assert.Equal(t, "key", val.Value)
+}
+
+func TestVaultDriver_PollErrors(t *testing.T) {
+	stub := newVaultStub()
+	stub.secrets["app/other"] = map[string]any{"key": "other"}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+	type PollConfig struct {
+		Password string `vault:"app/db#password"`
+		APIKey   string `vault:"app/api#key"`
+		Other    string `vault:"app/other#key"`
+	}
+	storage, _ := fmap.Get[PollConfig]()
+	d, err := New(server.URL, WithToken("root"), WithRefresh(time.Minute))
+	assert.NoError(t, err)
+	for _, path := range []string{"Password", "APIKey", "Other"} {
+		_, err = d.GetValue(storage.MustFind(path))
+		assert.NoError(t, err)
+	}
+
+	// secrets after the failed one are read, the deleted secret is evicted
+	stub.setFailure("app/api", true)
+	stub.setSecret("app/db", nil)
+	stub.setSecret("app/other", map[string]any{"key": "new"})
+	advance(d, time.Minute)
+	changed, err := d.(tinyconf.Watcher).Poll(context.Background())
+	assert.True(t, changed)
+	assert.ErrorContains(t, err, "failed to read app/api secret")
+	val, err := d.GetValue(storage.MustFind("Other"))
+	assert.NoError(t, err)
+	assert.Equal(t, "new", val.Value)
+	assert.NotContains(t, d.(*vaultDriver).secrets, "app/db")
+	_, err = d.GetValue(storage.MustFind("Password"))
+	assert.ErrorIs(t, err, tinyconf.ErrValueNotFound)
+	// the cached secret is kept when it can't be read
+	val, err = d.GetValue(storage.MustFind("APIKey"))
+	assert.NoError(t, err)
+	assert.Equal(t, "key", val.Value)
+}
+
+func TestVaultDriver_Watch(t *testing.T) {
+	stub := newVaultStub()
+	server := httptest.NewServer(stub)
+	defer server.Close()
+	storage, _ := fmap.Get[Config]()
+	d, err := New(server.URL, WithToken("root"), WithRefresh(10*time.Millisecond))
+	assert.NoError(t, err)
+	_, err = d.GetValue(storage.MustFind("Password"))
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := make(chan error, 1)
+	go d.(tinyconf.Watcher).Watch(ctx, func(err error) {
+		select {
+		case changes <- err:
+		default:
+		}
+	})
+	stub.setSecret("app/db", map[string]any{"password": "new"})
+	select {
+	case err := <-changes:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("secret change is not reported")
+	}
+	val, err := d.GetValue(storage.MustFind("Password"))
+	assert.NoError(t, err)
+	assert.Equal(t, "new", val.Value)
+}
+
+func TestVaultDriver_GenDoc(t *testing.T) {
+	stub := newVaultStub()
+	server := httptest.NewServer(stub)
+	defer server.Close()
+	d, err := New(server.URL, WithToken("root"), WithMount("/kv/"))
+	assert.NoError(t, err)
+	storage, _ := fmap.Get[Config]()
+	conf := &Config{User: "app", Password: "secret"}
+	assert.Equal(t, `#user: database user
+#password: database password
+vault kv put -mount=kv app/db user='app' password='' port=''
+vault kv put -mount=kv app/api timeout='' key=''
+vault kv put -mount=kv app/missing key=''
+`, d.GenDoc(&tinyconf.Registered{Storage: storage, Config: conf}))
+}
@@ -1,6 +1,7 @@
 package yaml
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -30,6 +31,9 @@ func (s *storageImpl) getMap() map[string]any {
 
 func (s *storageImpl) getReaderCloser() (readerCloser, error) {
 	f, err := os.Open(s.filePath)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: error while open file: %s", tinyconf.ErrValueNotFound, err)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("error while open file: %s", err)
 	}
@@ -41,6 +45,10 @@ func (s *storageImpl) parseYAML(r readerCloser) error {
 	s.yamlMap = make(map[string]any)
 	decoder := yaml.NewDecoder(r)
 	err := decoder.Decode(&s.yamlMap)
+	if errors.Is(err, io.EOF) {
+		// the empty document has no values
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("failed to decode yaml: %s", err)
 	}
@@ -30,6 +30,13 @@ func TestStorageImpl(t *testing.T) {
 			assert.NoError(t, err)
 			assert.Equal(t, map[string]any{"key": "value"}, stor.getMap())
 		})
+		t.Run("empty document", func(t *testing.T) {
+			stor := &storageImpl{}
+			mock := &mockReaderCloser{data: []byte("# comment only\n")}
+			err := stor.parseYAML(mock)
+			assert.NoError(t, err)
+			assert.Equal(t, map[string]any{}, stor.getMap())
+		})
 		t.Run("bad yaml", func(t *testing.T) {
 			stor := &storageImpl{}
 			mock := &mockReaderCloser{data: []byte("key: value:")}
@@ -5,11 +5,11 @@ import (
 	"reflect"
 	"strings"
 
-	"github.com/insei/cast"
 	"github.com/insei/fmap/v3"
 
 	"github.com/insei/tinyconf"
 	"github.com/insei/tinyconf/cmp118"
+	"github.com/insei/tinyconf/drivers/mapdriver"
 	"github.com/insei/tinyconf/slices118"
 )
 
@@ -18,60 +18,16 @@ type yamlDriver struct {
 	storage
 }
 
-func getMapValue(field fmap.Field, yamlMap any) (any, error) {
-	yamlPathKey := field.GetTagPath("yaml", true)
-	if yamlPathKey == "" {
-		return nil, fmt.Errorf("%w: 'yaml' tag is not set", tinyconf.ErrIncorrectTagSettings)
-	}
-	yamlPathKeyArr := strings.Split(yamlPathKey, ".")
-	if len(yamlPathKeyArr) > 1 {
-		for i := 0; i < len(yamlPathKeyArr)-1; i++ {
-			mCast := yamlMap.(map[string]interface{})
-			if newM, ok := mCast[yamlPathKeyArr[i]]; ok {
-				if mapCasted, ok := newM.(map[string]interface{}); ok {
-					yamlMap = mapCasted
-				}
-			}
-		}
-	}
-	mMap := yamlMap.(map[string]interface{})
-	val, ok := mMap[yamlPathKeyArr[len(yamlPathKeyArr)-1]]
-	if !ok || val == nil {
-		return nil, fmt.Errorf("%w: value not found in yaml config", tinyconf.ErrValueNotFound)
-	}
-	return val, nil
+func (d *yamlDriver) mapDriver() *mapdriver.Driver {
+	return &mapdriver.Driver{Name: d.name, Load: d.load}
 }
 
-func convertValToType(field fmap.Field, val any) (any, error) {
-	valOf := reflect.ValueOf(val)
-	fieldType := field.GetType()
-	if valOf.Type() == fieldType {
-		return val, nil
-	}
-	val, err := cast.ToReflect(fmt.Sprintf("%v", val), fieldType)
-	if err != nil {
-		return nil, err
-	}
-	return val, nil
+func (d *yamlDriver) GetValue(field fmap.Field) (*tinyconf.Value, error) {
+	return d.mapDriver().GetValue(field)
 }
 
-func (d *yamlDriver) GetValue(field fmap.Field) (*tinyconf.Value, error) {
-	yamlMap, err := d.load()
-	if err != nil {
-		return nil, err
-	}
-	val, err := getMapValue(field, yamlMap)
-	if err != nil {
-		return nil, err
-	}
-	val, err = convertValToType(field, val)
-	if err != nil {
-		return nil, fmt.Errorf("failed to convert yaml map value to field type value: %w", err)
-	}
-	return &tinyconf.Value{
-		Source: d.name,
-		Value:  val,
-	}, nil
+func (d *yamlDriver) GetKeys(field fmap.Field) ([]string, error) {
+	return d.mapDriver().GetKeys(field)
 }
 
 func (d *yamlDriver) GetName() string {
@@ -79,9 +35,12 @@ func (d *yamlDriver) GetName() string {
 }
 
 type field struct {
-	path  string
-	value any
-	tag   reflect.StructTag
+	path    string
+	value   any
+	section bool
+	// item is the []T section item, it is documented as the sequence item
+	item bool
+	tag  reflect.StructTag
 }
 
 func (f field) genDoc(driver string, depth int) string {
@@ -92,8 +51,11 @@ func (f field) genDoc(driver string, depth int) string {
 	offset.WriteRune('#')
 	tagDriver := offset.String() + f.tag.Get(driver)
 	tagDoc := offset.String() + f.tag.Get("doc")
+	if f.item {
+		return fmt.Sprintf("%s\n%s-\n", tagDoc, offset.String())
+	}
 	offset.Reset()
-	if reflect.TypeOf(f.value).Kind() == reflect.Struct {
+	if f.section {
 		f.value = ""
 	}
 	return fmt.Sprintf("%s\n%s: %v\n", tagDoc, tagDriver, f.value)
@@ -102,8 +64,8 @@ func (f field) genDoc(driver string, depth int) string {
 func (d *yamlDriver) getUniqueFields(registers []*tinyconf.Registered) []field {
 	var fields []field
 	for _, register := range registers {
-		for _, path := range register.Storage.GetAllPaths() {
-			fld := register.Storage.MustFind(path)
+		for _, configField := range register.GetFields() {
+			fld := configField.Field
 
 			tag := fld.GetTag()
 			tagDriver, ok := tag.Lookup(d.name)
@@ -112,9 +74,11 @@ func (d *yamlDriver) getUniqueFields(registers []*tinyconf.Registered) []field {
 			}
 
 			member := field{
-				path:  fld.GetTagPath(d.name, false),
-				value: fld.Get(register.Config),
-				tag:   tag,
+				path:    fld.GetTagPath(d.name, false),
+				value:   tinyconf.FormatFieldValue(fld, configField.Value),
+				section: tinyconf.IsSection(fld),
+				item:    tinyconf.IsSliceEntry(fld),
+				tag:     tag,
 			}
 
 			if slices118.ContainsFunc(fields, func(item field) bool {
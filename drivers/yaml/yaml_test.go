@@ -2,12 +2,13 @@ package yaml
 
 import (
 	"io"
-	"math"
-	"reflect"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/insei/fmap/v3"
 	"github.com/insei/tinyconf"
+	"github.com/insei/tinyconf/drivers/tag"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -41,200 +42,6 @@ func TestYamlDriver_GetName(t *testing.T) {
 	}
 }
 
-func Test_convertValToType(t *testing.T) {
-	tests := []struct {
-		name     string
-		getField func() fmap.Field
-		val      any
-		want     any
-		wantErr  bool
-	}{
-		{
-			name: "Convert string to int",
-			getField: func() fmap.Field {
-				storage, _ := fmap.Get[struct {
-					Test int
-				}]()
-				return storage.MustFind("Test")
-			},
-			val:     "123",
-			want:    123,
-			wantErr: false,
-		},
-		{
-			name: "Incompatible types",
-			getField: func() fmap.Field {
-				storage, _ := fmap.Get[struct {
-					Test int
-				}]()
-				return storage.MustFind("Test")
-			},
-			val:     "test",
-			want:    nil,
-			wantErr: true,
-		},
-		{
-			name: "Already same types",
-			getField: func() fmap.Field {
-				storage, _ := fmap.Get[struct {
-					Test string
-				}]()
-				return storage.MustFind("Test")
-			},
-			val:     "test",
-			want:    "test",
-			wantErr: false,
-		},
-		{
-			name: "convertible type",
-			getField: func() fmap.Field {
-				storage, _ := fmap.Get[struct {
-					Test int
-				}]()
-				return storage.MustFind("Test")
-			},
-			val:     float64(123),
-			want:    123,
-			wantErr: false,
-		},
-		{
-			name: "non convertible type",
-			getField: func() fmap.Field {
-				storage, _ := fmap.Get[struct {
-					Test int32
-				}]()
-				return storage.MustFind("Test")
-			},
-			val:     int64(math.MaxInt64),
-			wantErr: true,
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got, err := convertValToType(tt.getField(), tt.val)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("convertValToType() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("convertValToType() = %v, want %v", got, tt.want)
-			}
-		})
-	}
-}
-
-func TestYamlDriver_GetMapValue(t *testing.T) {
-	tests := []struct {
-		name          string
-		yamlMap       map[string]any
-		getField      func() fmap.Field
-		expectedValue any
-		wantErr       bool
-	}{
-		{
-			name:    "NonExistingYamlTag",
-			yamlMap: nil,
-			getField: func() fmap.Field {
-				storage, _ := fmap.Get[struct {
-					Test int
-				}]()
-				return storage.MustFind("Test")
-			},
-			expectedValue: nil,
-			wantErr:       true,
-		},
-		{
-			name:    "NonExistingValueInNonExistingYaml",
-			yamlMap: nil,
-			getField: func() fmap.Field {
-				storage, _ := fmap.Get[struct {
-					Test int `yaml:"nonexisting"`
-				}]()
-				return storage.MustFind("Test")
-			},
-			expectedValue: nil,
-			wantErr:       true,
-		},
-		{
-			name:    "NonExistingFieldInExistingYaml",
-			yamlMap: map[string]any{"existent": "value"},
-			getField: func() fmap.Field {
-				storage, _ := fmap.Get[struct {
-					Test int `yaml:"nonexistent"`
-				}]()
-				return storage.MustFind("Test")
-			},
-			expectedValue: nil,
-			wantErr:       true,
-		},
-		{
-			name:    "ExistingNonEmptyField",
-			yamlMap: map[string]any{"existent": "value"},
-			getField: func() fmap.Field {
-				storage, _ := fmap.Get[struct {
-					Test int `yaml:"existent"`
-				}]()
-				return storage.MustFind("Test")
-			},
-			expectedValue: "value",
-			wantErr:       false,
-		},
-		{
-			name:    "NestedExistingNonEmptyField",
-			yamlMap: map[string]any{"existent": map[string]any{"nested": "value"}},
-			getField: func() fmap.Field {
-				storage, _ := fmap.Get[struct {
-					Test struct {
-						Nested string `yaml:"nested"`
-					} `yaml:"existent"`
-				}]()
-				return storage.MustFind("Test.Nested")
-			},
-			expectedValue: "value",
-			wantErr:       false,
-		},
-		{
-			name:    "NestedNonExistingField",
-			yamlMap: map[string]any{"existent": map[string]any{"nested": "value"}},
-			getField: func() fmap.Field {
-				storage, _ := fmap.Get[struct {
-					Test struct {
-						Nonested string `yaml:"nonested"`
-					} `yaml:"existent"`
-				}]()
-				return storage.MustFind("Test.Nonested")
-			},
-			expectedValue: nil,
-			wantErr:       true,
-		},
-		{
-			name:    "NestedNonExistingFieldWithEmptyMap",
-			yamlMap: map[string]any{"existent": "value"},
-			getField: func() fmap.Field {
-				storage, _ := fmap.Get[struct {
-					Test struct {
-						Nonested string `yaml:"nonesting"`
-					} `yaml:"existent"`
-				}]()
-				return storage.MustFind("Test.Nonested")
-			},
-			expectedValue: nil,
-			wantErr:       true,
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got, err := getMapValue(tt.getField(), tt.yamlMap)
-			if tt.wantErr {
-				assert.Error(t, err)
-				return
-			}
-			assert.NoError(t, err)
-			assert.Equal(t, tt.expectedValue, got)
-		})
-	}
-}
-
 func TestYamlDriver_GenDoc(t *testing.T) {
 	type TestingFirstStruct struct {
 		HTTP struct {
@@ -338,6 +145,23 @@ func TestYamlDriver_GenDoc(t *testing.T) {
 	}
 }
 
+func TestYamlDriver_GenDocPointerSection(t *testing.T) {
+	type TLS struct {
+		Cert string `yaml:"cert" doc:"certificate path"`
+	}
+	type Config struct {
+		TLS *TLS `yaml:"tls" doc:"optional tls block"`
+	}
+	storage, _ := fmap.Get[Config]()
+	driver := yamlDriver{name: "yaml"}
+	out := driver.GenDoc(&tinyconf.Registered{Storage: storage, Config: &Config{}})
+	assert.Equal(t, `#optional tls block
+#tls: 
+	#certificate path
+	#cert: 
+`, out)
+}
+
 // go test ./drivers/yaml -bench . -benchmem
 func BenchmarkYamlDriver_GenDoc(b *testing.B) {
 	type TestingFirstStruct struct {
@@ -428,3 +252,182 @@ func TestNew(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, driver)
 }
+
+func TestYamlDriver_ParseEmptyFile(t *testing.T) {
+	type Config struct {
+		Port int `yaml:"port"`
+	}
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(file, nil, 0o600))
+	driver, err := New(file)
+	assert.NoError(t, err)
+	m, _ := tinyconf.New(tinyconf.WithDriver(driver))
+	conf := &Config{Port: 8080}
+	assert.NoError(t, m.Register(conf))
+	assert.NoError(t, m.Parse(conf))
+	assert.Equal(t, 8080, conf.Port)
+}
+
+func TestYamlDriver_ParseNullKeepsDefaults(t *testing.T) {
+	type Config struct {
+		Host string `yaml:"host" default:"localhost"`
+		Port int    `yaml:"port" default:"8080"`
+	}
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(file, []byte("host:\nport: ~\n"), 0o600))
+	yamlDriver, err := New(file)
+	assert.NoError(t, err)
+	tagDriver, err := tag.New("default")
+	assert.NoError(t, err)
+	m, _ := tinyconf.New(tinyconf.WithDriver(tagDriver), tinyconf.WithDriver(yamlDriver))
+	conf := &Config{}
+	assert.NoError(t, m.Register(conf))
+	assert.NoError(t, m.Parse(conf))
+	assert.Equal(t, &Config{Host: "localhost", Port: 8080}, conf)
+}
+
+func TestYamlDriver_ParseScalarSequences(t *testing.T) {
+	type Config struct {
+		Tags   []string       `yaml:"tags"`
+		Ports  []int          `yaml:"ports"`
+		Limits map[string]int `yaml:"limits"`
+	}
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(file, []byte("tags: [a, b]\nports:\n  - 80\n  - \"443\"\nlimits:\n  cpu: 2\n"), 0o600))
+	d, _ := New(file)
+	m, _ := tinyconf.New(tinyconf.WithDriver(d))
+	conf := &Config{}
+	assert.NoError(t, m.Register(conf))
+	assert.NoError(t, m.Parse(conf))
+	assert.Equal(t, &Config{Tags: []string{"a", "b"}, Ports: []int{80, 443}, Limits: map[string]int{"cpu": 2}}, conf)
+}
+
+func TestYamlDriver_MapSections(t *testing.T) {
+	type DB struct {
+		Host string `yaml:"host" doc:"database host"`
+		Port int    `yaml:"port"`
+	}
+	type Config struct {
+		DB map[string]*DB `yaml:"db" key:"name" doc:"databases by name"`
+	}
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(file, []byte("db:\n  main:\n    host: main.local\n  reports:\n    port: 5433\n"), 0o600))
+	d, _ := New(file)
+	storage, _ := fmap.Get[Config]()
+
+	keys, err := d.(tinyconf.KeysDriver).GetKeys(storage.MustFind("DB"))
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"main", "reports"}, keys)
+
+	m, _ := tinyconf.New(tinyconf.WithDriver(d))
+	conf := &Config{}
+	assert.NoError(t, m.Register(conf))
+	assert.NoError(t, m.Parse(conf))
+	assert.Equal(t, map[string]*DB{
+		"main":    {Host: "main.local"},
+		"reports": {Port: 5433},
+	}, conf.DB)
+
+	assert.Equal(t, `#databases by name
+#db: 
+	#databases by name
+	#<name>: 
+		#database host
+		#host: 
+		#
+		#port: 0
+`, d.GenDoc(&tinyconf.Registered{Storage: storage, Config: &Config{}}))
+}
+
+func TestYamlDriver_SliceSections(t *testing.T) {
+	type Server struct {
+		Host string `yaml:"host" doc:"server host"`
+		Port int    `yaml:"port"`
+	}
+	type Config struct {
+		Upstream struct {
+			Servers []Server `yaml:"servers" doc:"upstream servers"`
+		} `yaml:"upstream"`
+	}
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(file, []byte("upstream:\n  servers:\n    - host: a.local\n      port: 80\n    - host: b.local\n"), 0o600))
+	d, _ := New(file)
+	storage, _ := fmap.Get[Config]()
+
+	keys, err := d.(tinyconf.KeysDriver).GetKeys(storage.MustFind("Upstream.Servers"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0", "1"}, keys)
+
+	m, _ := tinyconf.New(tinyconf.WithDriver(d))
+	conf := &Config{}
+	assert.NoError(t, m.Register(conf))
+	assert.NoError(t, m.Parse(conf))
+	assert.Equal(t, []Server{{Host: "a.local", Port: 80}, {Host: "b.local"}}, conf.Upstream.Servers)
+
+	assert.Equal(t, `#
+#upstream: 
+	#upstream servers
+	#servers: 
+		#upstream servers
+		#-
+			#server host
+			#host: 
+			#
+			#port: 0
+`, d.GenDoc(&tinyconf.Registered{Storage: storage, Config: &Config{}}))
+}
+
+type variantStorage interface{}
+
+type variantS3 struct {
+	Bucket string `yaml:"bucket" doc:"bucket name"`
+}
+
+type variantFS struct {
+	Root string `yaml:"root" doc:"root directory"`
+}
+
+func TestYamlDriver_Variants(t *testing.T) {
+	tinyconf.RegisterVariant[variantStorage]("s3", &variantS3{})
+	tinyconf.RegisterVariant[variantStorage]("fs", &variantFS{})
+	type Config struct {
+		Storage variantStorage `yaml:"storage" doc:"storage backend"`
+	}
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(file, []byte("storage:\n  type: s3\n  bucket: data\n"), 0o600))
+	d, _ := New(file)
+	m, _ := tinyconf.New(tinyconf.WithDriver(d))
+	conf := &Config{}
+	assert.NoError(t, m.Register(conf))
+	assert.NoError(t, m.Parse(conf))
+	assert.Equal(t, &variantS3{Bucket: "data"}, conf.Storage)
+
+	storage, _ := fmap.Get[Config]()
+	assert.Equal(t, `#storage backend
+#storage: 
+	#(type=s3) bucket name
+	#bucket: 
+	#(type=fs) root directory
+	#root: 
+	#one of: fs, s3
+	#type: fs|s3
+`, d.GenDoc(&tinyconf.Registered{Storage: storage, Config: &Config{}}))
+}
+
+func TestYamlDriver_NamedConfigs(t *testing.T) {
+	type DB struct {
+		Host string `yaml:"host" doc:"db host"`
+	}
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(file, []byte("primary:\n  host: primary.local\nreplica:\n  host: replica.local\n"), 0o600))
+	d, _ := New(file)
+	m, _ := tinyconf.New(tinyconf.WithDriver(d))
+	primary, replica := &DB{}, &DB{}
+	assert.NoError(t, m.RegisterNamed("primary", primary))
+	assert.NoError(t, m.RegisterNamed("replica", replica))
+	assert.NoError(t, m.Parse(primary))
+	assert.NoError(t, m.Parse(replica))
+	assert.Equal(t, "primary.local", primary.Host)
+	assert.Equal(t, "replica.local", replica.Host)
+	assert.Equal(t, "#\n#primary: \n\t#db host\n\t#host: primary.local\n#\n#replica: \n\t#db host\n\t#host: replica.local\n", m.GenDoc("yaml"))
+}
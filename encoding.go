@@ -0,0 +1,142 @@
+package tinyconf
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/insei/fmap/v3"
+)
+
+const encodingTag = "encoding"
+
+var typeOfBytes = reflect.TypeOf([]byte(nil))
+
+func getEncoding(field fmap.Field) (string, bool) {
+	encoding, ok := field.GetTag().Lookup(encodingTag)
+	return encoding, ok && encoding != ""
+}
+
+func decodeBytes(encoding, s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	switch encoding {
+	case "base64":
+		if strings.HasSuffix(s, "=") {
+			return base64.StdEncoding.DecodeString(s)
+		}
+		return base64.RawStdEncoding.DecodeString(s)
+	case "base64url":
+		if strings.HasSuffix(s, "=") {
+			return base64.URLEncoding.DecodeString(s)
+		}
+		return base64.RawURLEncoding.DecodeString(s)
+	case "hex":
+		return hex.DecodeString(s)
+	}
+	return nil, fmt.Errorf("%w: unsupported encoding %q", ErrIncorrectTagSettings, encoding)
+}
+
+func unmarshalJSON(data []byte, typeOf reflect.Type) (any, error) {
+	baseType := typeOf
+	pointers := 0
+	for baseType.Kind() == reflect.Ptr {
+		baseType = baseType.Elem()
+		pointers++
+	}
+	to := reflect.New(baseType)
+	if err := json.Unmarshal(data, to.Interface()); err != nil {
+		return nil, err
+	}
+	return wrapPointers(to.Elem(), pointers).Interface(), nil
+}
+
+func decodeEncoded(field fmap.Field, encoding, s string) (any, error) {
+	if encoding == "json" {
+		return unmarshalJSON([]byte(s), field.GetType())
+	}
+	data, err := decodeBytes(encoding, s)
+	if err != nil {
+		return nil, err
+	}
+	baseType := field.GetDereferencedType()
+	if baseType.Kind() == reflect.Slice && baseType.Elem().Kind() == reflect.Uint8 {
+		baseVal := reflect.ValueOf(data).Convert(baseType)
+		pointers := 0
+		for typeOf := field.GetType(); typeOf.Kind() == reflect.Ptr; typeOf = typeOf.Elem() {
+			pointers++
+		}
+		return wrapPointers(baseVal, pointers).Interface(), nil
+	}
+	return Decode(string(data), field.GetType())
+}
+
+// DecodeField converts string value to the field type value like Decode, but honours the field `encoding` tag:
+// base64, base64url and hex values are decoded to bytes first, json values are unmarshalled to the field type,
+// i.e. struct and map fields can be filled from a single JSON string.
+func DecodeField(field fmap.Field, s string) (any, error) {
+	encoding, ok := getEncoding(field)
+	if !ok {
+		return Decode(s, field.GetType())
+	}
+	val, err := decodeEncoded(field, encoding, s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s config field value as %s: %w", field.GetStructPath(), encoding, err)
+	}
+	return val, nil
+}
+
+// DecodeAnyField converts the value decoded by driver from structured document to the field type value like DecodeAny,
+// but honours the field `encoding` tag, see DecodeField. Mappings and sequences of json encoded fields are converted
+// through json, so they can be set as a nested document as well as a JSON string.
+func DecodeAnyField(field fmap.Field, val any) (any, error) {
+	encoding, ok := getEncoding(field)
+	if !ok {
+		return DecodeAny(val, field.GetType())
+	}
+	if str, isStr := val.(string); isStr {
+		return DecodeField(field, str)
+	}
+	kind := reflect.ValueOf(val).Kind()
+	if encoding == "json" && (kind == reflect.Map || kind == reflect.Slice) {
+		data, err := json.Marshal(val)
+		if err == nil {
+			val, err = unmarshalJSON(data, field.GetType())
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s config field value as %s: %w", field.GetStructPath(), encoding, err)
+		}
+		return val, nil
+	}
+	return DecodeAny(val, field.GetType())
+}
+
+// FormatFieldValue returns string representation of the field value, that is accepted by DecodeField,
+// it is used by drivers for documentation generation.
+func FormatFieldValue(field fmap.Field, val any) string {
+	encoding, _ := getEncoding(field)
+	derefVal := getDereferencedValue(val)
+	switch encoding {
+	case "json":
+		if data, err := json.Marshal(derefVal); err == nil {
+			return string(data)
+		}
+	case "base64", "base64url", "hex":
+		valOf := reflect.ValueOf(derefVal)
+		if !valOf.IsValid() || valOf.Kind() != reflect.Slice || valOf.Type().Elem().Kind() != reflect.Uint8 {
+			break
+		}
+		data := valOf.Convert(typeOfBytes).Bytes()
+		switch encoding {
+		case "base64":
+			return base64.StdEncoding.EncodeToString(data)
+		case "base64url":
+			return base64.URLEncoding.EncodeToString(data)
+		default:
+			return hex.EncodeToString(data)
+		}
+	}
+	return fmt.Sprintf("%v", val)
+}
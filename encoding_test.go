@@ -0,0 +1,118 @@
+package tinyconf
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/insei/fmap/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+type encodingTestConfig struct {
+	Key      []byte            `encoding:"base64"`
+	URLKey   []byte            `encoding:"base64url"`
+	HexKey   *[]byte           `encoding:"hex"`
+	Text     string            `encoding:"base64"`
+	Number   int               `encoding:"hex"`
+	Invalid  []byte            `encoding:"base32"`
+	Labels   map[string]string `encoding:"json"`
+	Upstream struct {
+		Host string `json:"host"`
+		Port int    `json:"port"`
+	} `encoding:"json"`
+	Plain string
+}
+
+func TestDecodeField(t *testing.T) {
+	storage, _ := fmap.Get[encodingTestConfig]()
+	hexKey := []byte{0xde, 0xad}
+	tests := []struct {
+		name     string
+		path     string
+		value    string
+		expected any
+		wantErr  error
+	}{
+		{name: "base64", path: "Key", value: "aGVsbG8=", expected: []byte("hello")},
+		{name: "base64 without padding", path: "Key", value: "aGVsbG8", expected: []byte("hello")},
+		{name: "base64url", path: "URLKey", value: "-_8", expected: []byte{0xfb, 0xff}},
+		{name: "hex pointer", path: "HexKey", value: "dead", expected: &hexKey},
+		{name: "base64 string", path: "Text", value: "aGVsbG8=", expected: "hello"},
+		{name: "hex decoded then cast", path: "Number", value: "3432", expected: 42},
+		{name: "json map", path: "Labels", value: `{"a":"b"}`, expected: map[string]string{"a": "b"}},
+		{name: "json struct", path: "Upstream", value: `{"host":"localhost","port":80}`, expected: struct {
+			Host string `json:"host"`
+			Port int    `json:"port"`
+		}{Host: "localhost", Port: 80}},
+		{name: "no encoding", path: "Plain", value: "aGVsbG8=", expected: "aGVsbG8="},
+		{name: "invalid base64", path: "Key", value: "!!!", wantErr: errors.New("")},
+		{name: "invalid json", path: "Labels", value: `{`, wantErr: errors.New("")},
+		{name: "unsupported encoding", path: "Invalid", value: "abc", wantErr: ErrIncorrectTagSettings},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			val, err := DecodeField(storage.MustFind(tt.path), tt.value)
+			if tt.wantErr != nil {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.path)
+				if errors.Is(tt.wantErr, ErrIncorrectTagSettings) {
+					assert.ErrorIs(t, err, ErrIncorrectTagSettings)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, val)
+		})
+	}
+}
+
+func TestDecodeAnyField(t *testing.T) {
+	storage, _ := fmap.Get[encodingTestConfig]()
+
+	val, err := DecodeAnyField(storage.MustFind("Labels"), map[string]any{"a": "b"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "b"}, val)
+
+	val, err = DecodeAnyField(storage.MustFind("Labels"), `{"c":"d"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"c": "d"}, val)
+
+	val, err = DecodeAnyField(storage.MustFind("Key"), "aGVsbG8=")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("hello"), val)
+
+	val, err = DecodeAnyField(storage.MustFind("Plain"), 42)
+	assert.NoError(t, err)
+	assert.Equal(t, "42", val)
+}
+
+func TestFormatFieldValue(t *testing.T) {
+	storage, _ := fmap.Get[encodingTestConfig]()
+	hexKey := []byte{0xde, 0xad}
+	assert.Equal(t, "aGVsbG8=", FormatFieldValue(storage.MustFind("Key"), []byte("hello")))
+	assert.Equal(t, "dead", FormatFieldValue(storage.MustFind("HexKey"), &hexKey))
+	assert.Equal(t, `{"a":"b"}`, FormatFieldValue(storage.MustFind("Labels"), map[string]string{"a": "b"}))
+	assert.Equal(t, "plain", FormatFieldValue(storage.MustFind("Plain"), "plain"))
+}
+
+func TestManager_ParseEncoded(t *testing.T) {
+	type Config struct {
+		Upstream struct {
+			Host string `json:"host"`
+			Port int    `json:"port"`
+		} `encoding:"json"`
+	}
+	m, _ := New(
+		WithDriver(&tlsMockDriver{values: map[string]string{
+			"Upstream": `{"host":"localhost","port":80}`,
+		}}),
+		WithDriver(&tlsMockDriver{values: map[string]string{
+			"Upstream.Port": "8080",
+		}}),
+	)
+	conf := &Config{}
+	assert.NoError(t, m.Register(conf))
+	assert.NoError(t, m.Parse(conf))
+	assert.Equal(t, "localhost", conf.Upstream.Host)
+	assert.Equal(t, 8080, conf.Upstream.Port)
+}
@@ -7,7 +7,7 @@ require github.com/insei/tinyconf v1.1.0
 require (
 	github.com/google/uuid v1.6.0 // indirect
 	github.com/insei/cast v1.1.1 // indirect
-	github.com/insei/fmap/v3 v3.0.0 // indirect
+	github.com/insei/fmap/v3 v3.1.2 // indirect
 	go.uber.org/multierr v1.10.0 // indirect
 	go.uber.org/zap v1.27.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
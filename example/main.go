@@ -8,13 +8,14 @@ import (
 
 	"github.com/insei/tinyconf"
 	"github.com/insei/tinyconf/drivers/env"
+	flagdriver "github.com/insei/tinyconf/drivers/flag"
 	"github.com/insei/tinyconf/drivers/tag"
 	"github.com/insei/tinyconf/drivers/yaml"
 	"github.com/insei/tinyconf/logger"
 )
 
 const (
-	UsageDocFlag = "Show documentation in selected format. Supported formats: env, yaml.\nExample: go run ./example/main.go -doc yaml"
+	UsageDocFlag = "Show documentation in selected format. Supported formats: env, yaml, flag.\nExample: go run ./example/main.go -doc yaml"
 )
 
 type Application struct {
@@ -51,7 +52,23 @@ type sharedAuthSignConfig struct {
 }
 
 func main() {
+	c1 := &sharedAuthSignConfig{}
+	c2 := &sharedConfig{}
+	c3 := &sharedAuthConfig{}
+	c4 := &sharedApplicationConfig{}
+
 	driverName := flag.String("doc", "", UsageDocFlag)
+	// config values can be set by flags, i.e. -http-port 8080
+	flagDriver, err := flagdriver.New(flag.CommandLine,
+		flagdriver.WithConfig(c1),
+		flagdriver.WithConfig(c2),
+		flagdriver.WithConfig(c3),
+		flagdriver.WithConfig(c4),
+		flagdriver.AutoNames(),
+	)
+	if err != nil {
+		panic(err)
+	}
 	flag.Parse()
 
 	yamlDriver, err := yaml.New("config.yaml")
@@ -72,31 +89,24 @@ func main() {
 		tinyconf.WithDriver(tagDriver),
 		tinyconf.WithDriver(yamlDriver),
 		tinyconf.WithDriver(envDriver),
+		tinyconf.WithDriver(flagDriver),
 	)
 	if err != nil {
 		return
 	}
 
-	c1 := &sharedAuthSignConfig{}
 	if err = config.Register(c1); err != nil {
 		panic(err)
 	}
-
-	c2 := &sharedConfig{}
 	if err = config.Register(c2); err != nil {
 		panic(err)
 	}
-
-	c3 := &sharedAuthConfig{}
 	if err = config.Register(c3); err != nil {
 		panic(err)
 	}
-
-	c4 := &sharedApplicationConfig{}
 	if err = config.Register(c4); err != nil {
 		panic(err)
 	}
-
 	if *driverName == "" {
 		if err = config.Parse(c1); err != nil {
 			panic(err)
@@ -112,7 +122,7 @@ func main() {
 		}
 	}
 
-	if strings.Contains("env yaml", *driverName) {
+	if strings.Contains("env yaml flag", *driverName) {
 		doc := config.GenDoc(*driverName)
 		fmt.Println(doc)
 		os.Exit(0)
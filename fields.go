@@ -0,0 +1,892 @@
+package tinyconf
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/insei/fmap/v3"
+
+	"github.com/insei/tinyconf/slices118"
+)
+
+// sectionField is a field of the struct, that is not a part of the registered config storage, e.g. a field
+// of the pointer to struct section, or a field of the named config. It extends struct and tag paths of the section
+// storage field with the section paths.
+// Get, Set and GetPtr methods work with the section struct, not with the registered config.
+type sectionField struct {
+	fmap.Field
+	section fmap.Field
+}
+
+func (f *sectionField) GetStructPath() string {
+	return f.section.GetStructPath() + "." + f.Field.GetStructPath()
+}
+
+func (f *sectionField) GetParent() fmap.Field {
+	parent := ParentOf(f.Field)
+	if parent == nil {
+		return f.section
+	}
+	return &sectionField{Field: parent, section: f.section}
+}
+
+func (f *sectionField) GetTagPath(tag string, ignoreParentTagMissing bool) string {
+	return getTagPath(f, tag, ignoreParentTagMissing)
+}
+
+func getTagName(field fmap.Field, tag string) string {
+	val, ok := field.GetTag().Lookup(tag)
+	if !ok {
+		return ""
+	}
+	return strings.Split(val, ",")[0]
+}
+
+// getTagPath is the same as fmap.Field GetTagPath, but it resolves parents through GetParent method of the field.
+func getTagPath(field fmap.Field, tag string, ignoreParentTagMissing bool) string {
+	tagPath := getTagName(field, tag)
+	if tagPath == "" {
+		return ""
+	}
+	parent := ParentOf(field)
+	if parent == nil {
+		return tagPath
+	}
+	parentTag := parent.GetTagPath(tag, ignoreParentTagMissing)
+	if parentTag == "" && !ignoreParentTagMissing {
+		return ""
+	}
+	if parentTag == "" {
+		return tagPath
+	}
+	return parentTag + "." + tagPath
+}
+
+// EntryField is implemented by virtual fields of dynamic sections entries, i.e. keys of map[string]T sections.
+type EntryField interface {
+	fmap.Field
+	GetKey() string
+}
+
+// entryField is the virtual field of map[string]T section entry, its struct path is the map field path with the key
+// and all its tags except doc are set to the key, e.g. `yaml:"main"` for databases.main entry.
+type entryField struct {
+	fmap.Field
+	key string
+}
+
+func (f *entryField) GetKey() string {
+	return f.key
+}
+
+func (f *entryField) GetName() string {
+	return f.key
+}
+
+func (f *entryField) GetType() reflect.Type {
+	return f.Field.GetType().Elem()
+}
+
+func (f *entryField) GetDereferencedType() reflect.Type {
+	typeOf := f.GetType()
+	for typeOf.Kind() == reflect.Ptr {
+		typeOf = typeOf.Elem()
+	}
+	return typeOf
+}
+
+func (f *entryField) GetTag() reflect.StructTag {
+	return replaceTagValues(f.Field.GetTag(), f.key)
+}
+
+func (f *entryField) GetStructPath() string {
+	return f.Field.GetStructPath() + "." + f.key
+}
+
+func (f *entryField) GetParent() fmap.Field {
+	return f.Field
+}
+
+func (f *entryField) GetTagPath(tag string, ignoreParentTagMissing bool) string {
+	return getTagPath(f, tag, ignoreParentTagMissing)
+}
+
+type tagValue struct {
+	name, value string
+}
+
+// parseTag returns values of the struct tag in order, the tag format is the same as reflect.StructTag Lookup.
+func parseTag(tag reflect.StructTag) []tagValue {
+	var result []tagValue
+	str := string(tag)
+	for str != "" {
+		str = strings.TrimLeft(str, " ")
+		nameEnd := strings.Index(str, ":\"")
+		if nameEnd <= 0 {
+			break
+		}
+		name := str[:nameEnd]
+		str = str[nameEnd+1:]
+		valueEnd := 1
+		for valueEnd < len(str) && str[valueEnd] != '"' {
+			if str[valueEnd] == '\\' {
+				valueEnd++
+			}
+			valueEnd++
+		}
+		if valueEnd >= len(str) {
+			break
+		}
+		value, err := strconv.Unquote(str[:valueEnd+1])
+		str = str[valueEnd+1:]
+		if err != nil {
+			break
+		}
+		result = append(result, tagValue{name: name, value: value})
+	}
+	return result
+}
+
+func formatTag(values []tagValue) reflect.StructTag {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		result = append(result, value.name+":"+strconv.Quote(value.value))
+	}
+	return reflect.StructTag(strings.Join(result, " "))
+}
+
+// replaceTagValues returns the tag with all values except doc replaced by the value.
+func replaceTagValues(tag reflect.StructTag, value string) reflect.StructTag {
+	values := parseTag(tag)
+	for i := range values {
+		if values[i].name != "doc" {
+			values[i].value = value
+		}
+	}
+	return formatTag(values)
+}
+
+// setTagValue returns the tag with the value of name tag, the tag is appended if it is not set.
+func setTagValue(tag reflect.StructTag, name, value string) reflect.StructTag {
+	values := parseTag(tag)
+	for i := range values {
+		if values[i].name == name {
+			values[i].value = value
+			return formatTag(values)
+		}
+	}
+	return formatTag(append(values, tagValue{name: name, value: value}))
+}
+
+// namedField is the virtual root field of the config registered by name, it is the parent of all config fields.
+// Its struct path and tag paths are the name and its tag has the name value for all tags of the config fields,
+// e.g. `yaml:"primary" env:"primary"`, env driver composes PRIMARY_HOST key for `env:"HOST"` field, because it is an entry.
+// Field methods that work with values, e.g. Get and Set, are not available.
+type namedField struct {
+	fmap.Field
+	name   string
+	typeOf reflect.Type
+	tag    reflect.StructTag
+}
+
+func newNamedField(name string, config any, storage fmap.Storage) *namedField {
+	var tagValues []tagValue
+	for _, path := range storage.GetAllPaths() {
+		for _, value := range parseTag(storage.MustFind(path).GetTag()) {
+			if value.name == "doc" || slices118.ContainsFunc(tagValues, func(item tagValue) bool {
+				return item.name == value.name
+			}) {
+				continue
+			}
+			tagValues = append(tagValues, tagValue{name: value.name, value: name})
+		}
+	}
+	return &namedField{name: name, typeOf: reflect.TypeOf(config), tag: formatTag(tagValues)}
+}
+
+func (f *namedField) GetKey() string {
+	return f.name
+}
+
+func (f *namedField) GetName() string {
+	return f.name
+}
+
+func (f *namedField) GetType() reflect.Type {
+	return f.typeOf
+}
+
+func (f *namedField) GetDereferencedType() reflect.Type {
+	return derefType(f.typeOf)
+}
+
+func (f *namedField) GetTag() reflect.StructTag {
+	return f.tag
+}
+
+func (f *namedField) GetStructPath() string {
+	return f.name
+}
+
+func (f *namedField) GetParent() fmap.Field {
+	return nil
+}
+
+func (f *namedField) GetTagPath(string, bool) string {
+	return f.name
+}
+
+func (f *namedField) IsExported() bool {
+	return true
+}
+
+// rootField returns the root field of the config registered by name or nil.
+func (r *Registered) rootField() fmap.Field {
+	if r.Name == "" {
+		return nil
+	}
+	return newNamedField(r.Name, r.Config, r.Storage)
+}
+
+// wrapRoot returns the wrapper of the config storage fields, fields of named configs are children of the root field.
+func wrapRoot(root fmap.Field) func(fmap.Field) fmap.Field {
+	if root == nil {
+		return func(f fmap.Field) fmap.Field { return f }
+	}
+	return func(f fmap.Field) fmap.Field {
+		return &sectionField{Field: f, section: root}
+	}
+}
+
+// IsSliceEntry reports whether the field is the virtual field of []T section item, its key is the item index.
+func IsSliceEntry(field fmap.Field) bool {
+	entry, ok := field.(*entryField)
+	return ok && entry.Field.GetType().Kind() == reflect.Slice
+}
+
+// IsDynamicField reports whether the field belongs to the entry of dynamic section, i.e. map[string]T or []T section.
+func IsDynamicField(field fmap.Field) bool {
+	for f := field; f != nil; f = ParentOf(f) {
+		if _, ok := f.(EntryField); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// SplitWords splits the struct path to words by dots and camel case, e.g. HTTP.MaxBody to HTTP, Max and Body.
+// Drivers derive keys from words of struct paths, i.e. HTTP_MAX_BODY env key or http-max-body flag name.
+func SplitWords(structPath string) []string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = nil
+		}
+	}
+	runes := []rune(structPath)
+	for i, r := range runes {
+		if r == '.' {
+			flush()
+			continue
+		}
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+				flush()
+			}
+		}
+		word = append(word, r)
+	}
+	flush()
+	return words
+}
+
+// IsHiddenField reports whether the field value is hidden in logs and docs by `hidden:"true"` tag, byDefault is
+// returned if the tag is not set, i.e. drivers of secrets hide values unless the field has `hidden:"false"` tag.
+func IsHiddenField(field fmap.Field, byDefault bool) bool {
+	switch field.GetTag().Get("hidden") {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	return byDefault
+}
+
+type optionalValue interface {
+	optional()
+}
+
+// IsNullableField reports whether the field can be explicitly unset by drivers, i.e. by yaml null: pointer, map, slice
+// and Optional fields. Null values of other fields are not found values, so lower priority values are kept.
+func IsNullableField(field fmap.Field) bool {
+	switch field.GetType().Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		return true
+	}
+	return field.GetType().Implements(reflect.TypeOf((*optionalValue)(nil)).Elem())
+}
+
+// section is the pointer to struct field or the map entry of the config, its fields are parsed into the allocated
+// struct and the struct is assigned to the field only if some of section fields values were found.
+type section struct {
+	field  fmap.Field
+	raw    fmap.Field
+	obj    any
+	value  reflect.Value
+	parent *section
+	// allocated is true if section pointer was nil before parsing
+	allocated bool
+	found     bool
+	// entry is true for map entries sections, value is assigned to the map by the key of entry field
+	entry bool
+	// list is set for []T section items, they are assigned to the slice all together by the list merge strategy
+	list *list
+	// driver is the index of the driver that owns the item if owned is true, other drivers except defaults
+	// drivers don't get values of the item fields
+	driver int
+	owned  bool
+	// variant is the registered variant type of the interface section, value is a pointer to the variant struct
+	variant reflect.Type
+}
+
+func (s *section) markFound() {
+	for sec := s; sec != nil && !sec.found; sec = sec.parent {
+		sec.found = true
+	}
+}
+
+// isAbsent reports whether the section or its parent is allocated, but its values are not found, i.e. it is not set in the config.
+func (s *section) isAbsent() bool {
+	for sec := s; sec != nil; sec = sec.parent {
+		if sec.allocated && !sec.found {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *section) hasAllocatedType(typeOf reflect.Type) bool {
+	for sec := s; sec != nil; sec = sec.parent {
+		if sec.allocated && sec.value.Type() == typeOf {
+			return true
+		}
+	}
+	return false
+}
+
+// owner returns the index of the driver that owns the section or its parent section.
+func (s *section) owner() (int, bool) {
+	for sec := s; sec != nil; sec = sec.parent {
+		if sec.owned {
+			return sec.driver, true
+		}
+	}
+	return 0, false
+}
+
+// elem returns the section value as the map or slice element.
+func (s *section) elem() reflect.Value {
+	if s.raw.GetType().Elem().Kind() != reflect.Ptr {
+		return s.value.Elem()
+	}
+	return s.value
+}
+
+func (s *section) apply() {
+	if s.variant != nil {
+		val := s.value
+		if s.variant.Kind() != reflect.Ptr {
+			val = val.Elem()
+		}
+		setValue(s.obj, s.raw, val.Interface())
+		return
+	}
+	if s.entry {
+		mapVal := reflect.ValueOf(FieldValue(s.obj, s.raw))
+		if mapVal.IsNil() {
+			mapVal = reflect.MakeMap(s.raw.GetType())
+			setValue(s.obj, s.raw, mapVal.Interface())
+		}
+		mapVal.SetMapIndex(reflect.ValueOf(s.field.(EntryField).GetKey()), s.elem())
+		return
+	}
+	setValue(s.obj, s.raw, s.value.Interface())
+}
+
+const (
+	mergeIndex   = "index"
+	mergeReplace = "replace"
+	mergeAppend  = "append"
+)
+
+// getMergeStrategy returns the merge strategy of []T section items from different drivers set by `merge` tag:
+//   - index (default) - items with the same index from all drivers are merged field by field;
+//   - replace - items of the driver with the highest priority replace the slice;
+//   - append - items of all drivers are appended to the slice in the drivers order.
+func getMergeStrategy(field fmap.Field) (string, error) {
+	strategy, ok := field.GetTag().Lookup("merge")
+	switch {
+	case !ok || strategy == mergeIndex:
+		return mergeIndex, nil
+	case strategy == mergeReplace || strategy == mergeAppend:
+		return strategy, nil
+	}
+	return mergeIndex, fmt.Errorf("%w: unknown merge strategy %q, %s is used", ErrIncorrectTagSettings, strategy, mergeIndex)
+}
+
+// list is []T or []*T section, its items are collected from drivers and assigned by the merge strategy.
+type list struct {
+	field    fmap.Field
+	raw      fmap.Field
+	obj      any
+	strategy string
+	items    []*section
+}
+
+func getItemIndex(item *section) int {
+	index, _ := strconv.Atoi(item.field.(EntryField).GetKey())
+	return index
+}
+
+// apply assigns found items to the slice, it returns false if there are no found items.
+func (l *list) apply() bool {
+	var items []*section
+	lastDriver := -1
+	for _, item := range l.items {
+		if item.found {
+			items = append(items, item)
+			lastDriver = item.driver
+		}
+	}
+	if len(items) == 0 {
+		return false
+	}
+	current := reflect.ValueOf(FieldValue(l.obj, l.raw))
+	typeOf := l.raw.GetType()
+	var result reflect.Value
+	switch l.strategy {
+	case mergeIndex:
+		length := current.Len()
+		for _, item := range items {
+			if index := getItemIndex(item); index >= length {
+				length = index + 1
+			}
+		}
+		result = reflect.MakeSlice(typeOf, length, length)
+		reflect.Copy(result, current)
+		for _, item := range items {
+			result.Index(getItemIndex(item)).Set(item.elem())
+		}
+	case mergeAppend:
+		result = reflect.AppendSlice(reflect.MakeSlice(typeOf, 0, current.Len()+len(items)), current)
+		for _, item := range items {
+			result = reflect.Append(result, item.elem())
+		}
+	case mergeReplace:
+		result = reflect.MakeSlice(typeOf, 0, len(items))
+		for _, item := range items {
+			if item.driver == lastDriver {
+				result = reflect.Append(result, item.elem())
+			}
+		}
+	}
+	setValue(l.obj, l.raw, result.Interface())
+	return true
+}
+
+// configField is the config field with the struct object that holds the field value.
+type configField struct {
+	// field has full struct and tag paths from the config root, it is passed to drivers
+	field fmap.Field
+	// raw is the field of obj storage, it is used to get and set the field value
+	raw     fmap.Field
+	obj     any
+	section *section
+	// value is the value of the virtual field without raw field, i.e. the variant discriminator in documentation
+	value any
+}
+
+func (f *configField) get() any {
+	if f.raw == nil {
+		return f.value
+	}
+	return FieldValue(f.obj, f.raw)
+}
+
+func (f *configField) set(val any) {
+	setValue(f.obj, f.raw, val)
+}
+
+// isPointerSection reports whether the field is a pointer to struct, that is parsed field by field.
+func isPointerSection(field fmap.Field) bool {
+	typeOf := field.GetType()
+	if typeOf.Kind() != reflect.Ptr || typeOf.Elem().Kind() != reflect.Struct {
+		return false
+	}
+	_, encoded := getEncoding(field)
+	return !encoded && !IsDecodable(typeOf)
+}
+
+// isMapSection reports whether the field is map[string]T or map[string]*T of structs, that is parsed entry by entry.
+func isMapSection(field fmap.Field) bool {
+	typeOf := field.GetType()
+	if typeOf.Kind() != reflect.Map || typeOf.Key().Kind() != reflect.String {
+		return false
+	}
+	elemType := typeOf.Elem()
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	_, encoded := getEncoding(field)
+	return elemType.Kind() == reflect.Struct && !encoded && !IsDecodable(typeOf) && !IsDecodable(elemType)
+}
+
+// isSliceSection reports whether the field is []T or []*T of structs, that is parsed item by item.
+func isSliceSection(field fmap.Field) bool {
+	typeOf := field.GetType()
+	if typeOf.Kind() != reflect.Slice {
+		return false
+	}
+	elemType := typeOf.Elem()
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	_, encoded := getEncoding(field)
+	return elemType.Kind() == reflect.Struct && !encoded && !IsDecodable(typeOf) && !IsDecodable(elemType)
+}
+
+// IsSection reports whether the field is a section, i.e. struct, pointer to struct, map[string]T or []T section or its entry,
+// or interface with registered variants.
+func IsSection(field fmap.Field) bool {
+	return !IsValueField(field) && !isDecodablePart(field)
+}
+
+func isAllocAlways(field fmap.Field) bool {
+	return field.GetTag().Get("alloc") == "always"
+}
+
+// getTemplateKey returns the placeholder key of map[string]T section entry for documentation, e.g. <name> for `key:"name"`,
+// the first index is used for []T sections.
+func getTemplateKey(field fmap.Field) string {
+	if field.GetType().Kind() == reflect.Slice {
+		return "0"
+	}
+	if key := field.GetTag().Get("key"); key != "" {
+		return "<" + key + ">"
+	}
+	return "<key>"
+}
+
+// driverKeys are keys of dynamic section entries discovered by the driver with the index.
+type driverKeys struct {
+	driver int
+	keys   []string
+}
+
+// unionKeys returns unique keys of all drivers.
+func unionKeys(groups []driverKeys) []string {
+	var keys []string
+	for _, group := range groups {
+		for _, key := range group.keys {
+			if !slices118.Contains(keys, key) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
+
+// indexKeys returns sorted unique non-negative integer keys.
+func indexKeys(keys []string) []string {
+	var indexes []int
+	for _, key := range keys {
+		index, err := strconv.Atoi(key)
+		if err != nil || index < 0 || slices118.Contains(indexes, index) {
+			continue
+		}
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	result := make([]string, 0, len(indexes))
+	for _, index := range indexes {
+		result = append(result, strconv.Itoa(index))
+	}
+	return result
+}
+
+// expander collects fields of the config including fields of pointer sections and map and slice sections entries.
+type expander struct {
+	// getKeys returns keys of dynamic sections entries by drivers, if it is nil, the template entry is expanded for documentation
+	getKeys func(field fmap.Field) []driverKeys
+	// getVariant returns the variant name by the discriminator field, if it is nil, all variants are expanded for documentation
+	getVariant func(field fmap.Field) (string, bool)
+	// warn is called on incorrect section tags settings, it can be nil
+	warn     func(field fmap.Field, err error)
+	fields   []*configField
+	sections []*section
+}
+
+// expand collects fields of the storage and fields of its sections.
+// Nil sections are allocated, but they are not assigned to the config until applySections call.
+func (e *expander) expand(obj any, storage fmap.Storage, parent *section, wrap func(fmap.Field) fmap.Field) {
+	for _, path := range storage.GetAllPaths() {
+		raw := storage.MustFind(path)
+		field := wrap(raw)
+		e.fields = append(e.fields, &configField{field: field, raw: raw, obj: obj, section: parent})
+		if isDecodablePart(raw) {
+			continue
+		}
+		switch {
+		case isPointerSection(raw):
+			sec := &section{field: field, raw: raw, obj: obj, parent: parent}
+			sec.value = reflect.ValueOf(FieldValue(obj, raw))
+			if sec.value.IsNil() {
+				// recursive types are allocated only once, deeper levels are expanded only while they are configured
+				if parent.hasAllocatedType(sec.value.Type()) {
+					continue
+				}
+				sec.value = reflect.New(raw.GetType().Elem())
+				sec.allocated = true
+			}
+			if isAllocAlways(raw) {
+				sec.markFound()
+			}
+			e.expandSection(sec)
+		case isMapSection(raw):
+			e.expandEntries(obj, raw, field, parent)
+		case isSliceSection(raw):
+			e.expandItems(obj, raw, field, parent)
+		case isVariantSection(raw):
+			e.expandVariants(obj, raw, field, parent)
+		}
+	}
+}
+
+func (e *expander) expandSection(sec *section) {
+	e.expandSectionWith(sec, func(f fmap.Field) fmap.Field {
+		return &sectionField{Field: f, section: sec.field}
+	})
+}
+
+func (e *expander) expandSectionWith(sec *section, wrap func(fmap.Field) fmap.Field) {
+	sectionStorage, err := fmap.GetFrom(sec.value.Interface())
+	if err != nil {
+		return
+	}
+	e.sections = append(e.sections, sec)
+	e.expand(sec.value.Interface(), sectionStorage, sec, wrap)
+}
+
+func (e *expander) expandEntries(obj any, raw, field fmap.Field, parent *section) {
+	mapVal := reflect.ValueOf(FieldValue(obj, raw))
+	var keys []string
+	if e.getKeys == nil {
+		keys = []string{getTemplateKey(raw)}
+	} else {
+		keys = unionKeys(e.getKeys(field))
+		for _, key := range mapVal.MapKeys() {
+			if !slices118.Contains(keys, key.String()) {
+				keys = append(keys, key.String())
+			}
+		}
+		sort.Strings(keys)
+	}
+	elemType := raw.GetType().Elem()
+	isPtr := elemType.Kind() == reflect.Ptr
+	if isPtr {
+		elemType = elemType.Elem()
+	}
+	for _, key := range keys {
+		entry := &entryField{Field: field, key: key}
+		sec := &section{field: entry, raw: raw, obj: obj, parent: parent, entry: true, allocated: true}
+		sec.value = reflect.New(elemType)
+		if existing := mapVal.MapIndex(reflect.ValueOf(key)); existing.IsValid() {
+			if isPtr && !existing.IsNil() {
+				sec.value = existing
+			} else if !isPtr {
+				sec.value.Elem().Set(existing)
+			}
+			sec.found = true
+		}
+		e.fields = append(e.fields, &configField{field: entry, raw: raw, obj: obj, section: parent})
+		e.expandSection(sec)
+	}
+}
+
+func (e *expander) expandItems(obj any, raw, field fmap.Field, parent *section) {
+	strategy, err := getMergeStrategy(raw)
+	if err != nil && e.warn != nil {
+		e.warn(field, err)
+	}
+	l := &list{field: field, raw: raw, obj: obj, strategy: strategy}
+	sliceVal := reflect.ValueOf(FieldValue(obj, raw))
+	var groups []driverKeys
+	switch {
+	case e.getKeys == nil:
+		groups = []driverKeys{{driver: -1, keys: []string{getTemplateKey(raw)}}}
+	case strategy == mergeIndex:
+		keys := unionKeys(e.getKeys(field))
+		for i := 0; i < sliceVal.Len(); i++ {
+			keys = append(keys, strconv.Itoa(i))
+		}
+		groups = []driverKeys{{driver: -1, keys: indexKeys(keys)}}
+	default:
+		for _, group := range e.getKeys(field) {
+			groups = append(groups, driverKeys{driver: group.driver, keys: indexKeys(group.keys)})
+		}
+	}
+	elemType := raw.GetType().Elem()
+	isPtr := elemType.Kind() == reflect.Ptr
+	if isPtr {
+		elemType = elemType.Elem()
+	}
+	for _, group := range groups {
+		for _, key := range group.keys {
+			entry := &entryField{Field: field, key: key}
+			sec := &section{field: entry, raw: raw, obj: obj, parent: parent, entry: true, allocated: true, list: l,
+				driver: group.driver, owned: group.driver >= 0}
+			sec.value = reflect.New(elemType)
+			// existing items are merged with drivers items by index only
+			if index := getItemIndex(sec); group.driver < 0 && index < sliceVal.Len() {
+				existing := sliceVal.Index(index)
+				if isPtr && !existing.IsNil() {
+					sec.value = existing
+				} else if !isPtr {
+					sec.value.Elem().Set(existing)
+				}
+				sec.found = e.getKeys != nil
+			}
+			l.items = append(l.items, sec)
+			e.fields = append(e.fields, &configField{field: entry, raw: raw, obj: obj, section: parent})
+			e.expandSection(sec)
+		}
+	}
+}
+
+// expandVariants expands the section of the variant selected by the discriminator, or the current or default variant.
+// Fields of all variants are expanded for documentation.
+func (e *expander) expandVariants(obj any, raw, field fmap.Field, parent *section) {
+	typeOf := raw.GetType()
+	key, defaultName := getDiscriminator(raw)
+	discriminator := &discriminatorField{Field: field, key: key, names: getVariantNames(typeOf)}
+	current := FieldValue(obj, raw)
+	currentName := getVariantName(typeOf, current)
+	name, found := "", false
+	if e.getVariant != nil {
+		name, found = e.getVariant(discriminator)
+	}
+	if name == "" {
+		name = currentName
+	}
+	if name == "" {
+		name = defaultName
+	}
+	if e.getVariant == nil {
+		value := name
+		if value == "" {
+			value = strings.Join(discriminator.names, "|")
+		}
+		e.fields = append(e.fields, &configField{field: discriminator, obj: obj, section: parent, value: value})
+		for _, variantName := range discriminator.names {
+			sec := e.newVariantSection(obj, raw, field, parent, variantName, current)
+			prefix := "(" + key + "=" + variantName + ")"
+			e.expandSectionWith(sec, func(f fmap.Field) fmap.Field {
+				return &variantField{Field: &sectionField{Field: f, section: field}, prefix: prefix}
+			})
+		}
+		return
+	}
+	if name == "" {
+		return
+	}
+	if _, ok := getVariant(typeOf, name); !ok {
+		if e.warn != nil {
+			e.warn(discriminator, fmt.Errorf("%w: unknown default variant %q", ErrIncorrectTagSettings, name))
+		}
+		return
+	}
+	sec := e.newVariantSection(obj, raw, field, parent, name, current)
+	if found || isAllocAlways(raw) {
+		sec.markFound()
+	}
+	e.expandSection(sec)
+}
+
+// newVariantSection returns the section of the variant, the current value is reused if it has the variant type.
+func (e *expander) newVariantSection(obj any, raw, field fmap.Field, parent *section, name string, current any) *section {
+	variantType, _ := getVariant(raw.GetType(), name)
+	sec := &section{field: field, raw: raw, obj: obj, parent: parent, variant: variantType, allocated: true}
+	currentVal := reflect.ValueOf(current)
+	switch {
+	case !currentVal.IsValid() || currentVal.Type() != variantType:
+		sec.value = reflect.New(derefType(variantType))
+	case variantType.Kind() == reflect.Ptr && !currentVal.IsNil():
+		sec.value = currentVal
+		sec.allocated = false
+	case variantType.Kind() == reflect.Ptr:
+		sec.value = reflect.New(variantType.Elem())
+	default:
+		sec.value = reflect.New(variantType)
+		sec.value.Elem().Set(currentVal)
+		sec.found = true
+	}
+	return sec
+}
+
+// applySections assigns allocated sections with found values to their fields,
+// nested sections are assigned before their parents. It returns struct paths of assigned sections.
+func applySections(sections []*section) []string {
+	var paths []string
+	for i := len(sections) - 1; i >= 0; i-- {
+		sec := sections[i]
+		if sec.list != nil {
+			// items are assigned all together when the first item is reached, nested sections of all items are applied
+			if sec == sec.list.items[0] && sec.list.apply() {
+				paths = append(paths, sec.list.field.GetStructPath())
+			}
+			continue
+		}
+		if !sec.allocated || !sec.found {
+			continue
+		}
+		sec.apply()
+		if sec.entry {
+			// map entries are copied to sub configs with the map field
+			paths = append(paths, ParentOf(sec.field).GetStructPath())
+			continue
+		}
+		paths = append(paths, sec.field.GetStructPath())
+	}
+	return paths
+}
+
+// ConfigField is the config field with its current value.
+type ConfigField struct {
+	Field fmap.Field
+	Value any
+}
+
+// GetFields returns all fields of the config including fields of pointer to struct sections and the template entry of
+// map[string]T sections (see `key` tag) and the first item of []T sections, the fields of nil sections have zero values. Drivers should use it instead of Storage for documentation generation.
+func (r *Registered) GetFields() []ConfigField {
+	e := &expander{}
+	root := r.rootField()
+	e.expand(r.Config, r.Storage, nil, wrapRoot(root))
+	result := make([]ConfigField, 0, len(e.fields)+1)
+	if root != nil {
+		// the root of named config is documented as a section
+		result = append(result, ConfigField{Field: root, Value: r.Config})
+	}
+	for _, field := range e.fields {
+		result = append(result, ConfigField{Field: field.field, Value: field.get()})
+	}
+	return result
+}
@@ -0,0 +1,386 @@
+package tinyconf
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/insei/fmap/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+type defaultsMockDriver struct {
+	tlsMockDriver
+}
+
+func (d *defaultsMockDriver) IsDefaults() bool { return true }
+
+type sectionsTestTLS struct {
+	Cert    string `yaml:"cert"`
+	Version string `yaml:"version"`
+	Client  *struct {
+		CA string `yaml:"ca"`
+	} `yaml:"client"`
+}
+
+type sectionsTestNode struct {
+	Name string
+	Next *sectionsTestNode
+}
+
+type sectionsTestConfig struct {
+	Name   string           `yaml:"name"`
+	TLS    *sectionsTestTLS `yaml:"tls"`
+	Always *struct {
+		Value string
+	} `alloc:"always"`
+	Node *sectionsTestNode
+}
+
+func TestSectionField_Paths(t *testing.T) {
+	e := &expander{getKeys: func(fmap.Field) []driverKeys { return nil }}
+	e.expand(&sectionsTestConfig{}, mustStorage[sectionsTestConfig](), nil, func(f fmap.Field) fmap.Field { return f })
+	byPath := map[string]fmap.Field{}
+	for _, f := range e.fields {
+		byPath[f.field.GetStructPath()] = f.field
+	}
+	assert.Contains(t, byPath, "TLS.Cert")
+	assert.Contains(t, byPath, "TLS.Client.CA")
+	assert.Contains(t, byPath, "Node.Next")
+	assert.NotContains(t, byPath, "Node.Next.Name")
+	assert.Equal(t, "tls.client.ca", byPath["TLS.Client.CA"].GetTagPath("yaml", false))
+	assert.Equal(t, "", byPath["Always.Value"].GetTagPath("yaml", false))
+	assert.Equal(t, "TLS.Client", byPath["TLS.Client.CA"].GetParent().GetStructPath())
+	assert.Equal(t, "TLS", byPath["TLS.Client.CA"].GetParent().GetParent().GetStructPath())
+}
+
+func mustStorage[T any]() fmap.Storage {
+	storage, err := fmap.Get[T]()
+	if err != nil {
+		panic(err)
+	}
+	return storage
+}
+
+func TestManager_ParsePointerSections(t *testing.T) {
+	tests := []struct {
+		name   string
+		values map[string]string
+		assert func(t *testing.T, conf *sectionsTestConfig)
+	}{
+		{
+			name:   "not configured section stays nil",
+			values: map[string]string{"Name": "app"},
+			assert: func(t *testing.T, conf *sectionsTestConfig) {
+				assert.Nil(t, conf.TLS)
+				assert.Nil(t, conf.Node)
+				assert.NotNil(t, conf.Always)
+			},
+		},
+		{
+			name:   "configured section is allocated with defaults",
+			values: map[string]string{"TLS.Cert": "cert.pem"},
+			assert: func(t *testing.T, conf *sectionsTestConfig) {
+				assert.NotNil(t, conf.TLS)
+				assert.Equal(t, "cert.pem", conf.TLS.Cert)
+				assert.Equal(t, "1.2", conf.TLS.Version)
+				assert.Nil(t, conf.TLS.Client)
+			},
+		},
+		{
+			name:   "nested section allocates parents",
+			values: map[string]string{"TLS.Client.CA": "ca.pem", "Node.Name": "first"},
+			assert: func(t *testing.T, conf *sectionsTestConfig) {
+				assert.NotNil(t, conf.TLS)
+				assert.Equal(t, "ca.pem", conf.TLS.Client.CA)
+				assert.Equal(t, "first", conf.Node.Name)
+				assert.Nil(t, conf.Node.Next)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := New(
+				WithDriver(&defaultsMockDriver{tlsMockDriver{values: map[string]string{"TLS.Version": "1.2"}}}),
+				WithDriver(&tlsMockDriver{values: tt.values}),
+			)
+			conf := &sectionsTestConfig{}
+			assert.NoError(t, m.Register(conf))
+			assert.NoError(t, m.Parse(conf))
+			tt.assert(t, conf)
+		})
+	}
+}
+
+func TestManager_ParseExistingPointerSection(t *testing.T) {
+	m, _ := New(WithDriver(&tlsMockDriver{values: map[string]string{"Node.Next.Name": "second"}}))
+	node := &sectionsTestNode{Name: "first"}
+	conf := &sectionsTestConfig{Node: node}
+	assert.NoError(t, m.Register(conf))
+	assert.NoError(t, m.Parse(conf))
+	assert.Same(t, node, conf.Node)
+	assert.Equal(t, "first", conf.Node.Name)
+	assert.Equal(t, "second", conf.Node.Next.Name)
+}
+
+func TestRegistered_GetFields(t *testing.T) {
+	conf := &sectionsTestConfig{TLS: &sectionsTestTLS{Cert: "cert.pem"}}
+	r := &Registered{Storage: mustStorage[sectionsTestConfig](), Config: conf}
+	values := map[string]any{}
+	for _, f := range r.GetFields() {
+		values[f.Field.GetStructPath()] = f.Value
+	}
+	assert.Equal(t, "cert.pem", values["TLS.Cert"])
+	assert.Equal(t, "", values["Always.Value"])
+	assert.Nil(t, conf.Always)
+}
+
+// keysMockDriver discovers map section keys by values struct paths, e.g. DB.main.Host gives main key for DB field.
+type keysMockDriver struct {
+	tlsMockDriver
+}
+
+func (d *keysMockDriver) GetKeys(field fmap.Field) ([]string, error) {
+	prefix := field.GetStructPath() + "."
+	var keys []string
+	for path := range d.values {
+		if strings.HasPrefix(path, prefix) {
+			keys = append(keys, strings.Split(strings.TrimPrefix(path, prefix), ".")[0])
+		}
+	}
+	if len(keys) == 0 {
+		return nil, ErrValueNotFound
+	}
+	return keys, nil
+}
+
+type mapSectionsTestDB struct {
+	Host string `yaml:"host" doc:"database host"`
+	Port int    `yaml:"port"`
+}
+
+type mapSectionsTestConfig struct {
+	DB      map[string]mapSectionsTestDB  `yaml:"db" key:"name"`
+	Replica map[string]*mapSectionsTestDB `yaml:"replica"`
+	Raw     map[string]string             `yaml:"raw"`
+}
+
+func TestManager_ParseMapSections(t *testing.T) {
+	tests := []struct {
+		name   string
+		conf   *mapSectionsTestConfig
+		values map[string]string
+		assert func(t *testing.T, conf *mapSectionsTestConfig)
+	}{
+		{
+			name:   "not configured map stays nil",
+			conf:   &mapSectionsTestConfig{},
+			values: map[string]string{},
+			assert: func(t *testing.T, conf *mapSectionsTestConfig) {
+				assert.Nil(t, conf.DB)
+				assert.Nil(t, conf.Replica)
+			},
+		},
+		{
+			name: "entries are discovered with defaults",
+			conf: &mapSectionsTestConfig{},
+			values: map[string]string{
+				"DB.main.Host":    "main.local",
+				"DB.reports.Host": "reports.local",
+				"DB.reports.Port": "5433",
+				"Replica.ro.Host": "ro.local",
+			},
+			assert: func(t *testing.T, conf *mapSectionsTestConfig) {
+				assert.Equal(t, map[string]mapSectionsTestDB{
+					"main":    {Host: "main.local", Port: 5432},
+					"reports": {Host: "reports.local", Port: 5433},
+				}, conf.DB)
+				assert.Equal(t, &mapSectionsTestDB{Host: "ro.local"}, conf.Replica["ro"])
+			},
+		},
+		{
+			name:   "existing entries are overridden",
+			conf:   &mapSectionsTestConfig{DB: map[string]mapSectionsTestDB{"main": {Host: "old", Port: 1}, "keep": {Host: "keep"}}},
+			values: map[string]string{"DB.main.Host": "new"},
+			assert: func(t *testing.T, conf *mapSectionsTestConfig) {
+				// defaults driver values override existing values as for any other field
+				assert.Equal(t, mapSectionsTestDB{Host: "new", Port: 5432}, conf.DB["main"])
+				assert.Equal(t, mapSectionsTestDB{Host: "keep"}, conf.DB["keep"])
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := New(
+				WithDriver(&defaultsMockDriver{tlsMockDriver{values: map[string]string{"DB.main.Port": "5432"}}}),
+				WithDriver(&keysMockDriver{tlsMockDriver{values: tt.values}}),
+			)
+			assert.NoError(t, m.Register(tt.conf))
+			assert.NoError(t, m.Parse(tt.conf))
+			tt.assert(t, tt.conf)
+		})
+	}
+}
+
+func TestEntryField(t *testing.T) {
+	storage := mustStorage[mapSectionsTestConfig]()
+	entry := &entryField{Field: storage.MustFind("DB"), key: "main"}
+	assert.Equal(t, "DB.main", entry.GetStructPath())
+	assert.Equal(t, "main", entry.GetName())
+	assert.Equal(t, reflect.TypeOf(mapSectionsTestDB{}), entry.GetType())
+	assert.Equal(t, `yaml:"main" key:"main"`, string(entry.GetTag()))
+	assert.Equal(t, "db.main", entry.GetTagPath("yaml", false))
+	assert.True(t, IsSection(entry))
+	assert.True(t, IsSection(storage.MustFind("DB")))
+	assert.False(t, IsSection(storage.MustFind("Raw")))
+}
+
+func TestReplaceTagValues(t *testing.T) {
+	tag := reflect.StructTag(`yaml:"db" env:"DB" doc:"databases \"by\" name"`)
+	assert.Equal(t, reflect.StructTag(`yaml:"main" env:"main" doc:"databases \"by\" name"`), replaceTagValues(tag, "main"))
+}
+
+func TestRegistered_GetFieldsMapTemplate(t *testing.T) {
+	r := &Registered{Storage: mustStorage[mapSectionsTestConfig](), Config: &mapSectionsTestConfig{}}
+	var paths []string
+	for _, f := range r.GetFields() {
+		paths = append(paths, f.Field.GetStructPath())
+	}
+	assert.Contains(t, paths, "DB.<name>.Host")
+	assert.Contains(t, paths, "Replica.<key>.Port")
+}
+
+type sliceSectionsTestServer struct {
+	Host string `yaml:"host"`
+	Port int    `yaml:"port"`
+}
+
+type sliceSectionsTestConfig struct {
+	Servers  []sliceSectionsTestServer  `yaml:"servers"`
+	Replaced []*sliceSectionsTestServer `merge:"replace"`
+	Appended []sliceSectionsTestServer  `merge:"append"`
+	Unknown  []sliceSectionsTestServer  `merge:"unknown"`
+}
+
+func TestManager_ParseSliceSections(t *testing.T) {
+	tests := []struct {
+		name   string
+		conf   *sliceSectionsTestConfig
+		first  map[string]string
+		second map[string]string
+		assert func(t *testing.T, conf *sliceSectionsTestConfig)
+	}{
+		{
+			name: "not configured slices stay nil",
+			conf: &sliceSectionsTestConfig{},
+			assert: func(t *testing.T, conf *sliceSectionsTestConfig) {
+				assert.Nil(t, conf.Servers)
+				assert.Nil(t, conf.Replaced)
+				assert.Nil(t, conf.Appended)
+			},
+		},
+		{
+			name:   "index strategy merges items fields",
+			conf:   &sliceSectionsTestConfig{Servers: []sliceSectionsTestServer{{Host: "a", Port: 1}}},
+			first:  map[string]string{"Servers.0.Port": "80", "Servers.1.Host": "b"},
+			second: map[string]string{"Servers.1.Port": "8080", "Servers.3.Host": "d"},
+			assert: func(t *testing.T, conf *sliceSectionsTestConfig) {
+				assert.Equal(t, []sliceSectionsTestServer{
+					{Host: "a", Port: 80},
+					{Host: "b", Port: 8080},
+					{},
+					{Host: "d", Port: 443},
+				}, conf.Servers)
+			},
+		},
+		{
+			name:   "replace strategy uses the last driver items",
+			conf:   &sliceSectionsTestConfig{Replaced: []*sliceSectionsTestServer{{Host: "old"}}},
+			first:  map[string]string{"Replaced.0.Host": "a", "Replaced.1.Host": "b"},
+			second: map[string]string{"Replaced.0.Port": "8080"},
+			assert: func(t *testing.T, conf *sliceSectionsTestConfig) {
+				assert.Equal(t, []*sliceSectionsTestServer{{Port: 8080}}, conf.Replaced)
+			},
+		},
+		{
+			name:  "replace strategy keeps items of the only driver",
+			conf:  &sliceSectionsTestConfig{},
+			first: map[string]string{"Replaced.0.Host": "a", "Replaced.1.Host": "b"},
+			assert: func(t *testing.T, conf *sliceSectionsTestConfig) {
+				assert.Equal(t, []*sliceSectionsTestServer{{Host: "a"}, {Host: "b"}}, conf.Replaced)
+			},
+		},
+		{
+			name:   "append strategy appends items of all drivers",
+			conf:   &sliceSectionsTestConfig{Appended: []sliceSectionsTestServer{{Host: "existing"}}},
+			first:  map[string]string{"Appended.0.Host": "a", "Appended.2.Host": "c"},
+			second: map[string]string{"Appended.0.Host": "b"},
+			assert: func(t *testing.T, conf *sliceSectionsTestConfig) {
+				assert.Equal(t, []sliceSectionsTestServer{{Host: "existing"}, {Host: "a"}, {Host: "c"}, {Host: "b"}}, conf.Appended)
+			},
+		},
+		{
+			name:  "unknown strategy falls back to index",
+			conf:  &sliceSectionsTestConfig{},
+			first: map[string]string{"Unknown.1.Host": "b"},
+			assert: func(t *testing.T, conf *sliceSectionsTestConfig) {
+				assert.Equal(t, []sliceSectionsTestServer{{}, {Host: "b"}}, conf.Unknown)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := New(
+				WithDriver(&defaultsMockDriver{tlsMockDriver{values: map[string]string{"Servers.3.Port": "443"}}}),
+				WithDriver(&keysMockDriver{tlsMockDriver{values: tt.first}}),
+				WithDriver(&keysMockDriver{tlsMockDriver{values: tt.second}}),
+			)
+			assert.NoError(t, m.Register(tt.conf))
+			assert.NoError(t, m.Parse(tt.conf))
+			tt.assert(t, tt.conf)
+		})
+	}
+}
+
+func TestIndexKeys(t *testing.T) {
+	assert.Equal(t, []string{"0", "2", "10"}, indexKeys([]string{"10", "main", "2", "-1", "0", "2"}))
+}
+
+func TestRegistered_GetFieldsSliceTemplate(t *testing.T) {
+	conf := &sliceSectionsTestConfig{Servers: []sliceSectionsTestServer{{Host: "a"}, {Host: "b"}}}
+	r := &Registered{Storage: mustStorage[sliceSectionsTestConfig](), Config: conf}
+	values := map[string]any{}
+	for _, f := range r.GetFields() {
+		values[f.Field.GetStructPath()] = f.Value
+	}
+	assert.Equal(t, "a", values["Servers.0.Host"])
+	assert.NotContains(t, values, "Servers.1.Host")
+	assert.Contains(t, values, "Replaced.0.Port")
+	assert.True(t, IsSliceEntry(&entryField{Field: r.Storage.MustFind("Servers"), key: "0"}))
+}
+
+func TestSplitWords(t *testing.T) {
+	tests := map[string][]string{
+		"HTTP.MaxBody":  {"HTTP", "Max", "Body"},
+		"APIKey":        {"API", "Key"},
+		"Auth.OAuth2ID": {"Auth", "O", "Auth2", "ID"},
+		"port":          {"port"},
+		"":              nil,
+	}
+	for path, want := range tests {
+		assert.Equal(t, want, SplitWords(path), path)
+	}
+}
+
+func TestIsHiddenField(t *testing.T) {
+	type Config struct {
+		Default string
+		Hidden  string `hidden:"true"`
+		Visible string `hidden:"false"`
+	}
+	storage := mustStorage[Config]()
+	assert.False(t, IsHiddenField(storage.MustFind("Default"), false))
+	assert.True(t, IsHiddenField(storage.MustFind("Default"), true))
+	assert.True(t, IsHiddenField(storage.MustFind("Hidden"), false))
+	assert.False(t, IsHiddenField(storage.MustFind("Visible"), true))
+}
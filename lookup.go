@@ -0,0 +1,217 @@
+package tinyconf
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/insei/fmap/v3"
+
+	"github.com/insei/tinyconf/slices118"
+)
+
+// pathField is the virtual string field of the dotted path, that is not a field of registered configs.
+// Every path segment is an entry, so env driver composes HTTP_PORT key for http.port path,
+// tag paths are the path itself for all tags, e.g. http.port for yaml.
+// Field methods that work with values, e.g. Get and Set, are not available.
+type pathField struct {
+	fmap.Field
+	parent fmap.Field
+	key    string
+	path   string
+}
+
+// newPathField returns the field of the last path segment.
+func newPathField(path string) fmap.Field {
+	var field fmap.Field
+	segments := strings.Split(path, ".")
+	for i, segment := range segments {
+		field = &pathField{parent: field, key: segment, path: strings.Join(segments[:i+1], ".")}
+	}
+	return field
+}
+
+func (f *pathField) GetKey() string {
+	return f.key
+}
+
+func (f *pathField) GetName() string {
+	return f.key
+}
+
+func (f *pathField) GetType() reflect.Type {
+	return typeOfString
+}
+
+func (f *pathField) GetDereferencedType() reflect.Type {
+	return typeOfString
+}
+
+func (f *pathField) GetTag() reflect.StructTag {
+	return ""
+}
+
+func (f *pathField) GetStructPath() string {
+	return f.path
+}
+
+func (f *pathField) GetParent() fmap.Field {
+	return f.parent
+}
+
+func (f *pathField) GetTagPath(string, bool) string {
+	return f.path
+}
+
+func (f *pathField) IsExported() bool {
+	return true
+}
+
+// registers returns configs registered in the manager and its parents, configs registered by type are sorted by type
+// and named configs are sorted by name. Registrations of the manager hide parents registrations of the same type
+// or name, parents registrations are returned as copies without parsed values cache, see getRegistered.
+func (c *Manager) registers() []*Registered {
+	types := c.getRegisteredTypes()
+	registers := make([]*Registered, 0, len(types))
+	for _, typeOf := range types {
+		register, _ := c.getRegistered(typeOf)
+		registers = append(registers, register)
+	}
+	sort.Slice(registers, func(i, j int) bool {
+		return reflect.TypeOf(registers[i].Config).String() < reflect.TypeOf(registers[j].Config).String()
+	})
+	var names []string
+	for m := c; m != nil; m = m.parent {
+		for name := range m.named {
+			if !slices118.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		register, _ := c.getNamedByName(name)
+		registers = append(registers, register)
+	}
+	return registers
+}
+
+// ownRegisters returns configs registered in the manager without parents registrations, see registers.
+func (c *Manager) ownRegisters() []*Registered {
+	own := &Manager{registered: c.registered, named: c.named}
+	return own.registers()
+}
+
+// Lookup returns the value by the struct path (HTTP.Port) or the yaml path (http.port) of registered configs fields.
+// Paths that are not fields of registered configs are resolved by drivers as string values, the value of the driver
+// with the highest priority is returned, e.g. env driver reads HTTP_PORT for http.port. Values set by Set are returned first.
+func (c *Manager) Lookup(path string) (any, bool) {
+	// values are set by any path of the field, e.g. Set("HTTP.Port") is returned by Lookup("http.port")
+	paths := []string{path}
+	if cf, ok := findField(c.registers(), path); ok {
+		paths = append(paths, cf.field.GetStructPath(), cf.field.GetTagPath("yaml", false))
+	}
+	if val, ok := c.lookupOverrides(paths...); ok {
+		return val, true
+	}
+	if val, ok := c.lookupRegistered(path); ok {
+		return val, true
+	}
+	return c.lookupDrivers(path)
+}
+
+// findField returns the field of registers by the struct path or the yaml path.
+func findField(registers []*Registered, path string) (*configField, bool) {
+	for _, register := range registers {
+		// existing map keys, slice items and variants are expanded only
+		e := &expander{
+			getKeys:    func(fmap.Field) []driverKeys { return nil },
+			getVariant: func(fmap.Field) (string, bool) { return "", false },
+		}
+		e.expand(register.Config, register.Storage, nil, wrapRoot(register.rootField()))
+		for _, cf := range e.fields {
+			field := cf.field
+			if !IsValueField(field) || cf.section.isAbsent() {
+				continue
+			}
+			if field.GetStructPath() == path || field.GetTagPath("yaml", false) == path {
+				return cf, true
+			}
+		}
+	}
+	return nil, false
+}
+
+func (c *Manager) lookupRegistered(path string) (any, bool) {
+	// values of parents configs are not returned, because drivers of the child differ
+	cf, ok := findField(c.ownRegisters(), path)
+	if !ok {
+		return nil, false
+	}
+	return cf.get(), true
+}
+
+func (c *Manager) lookupDrivers(path string) (any, bool) {
+	field := newPathField(path)
+	var val any
+	found := false
+	for _, d := range c.getDrivers() {
+		log := c.log.With(
+			LogField("driver", d.GetName()),
+			LogField("path", path))
+		driverValue, err := d.GetValue(field)
+		switch {
+		case errors.Is(err, ErrValueUnset):
+			val, found = nil, false
+		case err != nil:
+			log.Debug("skip", LogField("details", err.Error()))
+		default:
+			val, found = driverValue.Value, true
+		}
+	}
+	return val, found
+}
+
+// lookupAs returns the value by path converted to typeOf, see Lookup.
+func (c *Manager) lookupAs(path string, typeOf reflect.Type) (any, error) {
+	val, ok := c.Lookup(path)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrValueNotFound, path)
+	}
+	val, err := DecodeAny(getDereferencedValue(val), typeOf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert %s value to %s: %w", path, typeOf.String(), err)
+	}
+	return val, nil
+}
+
+// GetString returns the value by path as string, see Lookup. ErrValueNotFound is returned if the value is not found.
+func (c *Manager) GetString(path string) (string, error) {
+	val, err := c.lookupAs(path, typeOfString)
+	if err != nil {
+		return "", err
+	}
+	return val.(string), nil
+}
+
+// GetInt returns the value by path as int, see Lookup. ErrValueNotFound is returned if the value is not found.
+func (c *Manager) GetInt(path string) (int, error) {
+	val, err := c.lookupAs(path, reflect.TypeOf(0))
+	if err != nil {
+		return 0, err
+	}
+	return val.(int), nil
+}
+
+// GetDuration returns the value by path as time.Duration, days are supported, see ParseDuration and Lookup.
+// ErrValueNotFound is returned if the value is not found.
+func (c *Manager) GetDuration(path string) (time.Duration, error) {
+	val, err := c.lookupAs(path, reflect.TypeOf(time.Duration(0)))
+	if err != nil {
+		return 0, err
+	}
+	return val.(time.Duration), nil
+}
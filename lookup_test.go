@@ -0,0 +1,80 @@
+package tinyconf
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager_Lookup(t *testing.T) {
+	type HTTP struct {
+		Port    int           `yaml:"port"`
+		Timeout time.Duration `yaml:"timeout"`
+		TLS     *struct {
+			Cert string `yaml:"cert"`
+		} `yaml:"tls"`
+	}
+	type Config struct {
+		HTTP HTTP                             `yaml:"http"`
+		Keep Duration                         `yaml:"keep"`
+		DB   map[string]struct{ Host string } `yaml:"db"`
+	}
+	type DB struct {
+		Host string `yaml:"host"`
+	}
+	m, _ := New(
+		WithDriver(&tlsMockDriver{values: map[string]string{"plugin.limit": "10", "plugin.name": "first"}}),
+		WithDriver(&tlsMockDriver{values: map[string]string{"plugin.name": "second", "plugin.ttl": "1d"}}),
+	)
+	conf := &Config{HTTP: HTTP{Port: 8080, Timeout: time.Second}, Keep: Duration(time.Hour)}
+	conf.DB = map[string]struct{ Host string }{"main": {Host: "main.local"}}
+	assert.NoError(t, m.Register(conf))
+	assert.NoError(t, m.RegisterNamed("replica", &DB{Host: "replica.local"}))
+
+	tests := []struct {
+		path     string
+		expected any
+		found    bool
+	}{
+		{path: "HTTP.Port", expected: 8080, found: true},
+		{path: "http.port", expected: 8080, found: true},
+		{path: "http.tls.cert", found: false},
+		{path: "DB.main.Host", expected: "main.local", found: true},
+		{path: "replica.host", expected: "replica.local", found: true},
+		{path: "plugin.name", expected: "second", found: true},
+		{path: "plugin.unknown", found: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			val, ok := m.Lookup(tt.path)
+			assert.Equal(t, tt.found, ok)
+			assert.Equal(t, tt.expected, val)
+		})
+	}
+
+	port, err := m.GetInt("http.port")
+	assert.NoError(t, err)
+	assert.Equal(t, 8080, port)
+	limit, err := m.GetInt("plugin.limit")
+	assert.NoError(t, err)
+	assert.Equal(t, 10, limit)
+	_, err = m.GetInt("plugin.name")
+	assert.Error(t, err)
+	_, err = m.GetInt("plugin.unknown")
+	assert.ErrorIs(t, err, ErrValueNotFound)
+
+	name, err := m.GetString("HTTP.Port")
+	assert.NoError(t, err)
+	assert.Equal(t, "8080", name)
+
+	timeout, err := m.GetDuration("http.timeout")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Second, timeout)
+	keep, err := m.GetDuration("keep")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Hour, keep)
+	ttl, err := m.GetDuration("plugin.ttl")
+	assert.NoError(t, err)
+	assert.Equal(t, 24*time.Hour, ttl)
+}
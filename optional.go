@@ -0,0 +1,77 @@
+package tinyconf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Optional is a config value that distinguishes "not configured" from the zero value, e.g. port: 0.
+// It is set by any driver that found the value and it is reset by explicitly unset value, e.g. yaml null.
+type Optional[T any] struct {
+	value T
+	set   bool
+}
+
+// Some returns the set Optional with the value.
+func Some[T any](value T) Optional[T] {
+	return Optional[T]{value: value, set: true}
+}
+
+// IsSet reports whether the value was configured.
+func (o Optional[T]) IsSet() bool {
+	return o.set
+}
+
+// Get returns the value, the zero value of T is returned when the value is not configured.
+func (o Optional[T]) Get() T {
+	return o.value
+}
+
+// GetOr returns the value if it is configured or def otherwise.
+func (o Optional[T]) GetOr(def T) T {
+	if !o.set {
+		return def
+	}
+	return o.value
+}
+
+// optional marks Optional types, see IsNullableField.
+func (o Optional[T]) optional() {}
+
+func (o Optional[T]) String() string {
+	if !o.set {
+		return ""
+	}
+	return fmt.Sprintf("%v", o.value)
+}
+
+func (o *Optional[T]) UnmarshalText(text []byte) error {
+	val, err := Decode(string(text), reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		return err
+	}
+	*o = Some(val.(T))
+	return nil
+}
+
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if !o.set {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.value)
+}
+
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		*o = Optional[T]{}
+		return nil
+	}
+	var val T
+	if err := json.Unmarshal(data, &val); err != nil {
+		return err
+	}
+	*o = Some(val)
+	return nil
+}
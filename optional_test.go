@@ -0,0 +1,120 @@
+package tinyconf
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/insei/fmap/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptional(t *testing.T) {
+	var port Optional[int]
+	assert.False(t, port.IsSet())
+	assert.Equal(t, 0, port.Get())
+	assert.Equal(t, 8080, port.GetOr(8080))
+	assert.Equal(t, "", port.String())
+
+	port = Some(0)
+	assert.True(t, port.IsSet())
+	assert.Equal(t, 0, port.Get())
+	assert.Equal(t, 0, port.GetOr(8080))
+	assert.Equal(t, "0", port.String())
+}
+
+func TestOptional_Decode(t *testing.T) {
+	val, err := Decode("0", reflect.TypeOf(Optional[int]{}))
+	assert.NoError(t, err)
+	assert.Equal(t, Some(0), val)
+
+	_, err = Decode("port", reflect.TypeOf(Optional[int]{}))
+	assert.Error(t, err)
+
+	val, err = DecodeAny(float64(5), reflect.TypeOf(Optional[int]{}))
+	assert.NoError(t, err)
+	assert.Equal(t, Some(5), val)
+
+	val, err = DecodeAny([]any{"a", "b"}, reflect.TypeOf(Optional[[]string]{}))
+	assert.NoError(t, err)
+	assert.Equal(t, Some([]string{"a", "b"}), val)
+}
+
+func TestOptional_JSON(t *testing.T) {
+	var opt Optional[string]
+	assert.NoError(t, json.Unmarshal([]byte(`"value"`), &opt))
+	assert.Equal(t, Some("value"), opt)
+	assert.NoError(t, json.Unmarshal([]byte(`null`), &opt))
+	assert.False(t, opt.IsSet())
+
+	data, err := json.Marshal(Some(42))
+	assert.NoError(t, err)
+	assert.Equal(t, "42", string(data))
+	data, err = json.Marshal(Optional[int]{})
+	assert.NoError(t, err)
+	assert.Equal(t, "null", string(data))
+}
+
+type unsetMockDriver struct {
+	unset map[string]bool
+}
+
+func (d *unsetMockDriver) GenDoc(...*Registered) string { return "" }
+func (d *unsetMockDriver) GetName() string              { return "unset" }
+func (d *unsetMockDriver) GetValue(field fmap.Field) (*Value, error) {
+	if d.unset[field.GetStructPath()] {
+		return nil, ErrValueUnset
+	}
+	return nil, ErrValueNotFound
+}
+
+type unsetTestLogger struct {
+	noopLogger
+	messages []string
+}
+
+func (l *unsetTestLogger) Debug(msg string, _ ...Field) { l.messages = append(l.messages, msg) }
+func (l *unsetTestLogger) With(...Field) Logger         { return l }
+
+func TestManager_ParseUnset(t *testing.T) {
+	type Config struct {
+		Host *string
+		Port Optional[int]
+		Name string
+	}
+	logger := &unsetTestLogger{}
+	m, _ := New(
+		WithLogger(logger),
+		WithDriver(&tlsMockDriver{values: map[string]string{
+			"Host": "localhost",
+			"Port": "0",
+			"Name": "name",
+		}}),
+		WithDriver(&unsetMockDriver{unset: map[string]bool{"Host": true}}),
+	)
+	conf := &Config{}
+	assert.NoError(t, m.Register(conf))
+	assert.NoError(t, m.Parse(conf))
+	assert.Nil(t, conf.Host)
+	assert.True(t, conf.Port.IsSet())
+	assert.Equal(t, 0, conf.Port.Get())
+	assert.Equal(t, "name", conf.Name)
+	assert.Contains(t, logger.messages, "unset")
+	assert.Contains(t, logger.messages, "skip")
+
+	// null values of not nullable fields are skipped
+	m, _ = New(
+		WithDriver(&tlsMockDriver{values: map[string]string{"Name": "name"}}),
+		WithDriver(&unsetMockDriver{unset: map[string]bool{"Name": true}}),
+	)
+	conf = &Config{}
+	assert.NoError(t, m.Register(conf))
+	assert.NoError(t, m.Parse(conf))
+	assert.Equal(t, "name", conf.Name)
+
+	m, _ = New(WithDriver(&unsetMockDriver{unset: map[string]bool{"Port": true}}))
+	conf = &Config{Port: Some(8080)}
+	assert.NoError(t, m.Register(conf))
+	assert.NoError(t, m.Parse(conf))
+	assert.False(t, conf.Port.IsSet())
+}
@@ -0,0 +1,263 @@
+package tinyconf
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/insei/fmap/v3"
+
+	"github.com/insei/tinyconf/slices118"
+)
+
+const overridesSource = "override"
+
+// overridesDriver is the in-memory driver of values set by Manager.Set, it has the highest priority.
+// Values are found by struct paths (HTTP.Port) or yaml paths (http.port) of fields.
+type overridesDriver struct {
+	mu     sync.RWMutex
+	values map[string]any
+}
+
+func (d *overridesDriver) set(path string, value any) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.values == nil {
+		d.values = map[string]any{}
+	}
+	d.values[path] = value
+}
+
+func (d *overridesDriver) unset(path string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.values, path)
+}
+
+func (d *overridesDriver) isEmpty() bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return len(d.values) == 0
+}
+
+func (d *overridesDriver) lookup(paths ...string) (any, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		if val, ok := d.values[path]; ok {
+			return val, true
+		}
+	}
+	return nil, false
+}
+
+func (d *overridesDriver) GetValue(field fmap.Field) (*Value, error) {
+	val, ok := d.lookup(field.GetStructPath(), field.GetTagPath("yaml", false))
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is not overridden", ErrValueNotFound, field.GetStructPath())
+	}
+	val, err := convertOverride(field, val)
+	if err != nil {
+		// values are set explicitly, so they are not skipped
+		return nil, InvalidValue(err)
+	}
+	return &Value{Source: overridesSource, Value: val}, nil
+}
+
+// GetKeys returns keys of map[string]T and []T sections entries of overridden paths.
+func (d *overridesDriver) GetKeys(field fmap.Field) ([]string, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	var keys []string
+	for _, prefix := range []string{field.GetStructPath(), field.GetTagPath("yaml", false)} {
+		if prefix == "" {
+			continue
+		}
+		for path := range d.values {
+			if !strings.HasPrefix(path, prefix+".") {
+				continue
+			}
+			key := strings.Split(strings.TrimPrefix(path, prefix+"."), ".")[0]
+			if !slices118.Contains(keys, key) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w: %s entries are not overridden", ErrValueNotFound, field.GetStructPath())
+	}
+	return keys, nil
+}
+
+func (d *overridesDriver) GetName() string {
+	return overridesSource
+}
+
+func (d *overridesDriver) GenDoc(...*Registered) string {
+	return ""
+}
+
+// convertOverride checks that the value can be assigned to the field, values of the same kind and numbers are converted,
+// e.g. int constant to time.Duration field or int64 value to int field.
+func convertOverride(field fmap.Field, value any) (any, error) {
+	typeOf := field.GetType()
+	if value == nil {
+		switch typeOf.Kind() {
+		case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+			return reflect.Zero(typeOf).Interface(), nil
+		}
+		return nil, fmt.Errorf("override value nil can't be assigned to %s config field of %s type",
+			field.GetStructPath(), typeOf.String())
+	}
+	valOf := reflect.ValueOf(value)
+	if valOf.Type().AssignableTo(typeOf) {
+		return value, nil
+	}
+	if valOf.Kind() == typeOf.Kind() && valOf.Type().ConvertibleTo(typeOf) {
+		return valOf.Convert(typeOf).Interface(), nil
+	}
+	if converted, ok := convertNumber(valOf, typeOf); ok {
+		return converted, nil
+	}
+	return nil, fmt.Errorf("override value %v of %s type can't be assigned to %s config field of %s type",
+		value, valOf.Type().String(), field.GetStructPath(), typeOf.String())
+}
+
+// convertNumber converts the number to the numeric type of other kind, e.g. int64 to int or int to time.Duration.
+// It reports false if the value overflows the type or a float value with a fraction is converted to an integer type.
+func convertNumber(valOf reflect.Value, typeOf reflect.Type) (any, bool) {
+	to := reflect.New(typeOf).Elem()
+	switch {
+	case isIntKind(valOf.Kind()):
+		return setNumber(to, valOf.Int() < 0, uint64(valOf.Int()), float64(valOf.Int()))
+	case isUintKind(valOf.Kind()):
+		return setNumber(to, false, valOf.Uint(), float64(valOf.Uint()))
+	case isFloatKind(valOf.Kind()):
+		f := valOf.Float()
+		if isFloatKind(typeOf.Kind()) {
+			return setNumber(to, f < 0, 0, f)
+		}
+		if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxUint64 {
+			return nil, false
+		}
+		if f < 0 {
+			return setNumber(to, true, uint64(int64(f)), f)
+		}
+		return setNumber(to, false, uint64(f), f)
+	}
+	return nil, false
+}
+
+// setNumber sets the number to the numeric value, u is the two's complement of negative numbers.
+func setNumber(to reflect.Value, negative bool, u uint64, f float64) (any, bool) {
+	switch {
+	case isIntKind(to.Kind()):
+		if !negative && u > math.MaxInt64 || to.OverflowInt(int64(u)) {
+			return nil, false
+		}
+		to.SetInt(int64(u))
+	case isUintKind(to.Kind()):
+		if negative || to.OverflowUint(u) {
+			return nil, false
+		}
+		to.SetUint(u)
+	case isFloatKind(to.Kind()):
+		if to.OverflowFloat(f) {
+			return nil, false
+		}
+		to.SetFloat(f)
+	default:
+		return nil, false
+	}
+	return to.Interface(), true
+}
+
+func isIntKind(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Int64
+}
+
+func isUintKind(kind reflect.Kind) bool {
+	return kind >= reflect.Uint && kind <= reflect.Uintptr
+}
+
+func isFloatKind(kind reflect.Kind) bool {
+	return kind == reflect.Float32 || kind == reflect.Float64
+}
+
+type overridesOption struct {
+	values map[string]any
+}
+
+func (o overridesOption) apply(m *Manager) {
+	for path, value := range o.values {
+		m.getOverrides().set(path, value)
+	}
+}
+
+// WithOverrides sets values of the highest priority by struct paths (HTTP.Port) or yaml paths (http.port), see Manager.Set.
+func WithOverrides(values map[string]any) Option {
+	return overridesOption{values: values}
+}
+
+func (c *Manager) getOverrides() *overridesDriver {
+	if c.overrides == nil {
+		c.overrides = &overridesDriver{}
+	}
+	return c.overrides
+}
+
+// getDrivers returns drivers of the manager in priority order, overrides have the highest priority,
+// overrides of child managers have priority over overrides of their parents.
+func (c *Manager) getDrivers() []Driver {
+	var overrides []Driver
+	for m := c; m != nil; m = m.parent {
+		if m.overrides != nil && !m.overrides.isEmpty() {
+			overrides = append([]Driver{m.overrides}, overrides...)
+		}
+	}
+	if len(overrides) == 0 {
+		return c.drivers
+	}
+	drivers := make([]Driver, 0, len(c.drivers)+len(overrides))
+	return append(append(drivers, c.drivers...), overrides...)
+}
+
+// lookupOverrides returns the value set by Set of the manager or its parents by the first set path of paths.
+func (c *Manager) lookupOverrides(paths ...string) (any, bool) {
+	for m := c; m != nil; m = m.parent {
+		if m.overrides == nil {
+			continue
+		}
+		if val, ok := m.overrides.lookup(paths...); ok {
+			return val, true
+		}
+	}
+	return nil, false
+}
+
+// Set sets the value of the highest priority by the struct path (HTTP.Port) or the yaml path (http.port), it is
+// applied by the next Parse call and returned by Lookup immediately. The value is checked against the type of registered
+// configs field, values of other paths are checked by Parse. Parse logs the value with "override" source.
+func (c *Manager) Set(path string, value any) error {
+	if cf, ok := findField(c.registers(), path); ok {
+		converted, err := convertOverride(cf.field, value)
+		if err != nil {
+			return err
+		}
+		value = converted
+	}
+	c.getOverrides().set(path, value)
+	c.invalidate()
+	return nil
+}
+
+// Unset removes the value set by Set or WithOverrides, the value of drivers is applied by the next Parse call.
+func (c *Manager) Unset(path string) {
+	c.getOverrides().unset(path)
+	c.invalidate()
+}
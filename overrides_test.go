@@ -0,0 +1,166 @@
+package tinyconf
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type overridesTestLogger struct {
+	noopLogger
+	sources []any
+}
+
+func (l *overridesTestLogger) Debug(msg string, fields ...Field) {
+	for _, field := range fields {
+		if msg == "override" && field.Key == "source" {
+			l.sources = append(l.sources, field.Value)
+		}
+	}
+}
+
+func (l *overridesTestLogger) With(...Field) Logger {
+	return l
+}
+
+func TestManager_Overrides(t *testing.T) {
+	type DB struct {
+		Host string `yaml:"host"`
+	}
+	type Config struct {
+		Port    int           `yaml:"port"`
+		Timeout time.Duration `yaml:"timeout"`
+		Name    string
+		DB      map[string]DB `yaml:"db"`
+	}
+	log := &overridesTestLogger{}
+	m, _ := New(
+		WithLogger(log),
+		WithDriver(&tlsMockDriver{values: map[string]string{"Port": "80", "Name": "driver"}}),
+		WithOverrides(map[string]any{"Name": "initial", "db.extra.host": "extra.local"}),
+	)
+	conf := &Config{}
+	assert.NoError(t, m.Register(conf))
+
+	assert.NoError(t, m.Set("port", 8080))
+	assert.NoError(t, m.Set("Timeout", 5*time.Second))
+	assert.NoError(t, m.Set("Timeout", 5))
+	assert.NoError(t, m.Set("port", int64(8080)))
+	assert.ErrorContains(t, m.Set("port", "8080"), "can't be assigned")
+	assert.NoError(t, m.Parse(conf))
+	assert.Equal(t, &Config{Port: 8080, Timeout: 5, Name: "initial",
+		DB: map[string]DB{"extra": {Host: "extra.local"}}}, conf)
+	assert.Contains(t, log.sources, "override")
+
+	val, ok := m.Lookup("Name")
+	assert.True(t, ok)
+	assert.Equal(t, "initial", val)
+	// values are returned by both the struct path and the yaml path of the field
+	val, ok = m.Lookup("Port")
+	assert.True(t, ok)
+	assert.Equal(t, 8080, val)
+	assert.NoError(t, m.Set("Timeout", 7))
+	val, ok = m.Lookup("timeout")
+	assert.True(t, ok)
+	assert.Equal(t, time.Duration(7), val)
+
+	m.Unset("port")
+	m.Unset("Name")
+	assert.NoError(t, m.Parse(conf))
+	assert.Equal(t, 80, conf.Port)
+	assert.Equal(t, "driver", conf.Name)
+
+	assert.Error(t, m.Set("DB.extra.Host", 1))
+	// paths of not existing fields are checked by Parse
+	assert.NoError(t, m.Set("DB.other.Host", 1))
+	err := m.Parse(conf)
+	var fieldErr *FieldError
+	if assert.ErrorAs(t, err, &fieldErr) {
+		assert.Equal(t, "override", fieldErr.Driver)
+	}
+}
+
+func TestConvertOverride(t *testing.T) {
+	type Config struct {
+		Duration Duration
+		Timeout  time.Duration
+		Ptr      *int
+		Int      int
+		Int8     int8
+		Uint     uint
+		Float    float32
+	}
+	storage := mustStorage[Config]()
+	tests := []struct {
+		name    string
+		path    string
+		value   any
+		want    any
+		wantErr bool
+	}{
+		{name: "same kind", path: "Duration", value: time.Second, want: Duration(time.Second)},
+		{name: "nil pointer", path: "Ptr", value: nil, want: (*int)(nil)},
+		{name: "nil int", path: "Int", value: nil, wantErr: true},
+		{name: "int to duration", path: "Timeout", value: 5, want: time.Duration(5)},
+		{name: "int64 to int", path: "Int", value: int64(5), want: 5},
+		{name: "uint to int", path: "Int", value: uint64(5), want: 5},
+		{name: "int to int8 overflow", path: "Int8", value: 128, wantErr: true},
+		{name: "negative int to uint", path: "Uint", value: -1, wantErr: true},
+		{name: "uint64 to int overflow", path: "Int", value: uint64(math.MaxUint64), wantErr: true},
+		{name: "float to int", path: "Int", value: 2.0, want: 2},
+		{name: "float with fraction to int", path: "Int", value: 2.5, wantErr: true},
+		{name: "int to float", path: "Float", value: 2, want: float32(2)},
+		{name: "float64 to float32 overflow", path: "Float", value: math.MaxFloat64, wantErr: true},
+		{name: "string to int", path: "Int", value: "5", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			val, err := convertOverride(storage.MustFind(tt.path), tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, val)
+		})
+	}
+}
+
+func TestManager_OverridesInvalidateParsed(t *testing.T) {
+	type HTTP struct {
+		Port int
+	}
+	type Config struct {
+		HTTP HTTP
+	}
+	m, _ := New(WithDriver(&tlsMockDriver{values: map[string]string{"HTTP.Port": "1"}}))
+	conf := &Config{}
+	assert.NoError(t, m.Register(conf))
+	assert.NoError(t, m.Parse(conf))
+
+	assert.NoError(t, m.Set("HTTP.Port", 2))
+	http := &HTTP{}
+	assert.NoError(t, m.Parse(http))
+	assert.Equal(t, 2, http.Port)
+
+	m.Unset("HTTP.Port")
+	http = &HTTP{}
+	assert.NoError(t, m.Parse(http))
+	assert.Equal(t, 1, http.Port)
+
+	// overrides of the parent are applied to sub configs of the child registrations
+	child, _ := m.Child()
+	type Other struct {
+		HTTP HTTP
+	}
+	other := &Other{}
+	assert.NoError(t, child.Register(other))
+	assert.NoError(t, child.Parse(other))
+	assert.NoError(t, m.Set("HTTP.Port", 3))
+	http = &HTTP{}
+	assert.NoError(t, child.ParseSub(http, reflect.TypeOf(other), "HTTP"))
+	assert.Equal(t, 3, http.Port)
+}
@@ -4,20 +4,37 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
+	"sync/atomic"
 
 	"github.com/insei/fmap/v3"
+
+	"github.com/insei/tinyconf/slices118"
 )
 
 type Registered struct {
 	Storage fmap.Storage
 	Config  any
+	// Name is the name of the config registered by RegisterNamed, it is empty for configs registered by Register
+	Name string
+	// parsed is the last config instance parsed by Parse, its values found by drivers are copied to sub configs
+	// by parsedPaths while parsedRevision is the revision of the manager, see Manager.revision
+	parsed         any
+	parsedPaths    []string
+	parsedRevision uint64
 }
 
 type Manager struct {
 	drivers    []Driver
 	log        Logger
 	registered map[reflect.Type]*Registered
+	named      map[string]*Registered
+	overrides  *overridesDriver
+	// parent is the manager of the child manager, see Child
+	parent *Manager
+	// revision is increased when values of drivers are changed, e.g. by Set, so the cache of parsed configs is reset
+	revision uint64
 }
 
 func checkConfig(conf any) error {
@@ -40,6 +57,10 @@ func (c *Manager) Register(conf any) error {
 	if err != nil {
 		return fmt.Errorf("config can't be registred: %w", err)
 	}
+	if _, ok := c.registered[reflect.TypeOf(conf)]; ok {
+		return fmt.Errorf("config can't be registred: %s config is already registered, use RegisterNamed for several configs of the same type",
+			reflect.TypeOf(conf).String())
+	}
 
 	storage, err := fmap.GetFrom(conf)
 	if err != nil || storage == nil {
@@ -52,6 +73,68 @@ func (c *Manager) Register(conf any) error {
 	return nil
 }
 
+// RegisterNamed registers the config by the name, several configs of the same type can be registered by different names.
+// The name is the root of the config fields paths: the struct path, the yaml subtree and the env prefix,
+// e.g. primary.Host, primary.host and PRIMARY_HOST for RegisterNamed("primary", &db).
+func (c *Manager) RegisterNamed(name string, conf any) error {
+	if name == "" {
+		return fmt.Errorf("config can't be registred: name is empty")
+	}
+	err := checkConfig(conf)
+	if err != nil {
+		return fmt.Errorf("config %s can't be registred: %w", name, err)
+	}
+	if _, ok := c.named[name]; ok {
+		return fmt.Errorf("config %s can't be registred: name is already registered", name)
+	}
+	storage, err := fmap.GetFrom(conf)
+	if err != nil || storage == nil {
+		return fmt.Errorf("config %s can't be registred: %w", name, err)
+	}
+	if c.named == nil {
+		c.named = map[string]*Registered{}
+	}
+	c.named[name] = &Registered{
+		Storage: storage,
+		Config:  conf,
+		Name:    name,
+	}
+	return nil
+}
+
+// getNamed returns the named config registered in the manager or its parents by the config pointer, parents
+// registrations are returned as copies without parsed values cache, see getRegistered.
+func (c *Manager) getNamed(conf any) (*Registered, bool) {
+	for _, register := range c.named {
+		if register.Config == conf {
+			return register, true
+		}
+	}
+	if c.parent == nil {
+		return nil, false
+	}
+	register, ok := c.parent.getNamed(conf)
+	if !ok {
+		return nil, false
+	}
+	return &Registered{Storage: register.Storage, Config: register.Config, Name: register.Name}, true
+}
+
+// getNamedByName returns the named config registered in the manager or its parents by the name, see getNamed.
+func (c *Manager) getNamedByName(name string) (*Registered, bool) {
+	if register, ok := c.named[name]; ok {
+		return register, true
+	}
+	if c.parent == nil {
+		return nil, false
+	}
+	register, ok := c.parent.getNamedByName(name)
+	if !ok {
+		return nil, false
+	}
+	return &Registered{Storage: register.Storage, Config: register.Config, Name: register.Name}, true
+}
+
 func getDereferencedValue(val any) any {
 	valOf := reflect.ValueOf(val)
 	for valOf.IsValid() && valOf.Kind() == reflect.Ptr {
@@ -66,12 +149,129 @@ func getDereferencedValue(val any) any {
 func getLoggerValue(field fmap.Field, val any) string {
 	derefDriverValue := getDereferencedValue(val)
 	valueLog := fmt.Sprintf("%v", derefDriverValue)
-	if hidden, ok := field.GetTag().Lookup("hidden"); ok && hidden == "true" {
+	if IsHiddenField(field, false) {
 		valueLog = strings.Repeat("*", len(fmt.Sprintf("%s", valueLog)))
 	}
 	return valueLog
 }
 
+// getDriverLoggerValue returns the driver value for logs, it is masked if the field or the driver value is hidden.
+func getDriverLoggerValue(field fmap.Field, driverValue *Value) string {
+	valueLog := getLoggerValue(field, driverValue.Value)
+	if driverValue.Hidden {
+		valueLog = strings.Repeat("*", len(valueLog))
+	}
+	return valueLog
+}
+
+// ParentOf returns the parent field or nil for root fields, use it instead of GetParent method,
+// because fmap returns typed nil interface for root fields.
+func ParentOf(field fmap.Field) fmap.Field {
+	parent := field.GetParent()
+	if parent == nil {
+		return nil
+	}
+	if valOf := reflect.ValueOf(parent); valOf.Kind() == reflect.Ptr && valOf.IsNil() {
+		return nil
+	}
+	return parent
+}
+
+// IsValueField reports whether the field holds a config value, i.e. it is not a struct, pointer to struct, map, slice
+// or variant section
+// and it is not a part of the struct value decoded as a whole (see IsDecodable).
+// Struct sections with `encoding` tag are values too, they are decoded as a whole by DecodeField.
+func IsValueField(field fmap.Field) bool {
+	_, encoded := getEncoding(field)
+	if field.GetType().Kind() == reflect.Struct && !IsDecodable(field.GetType()) && !encoded {
+		return false
+	}
+	return !isPointerSection(field) && !isMapSection(field) && !isSliceSection(field) && !isVariantSection(field) &&
+		!isDecodablePart(field)
+}
+
+// isDecodablePart reports whether the field is a part of the struct value decoded as a whole.
+func isDecodablePart(field fmap.Field) bool {
+	for parent := ParentOf(field); parent != nil; parent = ParentOf(parent) {
+		if IsDecodable(parent.GetType()) {
+			return true
+		}
+	}
+	return false
+}
+
+// getFieldValue returns reflect value of the field in conf, for struct and interface kind fields it is used
+// instead of fmap.Field Get/Set, because fmap offsets of nested struct fields are relative to their parent.
+func getFieldValue(conf any, field fmap.Field) reflect.Value {
+	var fields []fmap.Field
+	for f := field; f != nil; f = ParentOf(f) {
+		fields = append(fields, f)
+	}
+	valOf := reflect.ValueOf(conf)
+	if valOf.Kind() == reflect.Ptr {
+		valOf = valOf.Elem()
+	}
+	for i := len(fields) - 1; i >= 0; i-- {
+		valOf = valOf.FieldByIndex(fields[i].GetIndex())
+	}
+	return valOf
+}
+
+func isReflectKind(field fmap.Field) bool {
+	kind := field.GetType().Kind()
+	return kind == reflect.Struct || kind == reflect.Interface
+}
+
+// FieldValue returns the value of the field in conf, it is safe for struct kind fields of nested structs.
+// The dynamic value is returned for interface fields.
+func FieldValue(conf any, field fmap.Field) any {
+	if isReflectKind(field) {
+		if valOf := getFieldValue(conf, field); valOf.CanInterface() {
+			return valOf.Interface()
+		}
+	}
+	val := field.Get(conf)
+	// fmap returns values of named types as values of their underlying types, e.g. int64 for time.Duration
+	if valOf := reflect.ValueOf(val); valOf.IsValid() && valOf.Type() != field.GetType() &&
+		valOf.Type().ConvertibleTo(field.GetType()) {
+		return valOf.Convert(field.GetType()).Interface()
+	}
+	return val
+}
+
+func setValue(conf any, field fmap.Field, val any) {
+	if isReflectKind(field) {
+		getFieldValue(conf, field).Set(reflect.ValueOf(val))
+		return
+	}
+	// fmap sets values of named types of basic kinds by their underlying types, e.g. int64 for time.Duration
+	if valOf := reflect.ValueOf(val); valOf.IsValid() {
+		if basicType := basicTypes[valOf.Kind()]; basicType != nil && valOf.Type() != basicType {
+			val = valOf.Convert(basicType).Interface()
+		}
+	}
+	field.Set(conf, val)
+}
+
+func isEqualValues(a, b any) bool {
+	typeOfA, typeOfB := reflect.TypeOf(a), reflect.TypeOf(b)
+	if typeOfA != typeOfB {
+		return false
+	}
+	if typeOfA == nil || typeOfA.Comparable() {
+		return a == b
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// getValueSource returns the driver value source, extended by the value own source if it is loaded from elsewhere.
+func getValueSource(driverValue *Value) string {
+	if sourcer, ok := driverValue.Value.(Sourcer); ok && sourcer.Source() != "" {
+		return driverValue.Source + " (" + sourcer.Source() + ")"
+	}
+	return driverValue.Source
+}
+
 func copyToSubConfig(conf, subConf any, subpath string, parsedPaths []string) error {
 	confFields, err := fmap.GetFrom(conf)
 	if err != nil {
@@ -81,61 +281,166 @@ func copyToSubConfig(conf, subConf any, subpath string, parsedPaths []string) er
 	if err != nil {
 		return err
 	}
+	// pointer to struct section is copied as a whole, because fmap doesn't provide fields of pointed structs
+	if field := confFields.MustFind(subpath); field.GetType().Kind() == reflect.Ptr {
+		valOf := reflect.ValueOf(FieldValue(conf, field))
+		if !valOf.IsNil() && slices118.Contains(parsedPaths, subpath) {
+			reflect.ValueOf(subConf).Elem().Set(valOf.Elem())
+		}
+		return nil
+	}
 	for _, path := range confFields.GetAllPaths() {
 		// exclude paths that is not parsed by tinyconf drivers
-		skip := true
-		for _, parsedPath := range parsedPaths {
-			if path == parsedPath {
-				skip = false
-				break
-			}
-		}
-		if skip || path == subpath || !strings.HasPrefix(path, subpath) {
+		if !slices118.Contains(parsedPaths, path) || !strings.HasPrefix(path, subpath+".") {
 			continue
 		}
 		field := confFields.MustFind(path)
-		subFieldPath := strings.Replace(path, subpath+".", "", -1)
+		subFieldPath := strings.TrimPrefix(path, subpath+".")
 		subField, ok := subConfFields.Find(subFieldPath)
 		if !ok {
 			return fmt.Errorf("subconf field %s not found", path)
 		}
-		subField.Set(subConf, field.Get(conf))
+		setValue(subConf, subField, FieldValue(conf, field))
 	}
 	return nil
 }
 
-func (c *Manager) Parse(conf any) (err error) {
-	confParse := conf
+// findSubConfig returns the registered config type and the struct path of the field with subTypeOf struct type.
+// ErrAmbiguousConfig is returned if there are several such fields.
+func (c *Manager) findSubConfig(subTypeOf reflect.Type) (reflect.Type, string, error) {
+	var parentTypeOf reflect.Type
+	var matches []string
+	for _, registeredTypeOf := range c.getRegisteredTypes() {
+		registeredConf, _ := c.getRegistered(registeredTypeOf)
+		for _, path := range registeredConf.Storage.GetAllPaths() {
+			fieldType := registeredConf.Storage.MustFind(path).GetDereferencedType()
+			if fieldType.Kind() == reflect.Struct && reflect.PointerTo(fieldType) == subTypeOf {
+				parentTypeOf = registeredTypeOf
+				matches = append(matches, registeredTypeOf.String()+" "+path)
+			}
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, "", ErrNotRegisteredConfig
+	case 1:
+		return parentTypeOf, strings.TrimPrefix(matches[0], parentTypeOf.String()+" "), nil
+	}
+	sort.Strings(matches)
+	return nil, "", fmt.Errorf("%w: %s is found at %s, use ParseSub", ErrAmbiguousConfig, subTypeOf.String(),
+		strings.Join(matches, ", "))
+}
+
+// Parse parses the registered config or the sub config, i.e. the struct type of the registered config field,
+// see ParseSub.
+func (c *Manager) Parse(conf any) error {
 	confTypeOf := reflect.TypeOf(conf)
-	register, ok := c.registered[confTypeOf]
-	parsedPaths := make([]string, 0)
+	register, ok := c.getNamed(conf)
 	if !ok {
-	RegisteredLoop:
-		for registeredTypeOf, registeredConf := range c.registered {
-			for _, path := range registeredConf.Storage.GetAllPaths() {
-				field := registeredConf.Storage.MustFind(path)
-				fieldType := field.GetDereferencedType()
-				if fieldType.Kind() == reflect.Struct &&
-					reflect.PointerTo(fieldType) == confTypeOf {
-					register = registeredConf
-					confParse = reflect.New(registeredTypeOf.Elem()).Interface()
-					defer func() {
-						err = copyToSubConfig(confParse, conf, field.GetStructPath(), parsedPaths)
-					}()
-					break RegisteredLoop
-				}
-			}
+		register, ok = c.getRegistered(confTypeOf)
+	}
+	if !ok {
+		parentTypeOf, path, err := c.findSubConfig(confTypeOf)
+		if err != nil {
+			return err
 		}
+		return c.ParseSub(conf, parentTypeOf, path)
 	}
-	if register == nil {
+	revision := c.getRevision()
+	parsedPaths, err := c.parse(register, conf)
+	if err != nil {
+		return err
+	}
+	register.parsed, register.parsedPaths, register.parsedRevision = conf, parsedPaths, revision
+	return nil
+}
+
+// ParseSub parses the sub config at the struct path of the registered parent config type, e.g. "HTTP.Auth".
+// The sub config is filled from the registered parent config without drivers querying if the parent is already parsed
+// and values are not changed since then by Set, Unset or drivers changes, otherwise the new parent config is parsed and only sub config values found by drivers are copied.
+func (c *Manager) ParseSub(subConf any, parentTypeOf reflect.Type, path string) error {
+	if err := checkConfig(subConf); err != nil {
+		return fmt.Errorf("sub config can't be parsed: %w", err)
+	}
+	if parentTypeOf.Kind() != reflect.Ptr {
+		parentTypeOf = reflect.PointerTo(parentTypeOf)
+	}
+	register, ok := c.getRegistered(parentTypeOf)
+	if !ok {
 		return ErrNotRegisteredConfig
 	}
-	for _, d := range c.drivers {
-		for _, path := range register.Storage.GetAllPaths() {
-			field := register.Storage.MustFind(path)
-			if field.GetType().Kind() == reflect.Struct {
+	field, ok := register.Storage.Find(path)
+	if !ok {
+		return fmt.Errorf("field %s is not found in %s config", path, parentTypeOf.String())
+	}
+	if reflect.PointerTo(field.GetDereferencedType()) != reflect.TypeOf(subConf) {
+		return fmt.Errorf("field %s of %s config has %s type, but sub config has %s type",
+			path, parentTypeOf.String(), field.GetType().String(), reflect.TypeOf(subConf).String())
+	}
+	if register.parsed != nil && register.parsedRevision == c.getRevision() {
+		return copyToSubConfig(register.parsed, subConf, path, register.parsedPaths)
+	}
+	parent := reflect.New(parentTypeOf.Elem()).Interface()
+	parsedPaths, err := c.parse(register, parent)
+	if err != nil {
+		return err
+	}
+	return copyToSubConfig(parent, subConf, path, parsedPaths)
+}
+
+// invalidate resets the cache of parsed configs of the manager and its children, the next ParseSub call queries drivers.
+func (c *Manager) invalidate() {
+	atomic.AddUint64(&c.revision, 1)
+}
+
+// getRevision returns the sum of the manager and its parents revisions, it is increased by changes of any of them.
+func (c *Manager) getRevision() uint64 {
+	var revision uint64
+	for m := c; m != nil; m = m.parent {
+		revision += atomic.LoadUint64(&m.revision)
+	}
+	return revision
+}
+
+// parse parses conf of the registered type by all drivers, it returns struct paths of fields found by drivers.
+func (c *Manager) parse(register *Registered, conf any) ([]string, error) {
+	confTypeOf := reflect.TypeOf(conf)
+	// drivers indexes are used by slices sections, so the same drivers are used for keys and values
+	drivers := c.getDrivers()
+	parsedPaths := make([]string, 0)
+	var fieldErr *FieldError
+	e := &expander{
+		getKeys: func(field fmap.Field) []driverKeys {
+			return c.getKeys(confTypeOf, drivers, field)
+		},
+		getVariant: func(field fmap.Field) (string, bool) {
+			name, err := c.getVariant(confTypeOf, field)
+			if err != nil && fieldErr == nil {
+				fieldErr = err
+			}
+			return name, name != ""
+		},
+		warn: func(field fmap.Field, err error) {
+			c.log.Warn("ignore",
+				LogField("config", confTypeOf.String()),
+				LogField("field", field.GetStructPath()),
+				LogField("details", err.Error()))
+		},
+	}
+	e.expand(conf, register.Storage, nil, wrapRoot(register.rootField()))
+	for i, d := range drivers {
+		defaults, isDefaults := d.(DefaultsDriver)
+		isDefaults = isDefaults && defaults.IsDefaults()
+		for _, cf := range e.fields {
+			field := cf.field
+			if !IsValueField(field) {
 				continue
 			}
+			// slice items collected by replace and append strategies get values only from their drivers
+			if owner, ok := cf.section.owner(); ok && owner != i && !isDefaults {
+				continue
+			}
+			path := field.GetStructPath()
 			log := c.log.With(
 				LogField("config", confTypeOf.String()),
 				LogField("driver", d.GetName()),
@@ -144,31 +449,111 @@ func (c *Manager) Parse(conf any) (err error) {
 			switch {
 			case errors.Is(err, ErrIncorrectTagSettings):
 				log.Warn("ignore", LogField("details", err.Error()))
-			case errors.Is(err, ErrValueNotFound):
+			case errors.Is(err, ErrValueNotFound),
+				errors.Is(err, ErrValueUnset) && !IsNullableField(field):
 				log.Debug("skip", LogField("details", err.Error()))
-			case err != nil &&
-				!errors.Is(err, ErrValueNotFound) &&
-				!errors.Is(err, ErrIncorrectTagSettings):
-				c.log.Error("failed", LogField("details", err.Error()))
-			case err == nil:
-				currentValue := field.Get(confParse)
-				if currentValue != driverValue.Value {
-					log.Debug("override", LogField("value", getLoggerValue(field, driverValue.Value)))
-					field.Set(confParse, driverValue.Value)
-					// only for sub configs
+			case errors.Is(err, ErrValueUnset):
+				zeroValue := reflect.Zero(field.GetType()).Interface()
+				if !isEqualValues(cf.get(), zeroValue) {
+					log.Debug("unset", LogField("details", err.Error()))
+					cf.set(zeroValue)
 					parsedPaths = append(parsedPaths, path)
 				}
+			case errors.Is(err, ErrInvalidValue):
+				log.Error("failed", LogField("details", err.Error()))
+				if fieldErr == nil {
+					fieldErr = &FieldError{Path: path, Driver: d.GetName(), Err: err}
+				}
+			case err != nil:
+				log.Error("failed", LogField("details", err.Error()))
+			case err == nil:
+				if !isDefaults {
+					cf.section.markFound()
+				}
+				// only for sub configs
+				parsedPaths = append(parsedPaths, path)
+				currentValue := cf.get()
+				if !isEqualValues(currentValue, driverValue.Value) {
+					log.Debug("override",
+						LogField("value", getDriverLoggerValue(field, driverValue)),
+						LogField("source", getValueSource(driverValue)))
+					cf.set(driverValue.Value)
+				}
 			}
 		}
 	}
-	return nil
+	parsedPaths = append(parsedPaths, applySections(e.sections)...)
+	if fieldErr != nil {
+		return parsedPaths, fieldErr
+	}
+	return parsedPaths, nil
 }
 
-func (c *Manager) GenDoc(driverName string) string {
-	var registers []*Registered
-	for _, register := range c.registered {
-		registers = append(registers, register)
+// getKeys returns keys of map[string]T and []T sections entries discovered by drivers.
+func (c *Manager) getKeys(confTypeOf reflect.Type, drivers []Driver, field fmap.Field) []driverKeys {
+	var keys []driverKeys
+	for i, d := range drivers {
+		keysDriver, ok := d.(KeysDriver)
+		if !ok {
+			continue
+		}
+		fieldKeys, err := keysDriver.GetKeys(field)
+		if err != nil {
+			c.log.Debug("no keys",
+				LogField("config", confTypeOf.String()),
+				LogField("driver", d.GetName()),
+				LogField("field", field.GetStructPath()),
+				LogField("details", err.Error()))
+			continue
+		}
+		keys = append(keys, driverKeys{driver: i, keys: fieldKeys})
+	}
+	return keys
+}
+
+// getVariant returns the variant name by the discriminator field value of drivers, the last found value wins.
+// Defaults drivers are not consulted, the default variant is set by `variant` tag.
+func (c *Manager) getVariant(confTypeOf reflect.Type, field fmap.Field) (string, *FieldError) {
+	var name string
+	for _, d := range c.getDrivers() {
+		if defaults, ok := d.(DefaultsDriver); ok && defaults.IsDefaults() {
+			continue
+		}
+		path := field.GetStructPath()
+		log := c.log.With(
+			LogField("config", confTypeOf.String()),
+			LogField("driver", d.GetName()),
+			LogField("field", path))
+		driverValue, err := d.GetValue(field)
+		switch {
+		case errors.Is(err, ErrIncorrectTagSettings):
+			log.Warn("ignore", LogField("details", err.Error()))
+			continue
+		case errors.Is(err, ErrValueNotFound), errors.Is(err, ErrValueUnset):
+			log.Debug("skip", LogField("details", err.Error()))
+			continue
+		case errors.Is(err, ErrInvalidValue):
+			log.Error("failed", LogField("details", err.Error()))
+			return "", &FieldError{Path: path, Driver: d.GetName(), Err: err}
+		case err != nil:
+			log.Error("failed", LogField("details", err.Error()))
+			continue
+		}
+		value := fmt.Sprintf("%v", getDereferencedValue(driverValue.Value))
+		if _, ok := getVariant(ParentOf(field).GetType(), value); !ok {
+			err = fmt.Errorf("unknown variant %q, expected one of: %s", value,
+				strings.Join(getVariantNames(ParentOf(field).GetType()), ", "))
+			log.Error("failed", LogField("details", err.Error()))
+			return "", &FieldError{Path: path, Driver: d.GetName(), Err: err}
+		}
+		log.Debug("variant", LogField("value", value), LogField("source", getValueSource(driverValue)))
+		name = value
 	}
+	return name, nil
+}
+
+func (c *Manager) GenDoc(driverName string) string {
+	registers := c.registers()
 
 	var doc string
 	for _, driver := range c.drivers {
@@ -193,6 +578,10 @@ func New(opts ...Option) (*Manager, error) {
 			opt.apply(m)
 		case loggerOption:
 			opt.apply(m)
+		case variantOption:
+			opt.apply(m)
+		case overridesOption:
+			opt.apply(m)
 		}
 	}
 	return m, nil
@@ -2,6 +2,8 @@ package tinyconf
 
 import (
 	"errors"
+	"fmt"
+	"os"
 	"reflect"
 	"testing"
 
@@ -99,6 +101,44 @@ func TestManager_Register(t *testing.T) {
 	}
 }
 
+func TestManager_RegisterNamed(t *testing.T) {
+	type DB struct {
+		Host string `yaml:"host" env:"HOST" doc:"db host"`
+		TLS  *struct {
+			CA string `yaml:"ca"`
+		} `yaml:"tls"`
+	}
+	m, _ := New(WithDriver(&tlsMockDriver{values: map[string]string{
+		"primary.Host":   "primary.local",
+		"replica.Host":   "replica.local",
+		"replica.TLS.CA": "ca.pem",
+	}}))
+	primary, replica := &DB{}, &DB{}
+	assert.NoError(t, m.RegisterNamed("primary", primary))
+	assert.NoError(t, m.RegisterNamed("replica", replica))
+	assert.ErrorContains(t, m.RegisterNamed("primary", &DB{}), "already registered")
+	assert.Error(t, m.RegisterNamed("", &DB{}))
+	assert.Error(t, m.RegisterNamed("invalid", DB{}))
+
+	assert.NoError(t, m.Register(&DB{}))
+	assert.ErrorContains(t, m.Register(&DB{}), "use RegisterNamed")
+
+	assert.NoError(t, m.Parse(primary))
+	assert.NoError(t, m.Parse(replica))
+	assert.Equal(t, "primary.local", primary.Host)
+	assert.Nil(t, primary.TLS)
+	assert.Equal(t, "replica.local", replica.Host)
+	assert.Equal(t, "ca.pem", replica.TLS.CA)
+
+	fields := map[string]fmap.Field{}
+	for _, f := range m.named["replica"].GetFields() {
+		fields[f.Field.GetStructPath()] = f.Field
+	}
+	assert.Equal(t, "replica.tls.ca", fields["replica.TLS.CA"].GetTagPath("yaml", false))
+	assert.Equal(t, `yaml:"replica" env:"replica"`, string(fields["replica"].GetTag()))
+	assert.True(t, IsDynamicField(fields["replica.Host"]))
+}
+
 func TestGetDereferencedValue(t *testing.T) {
 	type test struct {
 		name string
@@ -207,6 +247,16 @@ func TestGetLoggerValue(t *testing.T) {
 	}
 }
 
+func TestGetDriverLoggerValue(t *testing.T) {
+	storage, _ := fmap.Get[struct {
+		Test   string
+		Hidden string `hidden:"true"`
+	}]()
+	assert.Equal(t, "secret", getDriverLoggerValue(storage.MustFind("Test"), &Value{Value: "secret"}))
+	assert.Equal(t, "******", getDriverLoggerValue(storage.MustFind("Test"), &Value{Value: "secret", Hidden: true}))
+	assert.Equal(t, "******", getDriverLoggerValue(storage.MustFind("Hidden"), &Value{Value: "secret", Hidden: true}))
+}
+
 func TestNew(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -381,6 +431,97 @@ func TestManager_ParseSubConfig(t *testing.T) {
 		})
 	}
 }
+
+// countingMockDriver counts GetValue calls of tlsMockDriver.
+type countingMockDriver struct {
+	tlsMockDriver
+	calls int
+}
+
+func (d *countingMockDriver) GetValue(field fmap.Field) (*Value, error) {
+	d.calls++
+	return d.tlsMockDriver.GetValue(field)
+}
+
+func TestManager_ParseSub(t *testing.T) {
+	type Auth struct {
+		Issuer string
+		Alg    string
+	}
+	type TLS struct {
+		Cert string
+	}
+	type HTTP struct {
+		Auth  Auth
+		Admin Auth
+		TLS   *TLS
+	}
+	type Config struct {
+		HTTP HTTP
+	}
+	values := map[string]string{
+		"HTTP.Auth.Issuer":  "me",
+		"HTTP.Admin.Issuer": "admin",
+		"HTTP.TLS.Cert":     "cert.pem",
+	}
+
+	t.Run("not parsed parent", func(t *testing.T) {
+		m, _ := New(WithDriver(&tlsMockDriver{values: values}))
+		conf := &Config{}
+		assert.NoError(t, m.Register(conf))
+		auth := &Auth{Alg: "RS256"}
+		assert.NoError(t, m.ParseSub(auth, reflect.TypeOf(Config{}), "HTTP.Admin"))
+		assert.Equal(t, &Auth{Issuer: "admin", Alg: "RS256"}, auth)
+		assert.Equal(t, &Config{}, conf)
+	})
+
+	t.Run("cached parent", func(t *testing.T) {
+		d := &countingMockDriver{tlsMockDriver: tlsMockDriver{values: values}}
+		m, _ := New(WithDriver(d))
+		conf := &Config{}
+		assert.NoError(t, m.Register(conf))
+		assert.NoError(t, m.Parse(conf))
+		calls := d.calls
+		auth := &Auth{Alg: "RS256"}
+		assert.NoError(t, m.ParseSub(auth, reflect.TypeOf(conf), "HTTP.Auth"))
+		assert.Equal(t, &Auth{Issuer: "me", Alg: "RS256"}, auth)
+		tls := &TLS{}
+		assert.NoError(t, m.ParseSub(tls, reflect.TypeOf(conf), "HTTP.TLS"))
+		assert.Equal(t, &TLS{Cert: "cert.pem"}, tls)
+		assert.Equal(t, calls, d.calls)
+	})
+
+	t.Run("cached parsed instance", func(t *testing.T) {
+		m, _ := New(WithDriver(&tlsMockDriver{values: values}))
+		assert.NoError(t, m.Register(&Config{}))
+		conf := &Config{}
+		assert.NoError(t, m.Parse(conf))
+		http := &HTTP{}
+		assert.NoError(t, m.Parse(http))
+		assert.Equal(t, "me", http.Auth.Issuer)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		m, _ := New(WithDriver(&tlsMockDriver{values: values}))
+		assert.ErrorIs(t, m.ParseSub(&Auth{}, reflect.TypeOf(Config{}), "HTTP.Auth"), ErrNotRegisteredConfig)
+		assert.NoError(t, m.Register(&Config{}))
+		assert.ErrorContains(t, m.ParseSub(&Auth{}, reflect.TypeOf(Config{}), "HTTP.Unknown"), "not found")
+		assert.ErrorContains(t, m.ParseSub(&TLS{}, reflect.TypeOf(Config{}), "HTTP.Auth"), "type")
+		assert.Error(t, m.ParseSub(Auth{}, reflect.TypeOf(Config{}), "HTTP.Auth"))
+	})
+
+	t.Run("ambiguous", func(t *testing.T) {
+		m, _ := New(WithDriver(&tlsMockDriver{values: values}))
+		assert.NoError(t, m.Register(&Config{}))
+		err := m.Parse(&Auth{})
+		assert.ErrorIs(t, err, ErrAmbiguousConfig)
+		assert.ErrorContains(t, err, "HTTP.Admin, ")
+		tls := &TLS{}
+		assert.NoError(t, m.Parse(tls))
+		assert.Equal(t, "cert.pem", tls.Cert)
+	})
+}
+
 func TestManager_Parse(t *testing.T) {
 	t.Parallel()
 	testCases := []struct {
@@ -472,6 +613,42 @@ func TestManager_Parse(t *testing.T) {
 	}
 }
 
+func TestManager_ParseInvalidValues(t *testing.T) {
+	type Config struct {
+		Name string
+		Port int
+		Tags []string
+		Size ByteSize
+	}
+	t.Run("conversion failures are skipped", func(t *testing.T) {
+		m, _ := New(WithDriver(&tlsMockDriver{values: map[string]string{"Name": "app", "Port": "http", "Tags": "[a b]"}}))
+		conf := &Config{Port: 80}
+		assert.NoError(t, m.Register(conf))
+		assert.NoError(t, m.Parse(conf))
+		assert.Equal(t, &Config{Name: "app", Port: 80}, conf)
+	})
+	t.Run("rejected values fail parse", func(t *testing.T) {
+		m, _ := New(WithDriver(&tlsMockDriver{values: map[string]string{"Name": "app", "Size": "10XB"}}))
+		conf := &Config{}
+		assert.NoError(t, m.Register(conf))
+		err := m.Parse(conf)
+		assert.ErrorIs(t, err, ErrInvalidValue)
+		var fieldErr *FieldError
+		if assert.ErrorAs(t, err, &fieldErr) {
+			assert.Equal(t, "Size", fieldErr.Path)
+		}
+		assert.Equal(t, "app", conf.Name)
+	})
+}
+
+func TestInvalidValue(t *testing.T) {
+	assert.NoError(t, InvalidValue(nil))
+	err := fmt.Errorf("failed to read: %w", InvalidValue(os.ErrNotExist))
+	assert.ErrorIs(t, err, ErrInvalidValue)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	assert.EqualError(t, err, "failed to read: "+os.ErrNotExist.Error())
+}
+
 func TestManager_GenDoc(t *testing.T) {
 	type fields struct {
 		drivers    []Driver
@@ -0,0 +1,21 @@
+// Package strings118 defines string functions of newer Go versions and string helpers shared by drivers.
+package strings118
+
+import "strings"
+
+// CutPrefix returns s without the provided leading prefix string
+// and reports whether it found the prefix.
+// If s doesn't start with prefix, CutPrefix returns s, false.
+// If prefix is the empty string, CutPrefix returns s, true.
+func CutPrefix(s, prefix string) (after string, found bool) {
+	if !strings.HasPrefix(s, prefix) {
+		return s, false
+	}
+	return s[len(prefix):], true
+}
+
+// ShellQuote quotes the value by single quotes for POSIX shells, single quotes of the value are escaped
+// by closing the quoted part, adding the escaped quote and reopening the quoted part.
+func ShellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
@@ -0,0 +1,160 @@
+package tinyconf
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+const pemSource = "pem"
+
+// loadPEM returns PEM data from the literal or reads it from the comma separated file paths.
+// It returns the source of the data: "pem" for literals and "file:<path>[,<path>]" for files.
+func loadPEM(value string) ([]byte, string, error) {
+	value = strings.TrimSpace(value)
+	if strings.Contains(value, "-----BEGIN") {
+		return []byte(value), pemSource, nil
+	}
+	if value == "" {
+		return nil, "", errors.New("neither PEM data nor file path is set")
+	}
+	var data []byte
+	paths := strings.Split(value, ",")
+	for i := range paths {
+		paths[i] = strings.TrimSpace(paths[i])
+		fileData, err := os.ReadFile(paths[i])
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read PEM file: %w", err)
+		}
+		data = append(data, fileData...)
+		data = append(data, '\n')
+	}
+	return data, "file:" + strings.Join(paths, ","), nil
+}
+
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no certificates found in PEM data")
+	}
+	return certs, nil
+}
+
+func checkCertificateValidity(cert *x509.Certificate, now time.Time) error {
+	if now.After(cert.NotAfter) {
+		return fmt.Errorf("certificate %q expired at %s", cert.Subject.String(), cert.NotAfter.Format(time.RFC3339))
+	}
+	if now.Before(cert.NotBefore) {
+		return fmt.Errorf("certificate %q is not valid before %s", cert.Subject.String(), cert.NotBefore.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// CertPool is a pool of CA certificates, it is decoded from PEM data or from comma separated PEM file paths.
+// All certificates are checked for expiration on decode.
+type CertPool struct {
+	Pool         *x509.CertPool
+	Certificates []*x509.Certificate
+	source       string
+}
+
+func (p *CertPool) UnmarshalText(text []byte) error {
+	data, source, err := loadPEM(string(text))
+	if err != nil {
+		return err
+	}
+	certs, err := parseCertificates(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", source, err)
+	}
+	pool := x509.NewCertPool()
+	now := time.Now()
+	for _, cert := range certs {
+		if err = checkCertificateValidity(cert, now); err != nil {
+			return fmt.Errorf("%s: %w", source, err)
+		}
+		pool.AddCert(cert)
+	}
+	*p = CertPool{Pool: pool, Certificates: certs, source: source}
+	return nil
+}
+
+// Source returns where certificates were loaded from: "pem" or "file:<path>".
+func (p CertPool) Source() string {
+	return p.source
+}
+
+func (p CertPool) String() string {
+	if len(p.Certificates) == 0 {
+		return ""
+	}
+	return p.source
+}
+
+// KeyPair is a certificate chain with the private key, it is decoded from PEM data with certificate and key blocks,
+// from a single PEM file or from "cert.pem,key.pem" file paths.
+// The leaf certificate is checked for expiration and the private key is checked to match it on decode.
+type KeyPair struct {
+	Certificate tls.Certificate
+	Leaf        *x509.Certificate
+	source      string
+}
+
+func (p *KeyPair) UnmarshalText(text []byte) error {
+	data, source, err := loadPEM(string(text))
+	if err != nil {
+		return err
+	}
+	var certPEM, keyPEM bytes.Buffer
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type == "CERTIFICATE" {
+			_ = pem.Encode(&certPEM, block)
+			continue
+		}
+		if strings.HasSuffix(block.Type, "PRIVATE KEY") {
+			_ = pem.Encode(&keyPEM, block)
+		}
+	}
+	cert, err := tls.X509KeyPair(certPEM.Bytes(), keyPEM.Bytes())
+	if err != nil {
+		return fmt.Errorf("%s: %w", source, err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return fmt.Errorf("%s: failed to parse certificate: %w", source, err)
+	}
+	if err = checkCertificateValidity(leaf, time.Now()); err != nil {
+		return fmt.Errorf("%s: %w", source, err)
+	}
+	cert.Leaf = leaf
+	*p = KeyPair{Certificate: cert, Leaf: leaf, source: source}
+	return nil
+}
+
+// Source returns where the key pair was loaded from: "pem" or "file:<path>".
+func (p KeyPair) Source() string {
+	return p.source
+}
+
+func (p KeyPair) String() string {
+	if p.Leaf == nil {
+		return ""
+	}
+	return p.source
+}
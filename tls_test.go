@@ -0,0 +1,180 @@
+package tinyconf
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/insei/fmap/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func generateTestKeyPair(t *testing.T, notBefore, notAfter time.Time) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "tinyconf"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM
+}
+
+func writeTestFile(t *testing.T, dir, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	assert.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+func TestKeyPair_UnmarshalText(t *testing.T) {
+	now := time.Now()
+	certPEM, keyPEM := generateTestKeyPair(t, now.Add(-time.Hour), now.Add(time.Hour))
+	expiredCertPEM, expiredKeyPEM := generateTestKeyPair(t, now.Add(-2*time.Hour), now.Add(-time.Hour))
+	_, otherKeyPEM := generateTestKeyPair(t, now.Add(-time.Hour), now.Add(time.Hour))
+
+	dir := t.TempDir()
+	certPath := writeTestFile(t, dir, "cert.pem", certPEM)
+	keyPath := writeTestFile(t, dir, "key.pem", keyPEM)
+	bundlePath := writeTestFile(t, dir, "bundle.pem", append(append([]byte{}, certPEM...), keyPEM...))
+
+	tests := []struct {
+		name           string
+		value          string
+		expectedSource string
+		wantErr        bool
+	}{
+		{name: "pem literal", value: string(certPEM) + string(keyPEM), expectedSource: "pem"},
+		{name: "cert and key files", value: certPath + "," + keyPath, expectedSource: "file:" + certPath + "," + keyPath},
+		{name: "bundle file", value: bundlePath, expectedSource: "file:" + bundlePath},
+		{name: "missing file", value: filepath.Join(dir, "missing.pem"), wantErr: true},
+		{name: "key mismatch", value: string(certPEM) + string(otherKeyPEM), wantErr: true},
+		{name: "expired", value: string(expiredCertPEM) + string(expiredKeyPEM), wantErr: true},
+		{name: "empty", value: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pair := KeyPair{}
+			err := pair.UnmarshalText([]byte(tt.value))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "tinyconf", pair.Leaf.Subject.CommonName)
+			assert.Equal(t, tt.expectedSource, pair.Source())
+		})
+	}
+}
+
+func TestCertPool_UnmarshalText(t *testing.T) {
+	now := time.Now()
+	certPEM, _ := generateTestKeyPair(t, now.Add(-time.Hour), now.Add(time.Hour))
+	secondCertPEM, _ := generateTestKeyPair(t, now.Add(-time.Hour), now.Add(time.Hour))
+	futureCertPEM, _ := generateTestKeyPair(t, now.Add(time.Hour), now.Add(2*time.Hour))
+
+	dir := t.TempDir()
+	certPath := writeTestFile(t, dir, "ca.pem", certPEM)
+	secondCertPath := writeTestFile(t, dir, "ca2.pem", secondCertPEM)
+
+	tests := []struct {
+		name          string
+		value         string
+		expectedCount int
+		wantErr       bool
+	}{
+		{name: "pem literal", value: string(certPEM), expectedCount: 1},
+		{name: "files", value: certPath + ", " + secondCertPath, expectedCount: 2},
+		{name: "not yet valid", value: string(futureCertPEM), wantErr: true},
+		{name: "no certificates", value: "-----BEGIN NOTHING-----", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := CertPool{}
+			err := pool.UnmarshalText([]byte(tt.value))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, pool.Certificates, tt.expectedCount)
+			assert.NotNil(t, pool.Pool)
+		})
+	}
+}
+
+type tlsMockDriver struct {
+	values map[string]string
+}
+
+func (d *tlsMockDriver) GenDoc(...*Registered) string { return "" }
+func (d *tlsMockDriver) GetName() string              { return "tls" }
+func (d *tlsMockDriver) GetValue(field fmap.Field) (*Value, error) {
+	value, ok := d.values[field.GetStructPath()]
+	if !ok {
+		return nil, ErrValueNotFound
+	}
+	val, err := DecodeField(field, value)
+	if err != nil {
+		return nil, err
+	}
+	return &Value{Source: field.GetStructPath(), Value: val}, nil
+}
+
+func TestManager_ParseTLS(t *testing.T) {
+	type Config struct {
+		TLS struct {
+			CA      CertPool
+			KeyPair *KeyPair
+		}
+	}
+	now := time.Now()
+	certPEM, keyPEM := generateTestKeyPair(t, now.Add(-time.Hour), now.Add(time.Hour))
+	dir := t.TempDir()
+	certPath := writeTestFile(t, dir, "cert.pem", certPEM)
+	keyPath := writeTestFile(t, dir, "key.pem", keyPEM)
+
+	t.Run("valid", func(t *testing.T) {
+		m, _ := New(WithDriver(&tlsMockDriver{values: map[string]string{
+			"TLS.CA":      certPath,
+			"TLS.KeyPair": certPath + "," + keyPath,
+		}}))
+		conf := &Config{}
+		assert.NoError(t, m.Register(conf))
+		assert.NoError(t, m.Parse(conf))
+		assert.Len(t, conf.TLS.CA.Certificates, 1)
+		assert.NotNil(t, conf.TLS.KeyPair)
+		assert.Equal(t, "TLS.KeyPair (file:"+certPath+","+keyPath+")",
+			getValueSource(&Value{Source: "TLS.KeyPair", Value: conf.TLS.KeyPair}))
+	})
+	t.Run("invalid", func(t *testing.T) {
+		m, _ := New(WithDriver(&tlsMockDriver{values: map[string]string{
+			"TLS.KeyPair": filepath.Join(dir, "missing.pem"),
+		}}))
+		conf := &Config{}
+		assert.NoError(t, m.Register(conf))
+		err := m.Parse(conf)
+		var fieldErr *FieldError
+		assert.True(t, errors.As(err, &fieldErr))
+		assert.Equal(t, "TLS.KeyPair", fieldErr.Path)
+		assert.Equal(t, "tls", fieldErr.Driver)
+		assert.Nil(t, conf.TLS.KeyPair)
+	})
+}
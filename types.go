@@ -1,6 +1,7 @@
 package tinyconf
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/insei/fmap/v3"
@@ -9,12 +10,65 @@ import (
 var (
 	ErrNotRegisteredConfig  = fmt.Errorf("config is not registered")
 	ErrValueNotFound        = fmt.Errorf("value was not found")
+	ErrValueUnset           = fmt.Errorf("value was explicitly unset")
 	ErrIncorrectTagSettings = fmt.Errorf("incorrect tag settings")
+	ErrAmbiguousConfig      = fmt.Errorf("config is ambiguous")
+	ErrInvalidValue         = fmt.Errorf("invalid value")
 )
 
 type Value struct {
 	Source string
 	Value  interface{}
+	// Hidden masks the value in logs like `hidden:"true"` tag of the field, it is set by drivers of secrets.
+	Hidden bool
+}
+
+// Sourcer is implemented by values that are loaded from elsewhere than the driver value itself, e.g. files.
+type Sourcer interface {
+	Source() string
+}
+
+// FieldError is returned by Manager.Parse when the value of the config field is rejected (see ErrInvalidValue),
+// Parse returns the first of them after all fields are processed. Other driver errors are logged and the field is skipped.
+type FieldError struct {
+	Path   string
+	Driver string
+	Err    error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("failed to parse %s config field from %s driver: %s", e.Path, e.Driver, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// invalidValueError marks the error as ErrInvalidValue, the wrapped error is kept for errors.Is and errors.As.
+type invalidValueError struct {
+	err error
+}
+
+func (e invalidValueError) Error() string {
+	return e.err.Error()
+}
+
+func (e invalidValueError) Unwrap() error {
+	return e.err
+}
+
+func (e invalidValueError) Is(target error) bool {
+	return target == ErrInvalidValue
+}
+
+// InvalidValue marks the error of the rejected value as ErrInvalidValue, so Parse fails with FieldError instead of
+// skipping the field, e.g. TLS material or values of encoding.TextUnmarshaler types that failed validation.
+// Drivers wrap errors of Decode by %w, Decode marks errors of encoding.TextUnmarshaler and json.Unmarshaler itself.
+func InvalidValue(err error) error {
+	if err == nil || errors.Is(err, ErrInvalidValue) {
+		return err
+	}
+	return invalidValueError{err: err}
 }
 
 type Driver interface {
@@ -23,6 +77,18 @@ type Driver interface {
 	GetValue(field fmap.Field) (*Value, error)
 }
 
+// KeysDriver is implemented by drivers that discover keys of map[string]T sections entries,
+// e.g. keys of the yaml mapping. ErrValueNotFound is returned if the driver has no entries for the field.
+type KeysDriver interface {
+	GetKeys(field fmap.Field) ([]string, error)
+}
+
+// DefaultsDriver is implemented by drivers that provide default values, e.g. the tag driver.
+// Default values don't cause allocation of nil pointer to struct sections.
+type DefaultsDriver interface {
+	IsDefaults() bool
+}
+
 type Option interface {
 	apply(*Manager)
 }
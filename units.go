@@ -0,0 +1,225 @@
+package tinyconf
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ByteSize is a size in bytes, it is decoded from values like 512, 10MiB, 1.5GB.
+type ByteSize uint64
+
+const (
+	Byte ByteSize = 1
+	KiB           = 1024 * Byte
+	MiB           = 1024 * KiB
+	GiB           = 1024 * MiB
+	TiB           = 1024 * GiB
+	PiB           = 1024 * TiB
+	KB            = 1000 * Byte
+	MB            = 1000 * KB
+	GB            = 1000 * MB
+	TB            = 1000 * GB
+	PB            = 1000 * TB
+)
+
+var byteSizeUnits = []struct {
+	name string
+	size ByteSize
+}{
+	{"PiB", PiB}, {"TiB", TiB}, {"GiB", GiB}, {"MiB", MiB}, {"KiB", KiB},
+	{"PB", PB}, {"TB", TB}, {"GB", GB}, {"MB", MB}, {"KB", KB},
+	{"B", Byte},
+}
+
+// ParseByteSize parses byte size with optional decimal (KB, MB, GB, TB, PB) or binary (KiB, MiB, GiB, TiB, PiB) unit,
+// units are case-insensitive.
+func ParseByteSize(s string) (ByteSize, error) {
+	str := strings.TrimSpace(s)
+	numEnd := strings.IndexFunc(str, func(r rune) bool {
+		return !unicode.IsDigit(r) && r != '.'
+	})
+	if numEnd == -1 {
+		numEnd = len(str)
+	}
+	num, unit := str[:numEnd], strings.TrimSpace(str[numEnd:])
+	if num == "" {
+		return 0, fmt.Errorf("invalid byte size %q", s)
+	}
+	multiplier := Byte
+	if unit != "" {
+		found := false
+		for _, u := range byteSizeUnits {
+			if strings.EqualFold(u.name, unit) {
+				multiplier, found = u.size, true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("invalid byte size %q: unknown unit %q", s, unit)
+		}
+	}
+	if !strings.Contains(num, ".") {
+		val, err := strconv.ParseUint(num, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid byte size %q: %w", s, err)
+		}
+		if val > math.MaxUint64/uint64(multiplier) {
+			return 0, fmt.Errorf("invalid byte size %q: value out of range", s)
+		}
+		return ByteSize(val) * multiplier, nil
+	}
+	val, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid byte size %q: %w", s, err)
+	}
+	size := val * float64(multiplier)
+	if size >= math.MaxUint64 {
+		return 0, fmt.Errorf("invalid byte size %q: value out of range", s)
+	}
+	return ByteSize(math.Round(size)), nil
+}
+
+// String returns byte size with the largest unit that represents it exactly, binary units are preferred.
+func (b ByteSize) String() string {
+	if b == 0 {
+		return "0B"
+	}
+	for _, u := range byteSizeUnits {
+		if b%u.size == 0 {
+			return strconv.FormatUint(uint64(b/u.size), 10) + u.name
+		}
+	}
+	return strconv.FormatUint(uint64(b), 10) + "B"
+}
+
+func (b ByteSize) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	size, err := ParseByteSize(string(text))
+	if err != nil {
+		return err
+	}
+	*b = size
+	return nil
+}
+
+// Duration is a time.Duration that is decoded with days support, see ParseDuration.
+type Duration time.Duration
+
+const Day = 24 * time.Hour
+
+// ParseDuration parses duration like time.ParseDuration, but also accepts days unit, e.g. 7d or 1d12h30m.
+func ParseDuration(s string) (time.Duration, error) {
+	str := strings.TrimSpace(s)
+	sign := time.Duration(1)
+	if strings.HasPrefix(str, "-") || strings.HasPrefix(str, "+") {
+		if str[0] == '-' {
+			sign = -1
+		}
+		str = str[1:]
+	}
+	var days float64
+	if i := strings.IndexRune(str, 'd'); i != -1 {
+		val, err := strconv.ParseFloat(str[:i], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		days, str = val, str[i+1:]
+	}
+	var rest time.Duration
+	if str != "" {
+		var err error
+		if rest, err = time.ParseDuration(str); err != nil || rest < 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+	} else if days == 0 && !strings.HasSuffix(strings.TrimSpace(s), "d") {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return sign * (time.Duration(days*float64(Day)) + rest), nil
+}
+
+// FormatDuration returns duration in the compact form, that is accepted by ParseDuration, e.g. 1d12h or 1m30s.
+func FormatDuration(d time.Duration) string {
+	if d == 0 {
+		return "0s"
+	}
+	var b strings.Builder
+	if d < 0 {
+		b.WriteRune('-')
+		d = -d
+	}
+	if days := d / Day; days > 0 {
+		b.WriteString(strconv.FormatInt(int64(days), 10) + "d")
+		d -= days * Day
+		if d == 0 {
+			return b.String()
+		}
+	}
+	str := d.String()
+	if strings.HasSuffix(str, "m0s") {
+		str = strings.TrimSuffix(str, "0s")
+	}
+	if strings.HasSuffix(str, "h0m") {
+		str = strings.TrimSuffix(str, "0m")
+	}
+	b.WriteString(str)
+	return b.String()
+}
+
+func (d Duration) String() string {
+	return FormatDuration(time.Duration(d))
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	duration, err := ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(duration)
+	return nil
+}
+
+// Percent is a ratio, that is decoded from percentage like 75% or from fraction like 0.75.
+type Percent float64
+
+// ParsePercent parses percentage (75%, 12.5%) or fraction (0.75) to the ratio.
+func ParsePercent(s string) (Percent, error) {
+	str := strings.TrimSpace(s)
+	divider := 1.0
+	if strings.HasSuffix(str, "%") {
+		str, divider = strings.TrimSpace(strings.TrimSuffix(str, "%")), 100
+	}
+	val, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid percent %q", s)
+	}
+	return Percent(val / divider), nil
+}
+
+func (p Percent) String() string {
+	percentage := math.Round(float64(p)*100*1e6) / 1e6
+	return strconv.FormatFloat(percentage, 'f', -1, 64) + "%"
+}
+
+func (p Percent) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+func (p *Percent) UnmarshalText(text []byte) error {
+	percent, err := ParsePercent(string(text))
+	if err != nil {
+		return err
+	}
+	*p = percent
+	return nil
+}
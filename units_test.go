@@ -0,0 +1,146 @@
+package tinyconf
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected ByteSize
+		wantErr  bool
+	}{
+		{value: "512", expected: 512},
+		{value: "512B", expected: 512},
+		{value: "10MiB", expected: 10 * MiB},
+		{value: "10 mib", expected: 10 * MiB},
+		{value: "10MB", expected: 10 * MB},
+		{value: "1.5GiB", expected: GiB + 512*MiB},
+		{value: "2KB", expected: 2000},
+		{value: "MiB", wantErr: true},
+		{value: "10XB", wantErr: true},
+		{value: "-1KB", wantErr: true},
+		{value: "20000000PiB", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			size, err := ParseByteSize(tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, size)
+		})
+	}
+}
+
+func TestByteSize_String(t *testing.T) {
+	assert.Equal(t, "0B", ByteSize(0).String())
+	assert.Equal(t, "10MiB", (10 * MiB).String())
+	assert.Equal(t, "3MB", (3 * MB).String())
+	assert.Equal(t, "1536MiB", (GiB + 512*MiB).String())
+	assert.Equal(t, "1001B", ByteSize(1001).String())
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected time.Duration
+		wantErr  bool
+	}{
+		{value: "1m30s", expected: 90 * time.Second},
+		{value: "7d", expected: 7 * Day},
+		{value: "1d12h", expected: 36 * time.Hour},
+		{value: "1.5d", expected: 36 * time.Hour},
+		{value: "-2d", expected: -2 * Day},
+		{value: "0d", expected: 0},
+		{value: "", wantErr: true},
+		{value: "d", wantErr: true},
+		{value: "12h1d", wantErr: true},
+		{value: "1d-1h", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			duration, err := ParseDuration(tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, duration)
+		})
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		0:                                "0s",
+		10 * time.Second:                 "10s",
+		90 * time.Second:                 "1m30s",
+		2 * time.Hour:                    "2h",
+		time.Hour + 30*time.Minute:       "1h30m",
+		36 * time.Hour:                   "1d12h",
+		7 * Day:                          "7d",
+		-(Day + time.Minute):             "-1d1m",
+		1500 * time.Millisecond:          "1.5s",
+		Day + time.Hour + 20*time.Second: "1d1h0m20s",
+	}
+	for duration, expected := range tests {
+		t.Run(expected, func(t *testing.T) {
+			assert.Equal(t, expected, FormatDuration(duration))
+			parsed, err := ParseDuration(expected)
+			assert.NoError(t, err)
+			assert.Equal(t, duration, parsed)
+		})
+	}
+}
+
+func TestParsePercent(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected Percent
+		wantErr  bool
+	}{
+		{value: "75%", expected: 0.75},
+		{value: "12.5 %", expected: 0.125},
+		{value: "0.3", expected: 0.3},
+		{value: "%", wantErr: true},
+		{value: "abc", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			percent, err := ParsePercent(tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.InDelta(t, float64(tt.expected), float64(percent), 1e-9)
+		})
+	}
+	assert.Equal(t, "7%", Percent(0.07).String())
+	assert.Equal(t, "12.5%", Percent(0.125).String())
+}
+
+func TestDecodeUnits(t *testing.T) {
+	size, err := Decode("10MiB", reflect.TypeOf(ByteSize(0)))
+	assert.NoError(t, err)
+	assert.Equal(t, 10*MiB, size)
+
+	duration, err := Decode("1d", reflect.TypeOf(time.Duration(0)))
+	assert.NoError(t, err)
+	assert.Equal(t, Day, duration)
+
+	duration, err = Decode("1d", reflect.TypeOf(Duration(0)))
+	assert.NoError(t, err)
+	assert.Equal(t, Duration(Day), duration)
+
+	percent, err := DecodeAny(float64(0.5), reflect.TypeOf(Percent(0)))
+	assert.NoError(t, err)
+	assert.Equal(t, Percent(0.5), percent)
+}
@@ -0,0 +1,182 @@
+package tinyconf
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/insei/fmap/v3"
+
+	"github.com/insei/tinyconf/strings118"
+)
+
+var (
+	variantsMu sync.RWMutex
+	variants   = map[reflect.Type]map[string]reflect.Type{}
+)
+
+// RegisterVariant registers the concrete type of interface I config fields by the name, that is selected by
+// the discriminator key value, e.g. RegisterVariant[Storage]("s3", S3Config{}) for `type: s3` storage section.
+// The variant must be a struct or a pointer to struct, the field gets the variant of the same kind.
+func RegisterVariant[I any](name string, variant I) {
+	typeOf := reflect.TypeOf((*I)(nil)).Elem()
+	if typeOf.Kind() != reflect.Interface {
+		panic(fmt.Sprintf("tinyconf: variant %q can be registered only for interface type, got %s", name, typeOf))
+	}
+	variantType := reflect.TypeOf(variant)
+	if variantType == nil || derefType(variantType).Kind() != reflect.Struct {
+		panic(fmt.Sprintf("tinyconf: variant %q of %s must be a struct or a pointer to struct", name, typeOf))
+	}
+	variantsMu.Lock()
+	defer variantsMu.Unlock()
+	if variants[typeOf] == nil {
+		variants[typeOf] = map[string]reflect.Type{}
+	}
+	variants[typeOf][name] = variantType
+}
+
+type variantOption struct {
+	register func()
+}
+
+func (o variantOption) apply(*Manager) {
+	if o.register != nil {
+		o.register()
+	}
+}
+
+// WithVariant registers the variant of interface I config fields, see RegisterVariant.
+// Variants are shared by all managers and drivers in the process.
+func WithVariant[I any](name string, variant I) Option {
+	return variantOption{register: func() {
+		RegisterVariant[I](name, variant)
+	}}
+}
+
+func derefType(typeOf reflect.Type) reflect.Type {
+	for typeOf.Kind() == reflect.Ptr {
+		typeOf = typeOf.Elem()
+	}
+	return typeOf
+}
+
+func getVariant(typeOf reflect.Type, name string) (reflect.Type, bool) {
+	variantsMu.RLock()
+	defer variantsMu.RUnlock()
+	variantType, ok := variants[typeOf][name]
+	return variantType, ok
+}
+
+// getVariantNames returns sorted names of typeOf variants.
+func getVariantNames(typeOf reflect.Type) []string {
+	variantsMu.RLock()
+	defer variantsMu.RUnlock()
+	names := make([]string, 0, len(variants[typeOf]))
+	for name := range variants[typeOf] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// getVariantName returns the name of the variant type of value, it returns empty string for nil or unregistered types.
+func getVariantName(typeOf reflect.Type, value any) string {
+	valueType := reflect.TypeOf(value)
+	if valueType == nil {
+		return ""
+	}
+	variantsMu.RLock()
+	defer variantsMu.RUnlock()
+	for name, variantType := range variants[typeOf] {
+		if variantType == valueType {
+			return name
+		}
+	}
+	return ""
+}
+
+// isVariantSection reports whether the field is an interface with registered variants, that is parsed as the section
+// of the variant type selected by the discriminator key.
+func isVariantSection(field fmap.Field) bool {
+	typeOf := field.GetType()
+	if typeOf.Kind() != reflect.Interface {
+		return false
+	}
+	variantsMu.RLock()
+	defer variantsMu.RUnlock()
+	return len(variants[typeOf]) > 0
+}
+
+// getDiscriminator returns the discriminator key and the default variant of the field set by
+// `variant:"key,default=name"` tag, the key is "type" by default.
+func getDiscriminator(field fmap.Field) (key string, defaultName string) {
+	key = "type"
+	parts := strings.Split(field.GetTag().Get("variant"), ",")
+	if parts[0] != "" {
+		key = parts[0]
+	}
+	for _, part := range parts[1:] {
+		if name, ok := strings118.CutPrefix(part, "default="); ok {
+			defaultName = name
+		}
+	}
+	return key, defaultName
+}
+
+// discriminatorField is the virtual string field of the variant section, its value selects the variant.
+// Its struct path is the section path with the key and all its tags are set to the key, e.g. `yaml:"type"`
+// for storage.type discriminator, so env driver composes STORAGE_TYPE key from `env:"STORAGE"` section tag.
+type discriminatorField struct {
+	fmap.Field
+	key   string
+	names []string
+}
+
+var typeOfString = reflect.TypeOf("")
+
+func (f *discriminatorField) GetKey() string {
+	return f.key
+}
+
+func (f *discriminatorField) GetName() string {
+	return f.key
+}
+
+func (f *discriminatorField) GetType() reflect.Type {
+	return typeOfString
+}
+
+func (f *discriminatorField) GetDereferencedType() reflect.Type {
+	return typeOfString
+}
+
+func (f *discriminatorField) GetTag() reflect.StructTag {
+	tag := replaceTagValues(f.Field.GetTag(), f.key)
+	return setTagValue(tag, "doc", "one of: "+strings.Join(f.names, ", "))
+}
+
+func (f *discriminatorField) GetStructPath() string {
+	return f.Field.GetStructPath() + "." + f.key
+}
+
+func (f *discriminatorField) GetParent() fmap.Field {
+	return f.Field
+}
+
+func (f *discriminatorField) GetTagPath(tag string, ignoreParentTagMissing bool) string {
+	return getTagPath(f, tag, ignoreParentTagMissing)
+}
+
+// variantField is the field of the variant section in documentation, its doc is prefixed by the discriminator,
+// e.g. (type=s3), because fields of all variants are documented together.
+type variantField struct {
+	fmap.Field
+	prefix string
+}
+
+func (f *variantField) GetTag() reflect.StructTag {
+	tag := f.Field.GetTag()
+	return setTagValue(tag, "doc", strings.TrimSpace(f.prefix+" "+tag.Get("doc")))
+}
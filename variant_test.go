@@ -0,0 +1,139 @@
+package tinyconf
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type variantTestStorage interface {
+	Kind() string
+}
+
+type variantTestS3 struct {
+	Bucket string `yaml:"bucket" doc:"bucket name"`
+	Region string `yaml:"region"`
+}
+
+func (variantTestS3) Kind() string { return "s3" }
+
+type variantTestFS struct {
+	Root string `yaml:"root"`
+}
+
+func (*variantTestFS) Kind() string { return "fs" }
+
+type variantTestMemory struct{}
+
+func (variantTestMemory) Kind() string { return "memory" }
+
+type variantTestConfig struct {
+	Storage variantTestStorage `yaml:"storage" variant:"type,default=fs"`
+	Cache   variantTestStorage `variant:"kind" alloc:"always"`
+}
+
+func init() {
+	RegisterVariant[variantTestStorage]("s3", variantTestS3{})
+	RegisterVariant[variantTestStorage]("fs", &variantTestFS{})
+	RegisterVariant[variantTestStorage]("memory", variantTestMemory{})
+}
+
+func TestManager_ParseVariants(t *testing.T) {
+	existingFS := &variantTestFS{Root: "/var"}
+	tests := []struct {
+		name     string
+		conf     *variantTestConfig
+		values   map[string]string
+		expected *variantTestConfig
+		wantErr  string
+	}{
+		{
+			name:     "not configured variant stays nil",
+			conf:     &variantTestConfig{},
+			values:   map[string]string{},
+			expected: &variantTestConfig{},
+		},
+		{
+			name:     "discriminator selects the variant",
+			conf:     &variantTestConfig{},
+			values:   map[string]string{"Storage.type": "s3", "Storage.Bucket": "data", "Cache.kind": "memory"},
+			expected: &variantTestConfig{Storage: variantTestS3{Bucket: "data", Region: "us-east-1"}, Cache: variantTestMemory{}},
+		},
+		{
+			name:     "default variant is allocated when its fields are found",
+			conf:     &variantTestConfig{},
+			values:   map[string]string{"Storage.Root": "/data"},
+			expected: &variantTestConfig{Storage: &variantTestFS{Root: "/data"}},
+		},
+		{
+			name:     "discriminator replaces the current variant",
+			conf:     &variantTestConfig{Storage: existingFS},
+			values:   map[string]string{"Storage.type": "memory"},
+			expected: &variantTestConfig{Storage: variantTestMemory{}},
+		},
+		{
+			name:     "current variant is updated in place",
+			conf:     &variantTestConfig{Storage: existingFS, Cache: variantTestS3{Bucket: "cache"}},
+			values:   map[string]string{"Storage.Root": "/data"},
+			expected: &variantTestConfig{Storage: existingFS, Cache: variantTestS3{Bucket: "cache"}},
+		},
+		{
+			name:    "unknown variant",
+			conf:    &variantTestConfig{},
+			values:  map[string]string{"Storage.type": "gcs"},
+			wantErr: `unknown variant "gcs", expected one of: fs, memory, s3`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := New(
+				WithDriver(&defaultsMockDriver{tlsMockDriver{values: map[string]string{
+					"Storage.Region": "us-east-1",
+					"Storage.type":   "s3",
+				}}}),
+				WithDriver(&tlsMockDriver{values: tt.values}),
+			)
+			assert.NoError(t, m.Register(tt.conf))
+			err := m.Parse(tt.conf)
+			if tt.wantErr != "" {
+				var fieldErr *FieldError
+				assert.True(t, errors.As(err, &fieldErr))
+				assert.Equal(t, "Storage.type", fieldErr.Path)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, tt.conf)
+		})
+	}
+	assert.Equal(t, "/data", existingFS.Root)
+}
+
+func TestRegistered_GetFieldsVariants(t *testing.T) {
+	r := &Registered{Storage: mustStorage[variantTestConfig](), Config: &variantTestConfig{Cache: variantTestS3{}}}
+	fields := map[string]ConfigField{}
+	for _, f := range r.GetFields() {
+		fields[f.Field.GetStructPath()] = f
+	}
+	assert.Equal(t, "fs", fields["Storage.type"].Value)
+	assert.Equal(t, `yaml:"type" variant:"type" doc:"one of: fs, memory, s3"`, string(fields["Storage.type"].Field.GetTag()))
+	assert.Equal(t, "storage.type", fields["Storage.type"].Field.GetTagPath("yaml", false))
+	assert.Equal(t, "s3", fields["Cache.kind"].Value)
+	assert.Equal(t, "(type=s3) bucket name", fields["Storage.Bucket"].Field.GetTag().Get("doc"))
+	assert.Equal(t, "storage.bucket", fields["Storage.Bucket"].Field.GetTagPath("yaml", false))
+	assert.Equal(t, "(type=fs)", fields["Storage.Root"].Field.GetTag().Get("doc"))
+	assert.True(t, IsSection(r.Storage.MustFind("Storage")))
+}
+
+func TestRegisterVariant_Invalid(t *testing.T) {
+	assert.Panics(t, func() { RegisterVariant[variantTestS3]("s3", variantTestS3{}) })
+	assert.Panics(t, func() { RegisterVariant[variantTestStorage]("nil", nil) })
+}
+
+func TestTagValues(t *testing.T) {
+	tag := reflect.StructTag(`yaml:"storage" doc:"with \"quotes\""`)
+	assert.Equal(t, reflect.StructTag(`yaml:"storage" doc:"new"`), setTagValue(tag, "doc", "new"))
+	assert.Equal(t, reflect.StructTag(`yaml:"storage" doc:"with \"quotes\"" env:"STORAGE"`), setTagValue(tag, "env", "STORAGE"))
+}
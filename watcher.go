@@ -0,0 +1,82 @@
+package tinyconf
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// watchErrorDelay is the delay of the next poll of WatchPolls after the failed poll.
+const watchErrorDelay = 5 * time.Second
+
+// Watcher is implemented by drivers of values that are changed at runtime, i.e. remote documents and KV stores.
+type Watcher interface {
+	// Poll reloads values and reports whether they are changed, the current values are kept on errors.
+	Poll(ctx context.Context) (bool, error)
+	// Watch polls values until ctx is done, fn is called with nil error when values are changed, i.e. to parse
+	// configs again, and with the error of the failed poll.
+	Watch(ctx context.Context, fn func(error))
+}
+
+// WatchPolls calls poll until ctx is done, it is the loop of Watcher.Watch implementations. next returns the delay
+// before the poll, fn is called with nil error when values are changed and with the error of the failed poll,
+// the next poll after the failed one is delayed by 5s more.
+func WatchPolls(ctx context.Context, next func() time.Duration, poll func(context.Context) (bool, error), fn func(error)) {
+	for {
+		if !waitContext(ctx, next()) {
+			return
+		}
+		changed, err := poll(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if changed || err != nil {
+			fn(err)
+		}
+		if err != nil && !waitContext(ctx, watchErrorDelay) {
+			return
+		}
+	}
+}
+
+// waitContext waits for the delay and reports false if ctx is done earlier.
+func waitContext(ctx context.Context, delay time.Duration) bool {
+	if delay <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// Watch runs Watch of drivers that implement Watcher until ctx is done. When values of some driver are changed,
+// the cache of parsed configs is reset (see ParseSub) and fn is called with nil error, i.e. to parse configs again,
+// fn is called with errors of failed polls too. Calls of fn are not concurrent.
+func (c *Manager) Watch(ctx context.Context, fn func(error)) {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, d := range c.getDrivers() {
+		watcher, ok := d.(Watcher)
+		if !ok {
+			continue
+		}
+		wg.Add(1)
+		go func(watcher Watcher) {
+			defer wg.Done()
+			watcher.Watch(ctx, func(err error) {
+				mu.Lock()
+				defer mu.Unlock()
+				if err == nil {
+					c.invalidate()
+				}
+				fn(err)
+			})
+		}(watcher)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,129 @@
+package tinyconf
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/insei/fmap/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchPolls(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	results := []struct {
+		changed bool
+		err     error
+	}{{changed: false}, {changed: true}, {err: errors.New("unavailable")}}
+	polls := 0
+	var reported []error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		WatchPolls(ctx, func() time.Duration { return time.Millisecond }, func(context.Context) (bool, error) {
+			result := results[polls]
+			polls++
+			return result.changed, result.err
+		}, func(err error) {
+			reported = append(reported, err)
+			if err != nil {
+				// the next poll is delayed after the error, the watch is stopped during the delay
+				cancel()
+			}
+		})
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch is not stopped")
+	}
+	assert.Equal(t, 3, polls)
+	assert.Equal(t, []error{nil, results[2].err}, reported)
+}
+
+// watcherMockDriver is the driver of the value that is changed by set, changes are reported by Watch.
+type watcherMockDriver struct {
+	mu      sync.Mutex
+	value   string
+	changed chan struct{}
+}
+
+func (d *watcherMockDriver) set(value string) {
+	d.mu.Lock()
+	d.value = value
+	d.mu.Unlock()
+	d.changed <- struct{}{}
+}
+
+func (d *watcherMockDriver) GetValue(field fmap.Field) (*Value, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return &Value{Source: "watcher", Value: d.value}, nil
+}
+
+func (d *watcherMockDriver) GetName() string {
+	return "watcher"
+}
+
+func (d *watcherMockDriver) GenDoc(...*Registered) string {
+	return ""
+}
+
+func (d *watcherMockDriver) Poll(ctx context.Context) (bool, error) {
+	select {
+	case <-ctx.Done():
+		return false, ctx.Err()
+	case <-d.changed:
+		return true, nil
+	}
+}
+
+func (d *watcherMockDriver) Watch(ctx context.Context, fn func(error)) {
+	WatchPolls(ctx, func() time.Duration { return 0 }, d.Poll, fn)
+}
+
+func TestManager_Watch(t *testing.T) {
+	type HTTP struct {
+		Host string
+	}
+	type Config struct {
+		HTTP HTTP
+	}
+	d := &watcherMockDriver{value: "v1", changed: make(chan struct{})}
+	m, _ := New(WithDriver(d))
+	conf := &Config{}
+	assert.NoError(t, m.Register(conf))
+	assert.NoError(t, m.Parse(conf))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := make(chan error)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.Watch(ctx, func(err error) {
+			changes <- err
+		})
+	}()
+	d.set("v2")
+	select {
+	case err := <-changes:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("change is not reported")
+	}
+	// the cached parsed config is not used after the change
+	http := &HTTP{}
+	assert.NoError(t, m.ParseSub(http, reflect.TypeOf(conf), "HTTP"))
+	assert.Equal(t, "v2", http.Host)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch is not stopped")
+	}
+}